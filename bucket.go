@@ -0,0 +1,69 @@
+package toggo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// bucketSet is the set of 0-99 rollout buckets selected by an OperatorBucketIn condition
+type bucketSet [100]bool
+
+// parseBucketSet reads a bucket list such as [0, 1, "10-19"].
+// Elements are single bucket numbers or inclusive "from-to" ranges
+func parseBucketSet(value interface{}) (bucketSet, error) {
+	var set bucketSet
+
+	if !isList(value) {
+		return set, ErrInvalidCondition
+	}
+
+	for _, item := range listItems(value) {
+		from, to, err := parseBucketRange(item)
+		if err != nil {
+			return set, err
+		}
+		for b := from; b <= to; b++ {
+			set[b] = true
+		}
+	}
+
+	return set, nil
+}
+
+// parseBucketRange reads a single bucket or an inclusive "from-to" range
+func parseBucketRange(item interface{}) (int, int, error) {
+	if s, ok := item.(string); ok && strings.Contains(s, "-") {
+		parts := strings.SplitN(s, "-", 2)
+		from, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+		to, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err1 != nil || err2 != nil || from > to || !validBucket(from) || !validBucket(to) {
+			return 0, 0, ErrInvalidCondition
+		}
+		return from, to, nil
+	}
+
+	n, err := toFloat64(item)
+	if err != nil || n != float64(int(n)) || !validBucket(int(n)) {
+		return 0, 0, ErrInvalidCondition
+	}
+	return int(n), int(n), nil
+}
+
+// validBucket reports whether b is a valid 0-99 bucket
+func validBucket(b int) bool {
+	return b >= 0 && b < 100
+}
+
+// bucketHashKey is the hash key for condition-level bucketing.
+// It depends only on the attribute value so a key lands in the same bucket in
+// every flag, which allows layering mutually exclusive slices across flags
+func bucketHashKey(value interface{}) string {
+	return fmt.Sprintf("bucket:%s", fmt.Sprint(value))
+}
+
+// sampleHashKey is the hash key for sample_percent. Like bucketHashKey it
+// depends only on the attribute value, but samples independently of bucket_in
+func sampleHashKey(value interface{}) string {
+	return fmt.Sprintf("sample:%s", fmt.Sprint(value))
+}
@@ -0,0 +1,205 @@
+package toggo
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// evaluationCache is an LRU cache of evaluation results with a TTL.
+// Entries are keyed by flag name plus the context attributes the flag
+// references, so contexts differing only in unrelated attributes share entries.
+//
+// Every invalidation advances the flag's generation. A result is only stored
+// if the generation is unchanged since its evaluation started, so a decision
+// made against a flag replaced mid-evaluation is never cached
+type evaluationCache struct {
+	mu          sync.Mutex
+	size        int
+	ttl         time.Duration
+	entries     map[string]*list.Element
+	order       *list.List
+	generations map[string]uint64
+	epoch       uint64
+}
+
+// cacheEntry is a single cached evaluation
+type cacheEntry struct {
+	key     string
+	flag    string
+	result  EvaluationResult
+	expires time.Time
+}
+
+// newEvaluationCache creates a cache holding at most size entries for ttl each
+func newEvaluationCache(size int, ttl time.Duration) *evaluationCache {
+	return &evaluationCache{
+		size:        size,
+		ttl:         ttl,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+		generations: make(map[string]uint64),
+	}
+}
+
+// generation returns the flag's current generation, to be passed to put
+func (c *evaluationCache) generation(flag string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.epoch + c.generations[flag]
+}
+
+// get returns a cached result that has not expired at now
+func (c *evaluationCache) get(key string, now time.Time) (EvaluationResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return EvaluationResult{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !now.Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return EvaluationResult{}, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.result, true
+}
+
+// put stores a result, evicting the least recently used entry when full.
+// The result is dropped if the flag was invalidated since generation was read
+func (c *evaluationCache) put(key, flag string, generation uint64, result EvaluationResult, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.epoch+c.generations[flag] != generation {
+		return
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.result, entry.expires = result, now.Add(c.ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, flag: flag, result: result, expires: now.Add(c.ttl)})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// invalidate drops all entries for a flag
+func (c *evaluationCache) invalidate(flag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[flag]++
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if entry := elem.Value.(*cacheEntry); entry.flag == flag {
+			c.order.Remove(elem)
+			delete(c.entries, entry.key)
+		}
+		elem = next
+	}
+}
+
+// clear drops every entry
+func (c *evaluationCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// readsClock reports whether a flag's decisions depend on the current time,
+// which the cache cannot see: schedules, ramps, expiry, variant windows and
+// time conditions
+func (f *Flag) readsClock() bool {
+	if f.Schedule != nil || f.Ramp != nil || f.TTL > 0 {
+		return true
+	}
+	for _, variant := range f.Variants {
+		if variant.StartsAt != nil || variant.EndsAt != nil {
+			return true
+		}
+	}
+	for _, list := range f.conditionLists() {
+		for _, cond := range *list {
+			if cond.usesClock() || cond.Operator == OperatorOlderThan || cond.Operator == OperatorNewerThan {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cacheKey builds the cache key for evaluating flag against ctx from the
+// attributes the flag references
+func cacheKey(flag *Flag, ctx Context) string {
+	attrs := referencedAttributes(flag)
+
+	var b strings.Builder
+	b.WriteString(flag.Name)
+	for _, attr := range attrs {
+		b.WriteByte(0)
+		b.WriteString(attr)
+		if value, ok := ctx.Get(attr); ok {
+			fmt.Fprintf(&b, "=%T:%v", value, value)
+		}
+	}
+	return b.String()
+}
+
+// referencedAttributes returns the sorted context attributes a flag reads:
+// condition attributes (in every condition list), the rollout keys,
+// seed attribute, required attributes and the cohort attribute
+func referencedAttributes(flag *Flag) []string {
+	seen := map[string]struct{}{flag.GetRolloutKey(): {}}
+	addCondition := func(cond Condition) {
+		if !cond.usesClock() {
+			seen[cond.Attribute] = struct{}{}
+		}
+		for _, attr := range templateAttributes(cond.Value) {
+			seen[attr] = struct{}{}
+		}
+	}
+	for _, list := range flag.conditionLists() {
+		for _, cond := range *list {
+			addCondition(cond)
+		}
+	}
+	for _, rule := range flag.RolloutKeyRules {
+		seen[rule.Key] = struct{}{}
+	}
+	for _, attr := range flag.RequiredAttributes {
+		seen[attr] = struct{}{}
+	}
+	if flag.CohortRollout != nil {
+		seen[flag.CohortRollout.Attribute] = struct{}{}
+	}
+	if flag.RolloutFromAttribute != "" {
+		seen[flag.RolloutFromAttribute] = struct{}{}
+	}
+	if flag.SeedAttribute != "" {
+		seen[flag.SeedAttribute] = struct{}{}
+	}
+
+	attrs := make([]string, 0, len(seen))
+	for attr := range seen {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	return attrs
+}
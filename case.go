@@ -0,0 +1,87 @@
+package toggo
+
+import "strings"
+
+// foldFlagCase returns a copy of flag with every context attribute it reads
+// lowercased, for stores created with WithCaseInsensitiveAttributes
+func foldFlagCase(flag *Flag) *Flag {
+	folded, _ := flag.mapConditionLists(func(conditions []Condition) ([]Condition, error) {
+		return foldConditionsCase(conditions), nil
+	})
+	for i := range folded.RolloutKeyRules {
+		folded.RolloutKeyRules[i].Key = strings.ToLower(folded.RolloutKeyRules[i].Key)
+	}
+	folded.RolloutKey = strings.ToLower(flag.RolloutKey)
+	folded.RolloutFromAttribute = strings.ToLower(flag.RolloutFromAttribute)
+	folded.SeedAttribute = strings.ToLower(flag.SeedAttribute)
+
+	if flag.RequiredAttributes != nil {
+		folded.RequiredAttributes = make([]string, len(flag.RequiredAttributes))
+		for i, attr := range flag.RequiredAttributes {
+			folded.RequiredAttributes[i] = strings.ToLower(attr)
+		}
+	}
+	if flag.AttributeSchema != nil {
+		folded.AttributeSchema = make(map[string]string, len(flag.AttributeSchema))
+		for attr, typ := range flag.AttributeSchema {
+			folded.AttributeSchema[strings.ToLower(attr)] = typ
+		}
+	}
+	if flag.CohortRollout != nil {
+		cohort := *flag.CohortRollout
+		cohort.Attribute = strings.ToLower(cohort.Attribute)
+		folded.CohortRollout = &cohort
+	}
+	return folded
+}
+
+func foldConditionsCase(conditions []Condition) []Condition {
+	if conditions == nil {
+		return nil
+	}
+	folded := make([]Condition, len(conditions))
+	for i, cond := range conditions {
+		folded[i] = cond
+		folded[i].Attribute = strings.ToLower(cond.Attribute)
+		if isTemplate(cond.Value) {
+			folded[i].Value = foldTemplateCase(cond.Value.(string))
+		}
+	}
+	return folded
+}
+
+// foldTemplateCase lowercases the attribute names inside {{attr}} templates
+func foldTemplateCase(value string) string {
+	var b strings.Builder
+	rest := value
+	for {
+		start := strings.Index(rest, templateOpen)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], templateClose)
+		if end < 0 {
+			break
+		}
+		b.WriteString(rest[:start+len(templateOpen)])
+		b.WriteString(strings.ToLower(rest[start+len(templateOpen) : start+end]))
+		b.WriteString(templateClose)
+		rest = rest[start+end+len(templateClose):]
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+// foldContextCase copies src into dst with lowercased keys. When keys differ
+// only in case, the one already in lowercase wins
+func foldContextCase(dst, src Context) {
+	for key, value := range src {
+		lower := strings.ToLower(key)
+		if lower != key {
+			if _, exact := src[lower]; exact {
+				continue
+			}
+		}
+		dst[lower] = value
+	}
+}
@@ -0,0 +1,143 @@
+package toggo
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// subscriptions holds the store's change subscribers
+type subscriptions struct {
+	mu   sync.Mutex
+	next int
+	fns  map[int]func(FlagChange)
+}
+
+// Subscribe registers fn to receive a ChangeAdded, ChangeUpdated or
+// ChangeRemoved event for every base flag that AddFlag, AddFlags, RemoveFlag,
+// RenameFlag, Merge, Clear or ReplaceAll changes. Old and New hold the flag
+// before and after. Adding an identical flag is not a change, and runtime
+// overrides are not reported. fn is called synchronously after the store
+// lock is released, so it may read the store. The returned function unsubscribes
+func (s *Store) Subscribe(fn func(FlagChange)) (unsubscribe func()) {
+	s.subs.mu.Lock()
+	defer s.subs.mu.Unlock()
+
+	if s.subs.fns == nil {
+		s.subs.fns = make(map[int]func(FlagChange))
+	}
+	id := s.subs.next
+	s.subs.next++
+	s.subs.fns[id] = fn
+
+	return func() {
+		s.subs.mu.Lock()
+		defer s.subs.mu.Unlock()
+		delete(s.subs.fns, id)
+	}
+}
+
+// publish delivers changes to every subscriber, in order
+func (s *Store) publish(changes []FlagChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	s.subs.mu.Lock()
+	fns := make([]func(FlagChange), 0, len(s.subs.fns))
+	for _, fn := range s.subs.fns {
+		fns = append(fns, fn)
+	}
+	s.subs.mu.Unlock()
+
+	for _, fn := range fns {
+		for _, change := range changes {
+			fn(change)
+		}
+	}
+}
+
+// appendChange appends the event for a flag going from before to after,
+// either of which may be nil. Unchanged flags add nothing
+func appendChange(changes []FlagChange, name string, before, after *Flag) []FlagChange {
+	switch {
+	case before == nil && after == nil:
+		return changes
+	case before == nil:
+		return append(changes, FlagChange{Flag: name, Kind: ChangeAdded, New: after})
+	case after == nil:
+		return append(changes, FlagChange{Flag: name, Kind: ChangeRemoved, Old: before})
+	case before == after || reflect.DeepEqual(before, after):
+		return changes
+	}
+	return append(changes, FlagChange{Flag: name, Kind: ChangeUpdated, Old: before, New: after})
+}
+
+// sortedNames returns the union of the maps' flag names, sorted
+func sortedNames(maps ...map[string]*Flag) []string {
+	seen := make(map[string]struct{})
+	for _, flags := range maps {
+		for name := range flags {
+			seen[name] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReplaceAll atomically replaces every flag in the store with flags, e.g. on a
+// config reload. Unlike Clear followed by AddFlags, evaluations never see a
+// partial set and subscribers get one event per flag that was added, updated
+// or removed, in name order, so they can invalidate precisely. Flags may only
+// extend flags in the same batch. Runtime overrides are kept. On error the
+// store is unchanged
+func (s *Store) ReplaceAll(flags []*Flag) error {
+	for _, flag := range flags {
+		if err := flag.Validate(); err != nil {
+			return err
+		}
+	}
+	ordered, err := orderByInheritance(flags)
+	if err != nil {
+		return err
+	}
+
+	var changes []FlagChange
+	defer func() { s.publish(changes) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	replaced := make(map[string]*Flag, len(ordered))
+	configs := make(map[string]*Flag, len(ordered))
+	for _, flag := range ordered {
+		resolved, err := s.resolveFlag(flag, replaced)
+		if err != nil {
+			return err
+		}
+		config := *flag
+		configs[flag.Name] = &config
+		replaced[flag.Name] = resolved
+	}
+
+	var diff []FlagChange
+	for _, name := range sortedNames(s.flags, replaced) {
+		diff = appendChange(diff, name, s.flags[name], replaced[name])
+	}
+
+	s.flags = replaced
+	s.configs = configs
+	for _, change := range diff {
+		s.invalidateCache(change.Flag)
+	}
+	changes = diff
+	return nil
+}
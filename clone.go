@@ -0,0 +1,154 @@
+package toggo
+
+import "reflect"
+
+// Clone returns a deep copy of the flag. Slices, maps and nested pointers
+// are copied, as are slice and map condition values and variant payloads,
+// so the clone can be modified without affecting the original
+func (f *Flag) Clone() *Flag {
+	if f == nil {
+		return nil
+	}
+
+	clone := *f
+	clone.Conditions = cloneConditions(f.Conditions)
+	clone.Variants = cloneVariants(f.Variants)
+	clone.SegmentOverrides = cloneSegmentOverrides(f.SegmentOverrides)
+	clone.RolloutKeyRules = cloneRolloutKeyRules(f.RolloutKeyRules)
+
+	if f.Dimensions != nil {
+		clone.Dimensions = make([]Dimension, len(f.Dimensions))
+		for i, dim := range f.Dimensions {
+			clone.Dimensions[i] = dim
+			clone.Dimensions[i].Variants = cloneVariants(dim.Variants)
+		}
+	}
+	if f.AttributeSchema != nil {
+		clone.AttributeSchema = make(map[string]string, len(f.AttributeSchema))
+		for attr, typ := range f.AttributeSchema {
+			clone.AttributeSchema[attr] = typ
+		}
+	}
+	if f.RequiredAttributes != nil {
+		clone.RequiredAttributes = append([]string(nil), f.RequiredAttributes...)
+	}
+	if f.Ramp != nil {
+		ramp := *f.Ramp
+		clone.Ramp = &ramp
+	}
+	if f.CohortRollout != nil {
+		cohort := *f.CohortRollout
+		cohort.Steps = append([]CohortStep(nil), f.CohortRollout.Steps...)
+		clone.CohortRollout = &cohort
+	}
+	if f.Schedule != nil {
+		schedule := *f.Schedule
+		schedule.Windows = make([]ScheduleWindow, len(f.Schedule.Windows))
+		for i, w := range f.Schedule.Windows {
+			schedule.Windows[i] = w
+			schedule.Windows[i].Weekdays = append([]string(nil), w.Weekdays...)
+			schedule.Windows[i].DaysOfMonth = append([]int(nil), w.DaysOfMonth...)
+		}
+		clone.Schedule = &schedule
+	}
+	if f.CreatedAt != nil {
+		createdAt := *f.CreatedAt
+		clone.CreatedAt = &createdAt
+	}
+	if f.Sticky != nil {
+		sticky := *f.Sticky
+		clone.Sticky = &sticky
+	}
+	return &clone
+}
+
+// Clone returns a deep copy of the variant, including its conditions, groups and payload
+func (v *Variant) Clone() Variant {
+	clone := *v
+	clone.Conditions = cloneConditions(v.Conditions)
+	clone.Groups = cloneGroups(v.Groups)
+	clone.Payload = cloneValue(v.Payload)
+	if v.StartsAt != nil {
+		startsAt := *v.StartsAt
+		clone.StartsAt = &startsAt
+	}
+	if v.EndsAt != nil {
+		endsAt := *v.EndsAt
+		clone.EndsAt = &endsAt
+	}
+	return clone
+}
+
+// Clone returns a deep copy of the condition. Slice and map values are
+// copied; other values are immutable or shared as-is
+func (c *Condition) Clone() Condition {
+	clone := *c
+	clone.Value = cloneValue(c.Value)
+	clone.Default = cloneValue(c.Default)
+	if c.Transform != nil {
+		clone.Transform = append([]Transform(nil), c.Transform...)
+	}
+	return clone
+}
+
+func cloneConditions(conditions []Condition) []Condition {
+	if conditions == nil {
+		return nil
+	}
+	clone := make([]Condition, len(conditions))
+	for i := range conditions {
+		clone[i] = conditions[i].Clone()
+	}
+	return clone
+}
+
+func cloneVariants(variants []Variant) []Variant {
+	if variants == nil {
+		return nil
+	}
+	clone := make([]Variant, len(variants))
+	for i := range variants {
+		clone[i] = variants[i].Clone()
+	}
+	return clone
+}
+
+// cloneValue deep copies the slices and maps in a configuration value
+func cloneValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return cloneReflect(reflect.ValueOf(value)).Interface()
+}
+
+func cloneReflect(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		clone := reflect.New(v.Type()).Elem()
+		clone.Set(cloneReflect(v.Elem()))
+		return clone
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		clone := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			clone.Index(i).Set(cloneReflect(v.Index(i)))
+		}
+		return clone
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		clone := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			clone.SetMapIndex(iter.Key(), cloneReflect(iter.Value()))
+		}
+		return clone
+	}
+	return v
+}
@@ -0,0 +1,66 @@
+package toggo
+
+import "time"
+
+// CohortRollout maps a date attribute (such as a signup date) to a rollout
+// percentage using a step function. Steps are checked in order and the first
+// whose Before date is after the context date applies, so they should be
+// listed from oldest to newest. Contexts newer than every step, or without a
+// parseable date, use the flag's regular rollout
+type CohortRollout struct {
+	// Attribute is the context attribute holding the cohort date
+	Attribute string `json:"attribute" yaml:"attribute"`
+
+	// Steps are the cohort boundaries, oldest first
+	Steps []CohortStep `json:"steps" yaml:"steps"`
+}
+
+// CohortStep applies Rollout to contexts dated before Before
+type CohortStep struct {
+	// Before is an RFC3339 timestamp or YYYY-MM-DD date
+	Before string `json:"before" yaml:"before"`
+
+	// Rollout is the percentage (0-100) for this cohort
+	Rollout int `json:"rollout" yaml:"rollout"`
+}
+
+// Validate checks if the cohort rollout configuration is valid
+func (c *CohortRollout) Validate() error {
+	if c.Attribute == "" || len(c.Steps) == 0 {
+		return ErrInvalidRollout
+	}
+	for _, step := range c.Steps {
+		if _, ok := parseTimeValue(step.Before); !ok {
+			return ErrInvalidRollout
+		}
+		if step.Rollout < 0 || step.Rollout > 100 {
+			return ErrInvalidRollout
+		}
+	}
+	return nil
+}
+
+// RolloutFor returns the rollout for the cohort the context belongs to.
+// Returns false if the context has no parseable date or is newer than every step
+func (c *CohortRollout) RolloutFor(ctx Context) (int, bool) {
+	value, ok := ctx.Get(c.Attribute)
+	if !ok {
+		return 0, false
+	}
+	joined, ok := parseTimeValue(value)
+	if !ok {
+		return 0, false
+	}
+	return c.rolloutAt(joined)
+}
+
+// rolloutAt returns the rollout of the first step whose boundary is after joined
+func (c *CohortRollout) rolloutAt(joined time.Time) (int, bool) {
+	for _, step := range c.Steps {
+		before, ok := parseTimeValue(step.Before)
+		if ok && joined.Before(before) {
+			return step.Rollout, true
+		}
+	}
+	return 0, false
+}
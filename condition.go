@@ -1,27 +1,206 @@
 package toggo
 
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
 // Condition represents a single evaluation condition
 type Condition struct {
-	// Attribute is the key to lookup in the context
+	// Attribute is the key to lookup in the context. The reserved "@now"
+	// reads the store clock instead; time operators may also leave it empty
 	Attribute string `json:"attribute" yaml:"attribute"`
 
 	// Operator is the comparison operator to use
 	Operator Operator `json:"operator" yaml:"operator"`
 
 	// Value is the value to compare against (can be string, number, array, etc.)
+	// String values may embed context attributes as {{attr}}, resolved at
+	// evaluation; a value that is exactly one template keeps the attribute's
+	// type. A missing attribute fails the condition
 	Value interface{} `json:"value" yaml:"value"`
 
+	// JSONPath optionally extracts a field from an attribute holding a JSON
+	// document, e.g. "$.subscription.tier". The operator is applied to the
+	// extracted value; invalid JSON or a missing path fails the condition
+	JSONPath string `json:"json_path,omitempty" yaml:"json_path,omitempty"`
+
+	// RegexExtract optionally applies a pattern with a capture group, e.g.
+	// "@(.+)$", and compares the first group's text instead of the whole
+	// value. It runs after Transform; a value that does not match fails the condition
+	RegexExtract string `json:"regex_extract,omitempty" yaml:"regex_extract,omitempty"`
+
+	// Transform lists normalizations applied in order to a string context
+	// value before the operator runs, e.g. ["trim", "lower"] or
+	// ["email_domain"]. A value that cannot be transformed fails the condition
+	Transform []Transform `json:"transform,omitempty" yaml:"transform,omitempty"`
+
+	// Quantifier, if set, applies the operator to each element of a list
+	// attribute: "any" matches if one element satisfies it, "all" if every
+	// element does, e.g. any past order amount > 100. Empty lists never match
+	Quantifier Quantifier `json:"quantifier,omitempty" yaml:"quantifier,omitempty"`
+
+	// ElementField is the dotted field compared in each element when the
+	// list holds objects, e.g. "amount" for past_orders[].amount.
+	// It requires a Quantifier
+	ElementField string `json:"element_field,omitempty" yaml:"element_field,omitempty"`
+
+	// Default is used as the context value when the attribute is missing.
+	// If nil, a missing attribute fails the condition
+	Default interface{} `json:"default,omitempty" yaml:"default,omitempty"`
+
 	// Negate inverts the condition result if true
 	Negate bool `json:"negate,omitempty" yaml:"negate,omitempty"`
+
+	// Score is added to the flag's total when the condition matches, see
+	// Flag.ScoreThreshold. It may be negative to penalize a match
+	Score int `json:"score,omitempty" yaml:"score,omitempty"`
+
+	// set indexes list values for in/not_in, built when the flag is added to a store
+	set StringSet
 }
 
 // Validate checks if the condition is properly formed
 func (c *Condition) Validate() error {
-	if c.Attribute == "" {
+	if c.Attribute == "" && !c.Operator.IsTimeOperator() {
 		return ErrInvalidCondition
 	}
 	if !c.Operator.IsValid() {
 		return ErrInvalidOperator
 	}
+	if c.Operator.IsListOperator() && !isListValue(c.Value) {
+		return ErrInvalidCondition
+	}
+	if c.JSONPath != "" {
+		if _, ok := parseJSONPath(c.JSONPath); !ok {
+			return ErrInvalidCondition
+		}
+	}
+	if !c.Quantifier.IsValid() || (c.ElementField != "" && c.Quantifier == "") {
+		return ErrInvalidCondition
+	}
+	for _, t := range c.Transform {
+		if !t.IsValid() {
+			return ErrInvalidCondition
+		}
+	}
+	if c.RegexExtract != "" {
+		re, err := compileRegex(c.RegexExtract)
+		if err != nil || re.NumSubexp() == 0 {
+			return ErrInvalidCondition
+		}
+	}
+	return c.validateValue()
+}
+
+// validateValue checks operator-specific constraints on the condition value
+func (c *Condition) validateValue() error {
+	switch c.Operator {
+	case OperatorDivisibleBy:
+		divisor, err := toFloat64(c.Value)
+		if err != nil || divisor == 0 || divisor != math.Trunc(divisor) {
+			return ErrInvalidCondition
+		}
+	case OperatorWithinRadius:
+		if _, ok := parseGeoRadius(c.Value); !ok {
+			return ErrInvalidCondition
+		}
+	case OperatorBucketIn:
+		if _, err := parseBucketSet(c.Value); err != nil {
+			return err
+		}
+	case OperatorNotMatchesRegex:
+		if _, err := compileRegex(fmt.Sprint(c.Value)); err != nil {
+			return ErrInvalidCondition
+		}
+	case OperatorTimeOfDayBetween:
+		if _, _, ok := parseTimeOfDayRange(c.Value); !ok {
+			return ErrInvalidCondition
+		}
+	case OperatorDayOfWeekIn:
+		if _, ok := parseWeekdays(c.Value); !ok {
+			return ErrInvalidCondition
+		}
+	case OperatorSamplePercent, OperatorPercentileAbove, OperatorTopPercent:
+		percent, err := toFloat64(c.Value)
+		if err != nil || percent < 0 || percent > 100 {
+			return ErrInvalidCondition
+		}
+	case OperatorOlderThan, OperatorNewerThan:
+		if _, ok := parseAge(c.Value); !ok {
+			return ErrInvalidCondition
+		}
+	case OperatorIsType:
+		if _, ok := parseTypeNames(c.Value); !ok {
+			return ErrInvalidCondition
+		}
+	case OperatorLengthBetween:
+		if _, _, ok := parseLengthRange(c.Value); !ok {
+			return ErrInvalidCondition
+		}
+	case OperatorCountGreaterThan, OperatorCountLessThan:
+		if _, err := toFloat64(c.Value); err != nil {
+			return ErrInvalidCondition
+		}
+	case OperatorSemverSatisfies:
+		expr, ok := c.Value.(string)
+		if !ok {
+			return ErrInvalidCondition
+		}
+		if _, err := parseSemverRange(expr); err != nil {
+			return ErrInvalidCondition
+		}
+	}
 	return nil
 }
+
+// Normalize converts the elements of list operator values to their canonical
+// string form so that mixed-type lists (e.g. [1, "2", true]) compare the same
+// way regardless of how the configuration format decoded them
+func (c *Condition) Normalize() {
+	if !c.Operator.IsListOperator() || !isList(c.Value) {
+		return
+	}
+
+	items := listItems(c.Value)
+	normalized := make([]string, len(items))
+	for i, item := range items {
+		normalized[i] = canonicalString(item)
+	}
+	c.Value = normalized
+}
+
+// isList reports whether value is a slice or array
+func isList(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	kind := reflect.TypeOf(value).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// isListValue reports whether value is acceptable for a list operator:
+// a slice or array, a StringSet, or a reference to a registered set
+func isListValue(value interface{}) bool {
+	if _, ok := value.(StringSet); ok {
+		return true
+	}
+	if _, ok := setRef(value); ok {
+		return true
+	}
+	return isList(value)
+}
+
+// listItems returns the elements of a slice or array value
+func listItems(value interface{}) []interface{} {
+	if items, ok := value.([]interface{}); ok {
+		return items
+	}
+	list := reflect.ValueOf(value)
+	items := make([]interface{}, list.Len())
+	for i := range items {
+		items[i] = list.Index(i).Interface()
+	}
+	return items
+}
@@ -1,21 +1,39 @@
 package toggo
 
+import (
+	"sync"
+	"sync/atomic"
+)
+
 // Context represents the evaluation context containing arbitrary attributes
 // used for feature flag evaluation. It can hold any key-value pairs such as
 // user_id, country, plan, etc.
+//
+// A value may also be a func() interface{} for attributes that are expensive
+// to compute. The function is only called when the attribute is read, and
+// each store call (an evaluation, or a batch such as EvaluatePrefix) calls
+// it at most once. The store never modifies the caller's Context.
 type Context map[string]interface{}
 
 // Get retrieves a value from the context by key.
 // Returns the value and a boolean indicating whether the key exists.
+// Lazy values are computed by every Get on the caller's Context; the store
+// resolves them into a per-call cache instead.
 func (c Context) Get(key string) (interface{}, bool) {
 	val, ok := c[key]
+	switch v := val.(type) {
+	case func() interface{}:
+		val = v()
+	case *lazyValue:
+		val = v.get()
+	}
 	return val, ok
 }
 
 // GetString retrieves a string value from the context.
 // Returns empty string if the key doesn't exist or value is not a string.
 func (c Context) GetString(key string) string {
-	val, ok := c[key]
+	val, ok := c.Get(key)
 	if !ok {
 		return ""
 	}
@@ -29,3 +47,121 @@ func (c Context) GetString(key string) string {
 func (c Context) Set(key string, value interface{}) {
 	c[key] = value
 }
+
+// hasLazyValues reports whether any value is an unresolved function
+func (c Context) hasLazyValues() bool {
+	for _, val := range c {
+		if _, lazy := val.(func() interface{}); lazy {
+			return true
+		}
+	}
+	return false
+}
+
+// lazyValue resolves a lazy attribute at most once, safely across goroutines
+type lazyValue struct {
+	once     sync.Once
+	fn       func() interface{}
+	value    interface{}
+	resolved atomic.Bool
+}
+
+func (l *lazyValue) get() interface{} {
+	l.once.Do(func() {
+		l.value = l.fn()
+		l.resolved.Store(true)
+	})
+	return l.value
+}
+
+// wrapLazyValues replaces the lazy functions in a store-owned context with
+// lazyValues, so each is computed at most once per store call
+func (c Context) wrapLazyValues() {
+	for key, val := range c {
+		if fn, lazy := val.(func() interface{}); lazy {
+			c[key] = &lazyValue{fn: fn}
+		}
+	}
+}
+
+// resolved returns the context without lazyValue wrappers, for handing to
+// hooks and sinks: resolved values in place of their wrappers and the
+// original functions for values never read. c itself is returned when it
+// holds no wrappers
+func (c Context) resolved() Context {
+	wrapped := false
+	for _, val := range c {
+		if _, ok := val.(*lazyValue); ok {
+			wrapped = true
+			break
+		}
+	}
+	if !wrapped {
+		return c
+	}
+	view := make(Context, len(c))
+	for key, val := range c {
+		if l, ok := val.(*lazyValue); ok {
+			if l.resolved.Load() {
+				val = l.value
+			} else {
+				val = l.fn
+			}
+		}
+		view[key] = val
+	}
+	return view
+}
+
+// ContextBuilder builds a Context with typed setters:
+//
+//	ctx := toggo.NewContext().String("country", "US").Int("age", 25).Bool("beta", true).Build()
+type ContextBuilder struct {
+	ctx Context
+}
+
+// NewContext starts building a Context
+func NewContext() *ContextBuilder {
+	return &ContextBuilder{ctx: make(Context)}
+}
+
+// String sets a string attribute
+func (b *ContextBuilder) String(key, value string) *ContextBuilder {
+	b.ctx[key] = value
+	return b
+}
+
+// Int sets an integer attribute
+func (b *ContextBuilder) Int(key string, value int) *ContextBuilder {
+	b.ctx[key] = value
+	return b
+}
+
+// Float sets a floating point attribute
+func (b *ContextBuilder) Float(key string, value float64) *ContextBuilder {
+	b.ctx[key] = value
+	return b
+}
+
+// Bool sets a boolean attribute
+func (b *ContextBuilder) Bool(key string, value bool) *ContextBuilder {
+	b.ctx[key] = value
+	return b
+}
+
+// Strings sets a list of strings attribute
+func (b *ContextBuilder) Strings(key string, values ...string) *ContextBuilder {
+	b.ctx[key] = values
+	return b
+}
+
+// Lazy sets an attribute computed only when a flag reads it
+func (b *ContextBuilder) Lazy(key string, fn func() interface{}) *ContextBuilder {
+	b.ctx[key] = fn
+	return b
+}
+
+// Build returns the built Context. The builder should not be reused afterwards
+func (b *ContextBuilder) Build() Context {
+	return b.ctx
+}
@@ -0,0 +1,76 @@
+package toggo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// WithContextHashFallback buckets contexts that lack a flag's rollout key by
+// a deterministic hash of all their attributes instead of excluding them.
+// This changes the missing-key behavior: such contexts are rolled out and
+// assigned variants like keyed users, and stay stable as long as their
+// attributes do. Any attribute change, including static attributes, may move
+// them to another bucket, and identical contexts always share one. Lazy
+// attributes are left out of the hash so it never forces them to be computed.
+// Conditions still see the context without a rollout key, and these
+// decisions bypass the evaluation cache
+func WithContextHashFallback() StoreOption {
+	return func(s *Store) {
+		s.contextHash = true
+	}
+}
+
+// bucketingContext returns the context used for rollout hashing: ctx itself,
+// or with the fallback enabled and the rollout key missing, a copy whose
+// rollout key holds a hash of the whole context
+func (s *Store) bucketingContext(flag *Flag, ctx Context) Context {
+	if !s.usesContextHash(flag, ctx) {
+		return ctx
+	}
+	bucketed := make(Context, len(ctx)+1)
+	for k, v := range ctx {
+		bucketed[k] = v
+	}
+	bucketed[flag.GetRolloutKey()] = contextHash(ctx)
+	return bucketed
+}
+
+// usesContextHash reports whether ctx may be bucketed by the context hash
+// fallback. For flags with rollout key rules this holds if any key they may
+// use is missing
+func (s *Store) usesContextHash(flag *Flag, ctx Context) bool {
+	if !s.contextHash {
+		return false
+	}
+	if _, exists := ctx.Get(flag.GetRolloutKey()); !exists {
+		return true
+	}
+	for _, rule := range flag.RolloutKeyRules {
+		if _, exists := ctx.Get(rule.Key); !exists {
+			return true
+		}
+	}
+	return false
+}
+
+// contextHash hashes the context's entries in key order, skipping lazy
+// values whether or not they have been computed, so the hash does not
+// depend on which attributes a flag happened to read
+func contextHash(ctx Context) string {
+	keys := make([]string, 0, len(ctx))
+	for k, v := range ctx {
+		switch v.(type) {
+		case func() interface{}, *lazyValue:
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := fnv.New64a()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%v\x00", k, ctx[k])
+	}
+	return fmt.Sprintf("ctx:%016x", h.Sum64())
+}
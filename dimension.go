@@ -0,0 +1,120 @@
+package toggo
+
+import "fmt"
+
+// Dimension is one independently assigned factor of a multivariate flag,
+// e.g. button color in a color x copy factorial experiment
+type Dimension struct {
+	// Name identifies the dimension in the composite assignment
+	Name string `json:"name" yaml:"name"`
+
+	// Variants are the dimension's levels. Weights work like flag variant
+	// weights: absolute 0-100 caps with the remainder going to Default
+	Variants []Variant `json:"variants" yaml:"variants"`
+
+	// Default is assigned when the bucket falls beyond the total weight
+	// or the selected variant's conditions fail
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// Validate checks if the dimension configuration is valid
+func (d *Dimension) Validate() error {
+	if d.Name == "" || len(d.Variants) == 0 {
+		return ErrInvalidCondition
+	}
+
+	totalWeight := 0
+	for _, variant := range d.Variants {
+		if variant.Weight < 0 || variant.Weight > 100 {
+			return ErrInvalidRollout
+		}
+		totalWeight += variant.Weight
+		for _, cond := range variant.Conditions {
+			if err := cond.Validate(); err != nil {
+				return err
+			}
+		}
+		for i := range variant.Groups {
+			if err := variant.Groups[i].Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	if totalWeight > 100 {
+		return ErrInvalidRollout
+	}
+	return nil
+}
+
+// GetMultivariate returns the composite assignment of a flag with Dimensions,
+// e.g. {"color": "blue", "copy": "short"}, and whether the flag is enabled.
+// The flag itself is gated like a simple flag (conditions and Rollout), then
+// every dimension is hashed independently so levels are uncorrelated
+func (s *Store) GetMultivariate(name string, ctx Context) (map[string]string, bool) {
+	assignment, err := s.GetMultivariateWithError(name, ctx)
+	if err != nil {
+		s.reportError(name, err)
+		return nil, false
+	}
+	return assignment, assignment != nil
+}
+
+// GetMultivariateWithError returns the composite assignment with detailed error
+// information. The assignment is nil when the flag is not enabled for ctx
+func (s *Store) GetMultivariateWithError(name string, ctx Context) (map[string]string, error) {
+	flag, err := s.GetFlag(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(flag.Dimensions) == 0 {
+		return nil, nil
+	}
+
+	ctx = s.evaluationContext(ctx)
+	result, err := s.evaluateInContext(flag, ctx)
+	if err != nil || !result.Enabled {
+		return nil, err
+	}
+
+	keyed, err := s.withRolloutKeyRule(s.evaluator, flag, ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyValue, exists := keyed.rolloutKeyValue(s.bucketingContext(keyed, ctx))
+	if !exists {
+		return nil, nil
+	}
+
+	assignment := make(map[string]string, len(flag.Dimensions))
+	for _, dim := range flag.Dimensions {
+		level, err := s.dimensionLevel(flag, dim, keyValue, ctx)
+		if err != nil {
+			return nil, err
+		}
+		assignment[dim.Name] = level
+	}
+	return assignment, nil
+}
+
+// dimensionLevel picks a dimension's variant from its own hash bucket
+func (s *Store) dimensionLevel(flag *Flag, dim Dimension, keyValue interface{}, ctx Context) (string, error) {
+	bucket := s.evaluator.hasher.Hash(fmt.Sprintf("%s:dimension:%s:%s", flag.hashSalt(), dim.Name, fmt.Sprint(keyValue)))
+
+	cumulative := 0
+	for i := range dim.Variants {
+		variant := &dim.Variants[i]
+		cumulative += variant.Weight
+		if bucket >= cumulative {
+			continue
+		}
+		match, err := s.variantEligible(s.evaluator, flag, variant, ctx)
+		if err != nil {
+			return "", err
+		}
+		if match {
+			return variant.Name, nil
+		}
+		break
+	}
+	return dim.Default, nil
+}
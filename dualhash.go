@@ -0,0 +1,69 @@
+package toggo
+
+import "time"
+
+// DualHashStrategy migrates rollout bucketing from one hasher to another
+// without turning anyone off. Until the migration ends, a user is rolled out
+// if either hasher rolls them out, so everyone enabled under the old hasher
+// stays enabled while users are also bucketed by the new one; the exposed
+// population is therefore the union of both and temporarily exceeds the
+// rollout percentage. Variant flags keep a user's variant from the old hasher
+// when it is not the default and otherwise use the new hasher. From the end
+// of the migration on only the new hasher is used. The end is compared
+// against the clock of the Store using the strategy (see WithClock), or
+// time.Now outside a Store.
+//
+// Condition-level hashing such as bucket_in and kill percentages is not
+// affected by the rollout strategy
+type DualHashStrategy struct {
+	oldStrategy *DefaultRolloutStrategy
+	newStrategy *DefaultRolloutStrategy
+	until       time.Time
+	clock       func() time.Time
+}
+
+// NewDualHashStrategy creates a strategy migrating from oldHasher to
+// newHasher until the given time, e.g.
+//
+//	toggo.NewDualHashStrategy(toggo.NewFNVHasher(), toggo.NewMurmur3Hasher(), deadline)
+func NewDualHashStrategy(oldHasher, newHasher Hasher, until time.Time) *DualHashStrategy {
+	return &DualHashStrategy{
+		oldStrategy: NewDefaultRolloutStrategy(oldHasher),
+		newStrategy: NewDefaultRolloutStrategy(newHasher),
+		until:       until,
+		clock:       time.Now,
+	}
+}
+
+// useStoreClock reads the time from the store's clock
+func (s *DualHashStrategy) useStoreClock(clock func() time.Time) {
+	s.clock = clock
+}
+
+// Migrating reports whether the migration window is still open
+func (s *DualHashStrategy) Migrating() bool {
+	return s.clock().Before(s.until)
+}
+
+// ShouldRollout rolls a user out if the new hasher does or, while migrating, the old one does
+func (s *DualHashStrategy) ShouldRollout(flag *Flag, ctx Context) (bool, error) {
+	if s.Migrating() {
+		enabled, err := s.oldStrategy.ShouldRollout(flag, ctx)
+		if err != nil || enabled {
+			return enabled, err
+		}
+	}
+	return s.newStrategy.ShouldRollout(flag, ctx)
+}
+
+// GetVariant keeps a non-default variant from the old hasher while migrating,
+// otherwise assigns with the new hasher
+func (s *DualHashStrategy) GetVariant(flag *Flag, ctx Context) (string, error) {
+	if s.Migrating() {
+		variant, err := s.oldStrategy.GetVariant(flag, ctx)
+		if err != nil || variant != flag.DefaultVariant {
+			return variant, err
+		}
+	}
+	return s.newStrategy.GetVariant(flag, ctx)
+}
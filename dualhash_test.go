@@ -0,0 +1,78 @@
+package toggo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDualHashStrategy_NoUserFlipsOff(t *testing.T) {
+	deadline := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	now := deadline.Add(-24 * time.Hour)
+	dual := NewDualHashStrategy(NewFNVHasher(), NewMurmur3Hasher(), deadline)
+
+	flag := &Flag{Name: "new_search", Enabled: true, Rollout: 30}
+	before := NewStore()
+	before.AddFlag(flag)
+	during := NewStore(WithRolloutStrategy(dual), WithClock(func() time.Time { return now }))
+	during.AddFlag(flag)
+	target := NewStore(WithRolloutStrategy(NewDefaultRolloutStrategy(NewMurmur3Hasher())))
+	target.AddFlag(flag)
+
+	newlyEnabled := 0
+	for i := 0; i < 1000; i++ {
+		ctx := Context{"user_id": fmt.Sprintf("user-%d", i)}
+		wasEnabled := before.IsEnabled("new_search", ctx)
+		enabled := during.IsEnabled("new_search", ctx)
+		if wasEnabled && !enabled {
+			t.Fatalf("user-%d flipped off during migration", i)
+		}
+		if target.IsEnabled("new_search", ctx) && !enabled {
+			t.Fatalf("user-%d enabled under the new hasher but not during migration", i)
+		}
+		if enabled && !wasEnabled {
+			newlyEnabled++
+		}
+	}
+	if newlyEnabled == 0 {
+		t.Error("expected the new hasher to enable additional users during migration")
+	}
+
+	// After the window only the new hasher is used
+	now = deadline
+	if dual.Migrating() {
+		t.Error("expected migration to be over")
+	}
+	for i := 0; i < 1000; i++ {
+		ctx := Context{"user_id": fmt.Sprintf("user-%d", i)}
+		if during.IsEnabled("new_search", ctx) != target.IsEnabled("new_search", ctx) {
+			t.Fatalf("user-%d: expected new hasher decision after migration", i)
+		}
+	}
+}
+
+func TestDualHashStrategy_Variants(t *testing.T) {
+	deadline := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	now := deadline.Add(-time.Hour)
+	dual := NewDualHashStrategy(NewFNVHasher(), NewMurmur3Hasher(), deadline)
+
+	flag := &Flag{
+		Name:           "layout",
+		Enabled:        true,
+		DefaultVariant: "holdout",
+		Variants:       []Variant{{Name: "holdout", Weight: 0}, {Name: "grid", Weight: 40}},
+	}
+	before := NewStore()
+	before.AddFlag(flag)
+	during := NewStore(WithRolloutStrategy(dual), WithClock(func() time.Time { return now }))
+	during.AddFlag(flag)
+
+	for i := 0; i < 1000; i++ {
+		ctx := Context{"user_id": fmt.Sprintf("user-%d", i)}
+		old, _ := before.GetVariant("layout", ctx)
+		current, _ := during.GetVariant("layout", ctx)
+		if old == "grid" && current != "grid" {
+			t.Fatalf("user-%d moved from grid to %s during migration", i, current)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package toggo
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrFlagNotFound is returned when a requested flag doesn't exist in the store
@@ -17,4 +20,75 @@ var (
 
 	// ErrRolloutKeyMissing is returned when the specified rollout key is not in context
 	ErrRolloutKeyMissing = errors.New("rollout key missing from context")
+
+	// ErrInvalidSchema is returned when a flag's attribute schema names an unknown type
+	ErrInvalidSchema = errors.New("invalid attribute schema")
+
+	// ErrAttributeType is returned in strict mode when a context value does not match the flag's attribute schema
+	ErrAttributeType = errors.New("attribute type mismatch")
+
+	// ErrRequiredAttributeMissing is returned in strict mode when a flag's required attribute is absent from the context
+	ErrRequiredAttributeMissing = errors.New("required attribute missing from context")
+
+	// ErrInheritanceCycle is returned when flags extend each other in a cycle
+	ErrInheritanceCycle = errors.New("flag inheritance cycle")
+
+	// ErrUnknownSet is returned when a condition references a set that was not registered
+	ErrUnknownSet = errors.New("unknown set")
+
+	// ErrInvalidSchedule is returned when a flag's recurring schedule is malformed
+	ErrInvalidSchedule = errors.New("invalid schedule")
+
+	// ErrReadOnly is returned when mutating a read-only store after its initial load
+	ErrReadOnly = errors.New("store is read-only")
+
+	// ErrEvaluationTimeout is returned when a flag decision exceeds the store's evaluation timeout
+	ErrEvaluationTimeout = errors.New("evaluation timed out")
+
+	// ErrInvalidVariant is returned when variant names are empty or duplicated,
+	// or DefaultVariant does not name one of the flag's variants
+	ErrInvalidVariant = errors.New("invalid variant")
+
+	// ErrFlagExists is returned when renaming a flag to a name already in use
+	ErrFlagExists = errors.New("flag already exists")
+
+	// ErrContextRequired is returned by GetCurrentVariant for flags whose
+	// variant depends on the evaluation context
+	ErrContextRequired = errors.New("flag requires an evaluation context")
+
+	// ErrInvalidPatch is returned when a JSON Patch is malformed, fails to
+	// apply or would rename the flag
+	ErrInvalidPatch = errors.New("invalid patch")
+
+	// ErrInvalidTTL is returned when a flag's TTL is negative or set without CreatedAt
+	ErrInvalidTTL = errors.New("invalid ttl")
+
+	// ErrFlagConflict is returned by Merge with MergeError when both stores define a flag
+	ErrFlagConflict = errors.New("flag defined in both stores")
 )
+
+// FlagValidationError reports which flag and field failed validation.
+// It wraps the underlying sentinel, so errors.Is(err, ErrInvalidRollout)
+// and similar checks keep working
+type FlagValidationError struct {
+	// FlagName is the name of the invalid flag
+	FlagName string
+
+	// Field is the offending field as written in configuration files,
+	// e.g. "rollout" or "variants[1].conditions[0]"
+	Field string
+
+	// Reason describes what is wrong with the field
+	Reason string
+
+	// Err is the sentinel error being wrapped
+	Err error
+}
+
+func (e *FlagValidationError) Error() string {
+	return fmt.Sprintf("flag %q: %s: %s", e.FlagName, e.Field, e.Reason)
+}
+
+func (e *FlagValidationError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,472 @@
+package toggo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Reason explains why an evaluation produced its result
+type Reason string
+
+const (
+	// ReasonDisabled means the flag is switched off
+	ReasonDisabled Reason = "disabled"
+
+	// ReasonConditionsNotMet means the flag's global conditions did not match
+	ReasonConditionsNotMet Reason = "conditions_not_met"
+
+	// ReasonRolloutIncluded means a simple flag matched and the user is inside the rollout
+	ReasonRolloutIncluded Reason = "rollout_included"
+
+	// ReasonRolloutExcluded means a simple flag matched but the user is outside the rollout
+	ReasonRolloutExcluded Reason = "rollout_excluded"
+
+	// ReasonVariantSelected means the weighted variant was selected and its conditions passed
+	ReasonVariantSelected Reason = "variant_selected"
+
+	// ReasonDefaultVariant means evaluation fell back to DefaultVariant
+	ReasonDefaultVariant Reason = "default_variant"
+
+	// ReasonDefaultVariantDenied means DefaultVariant names a variant whose conditions failed
+	ReasonDefaultVariantDenied Reason = "default_variant_denied"
+
+	// ReasonOutsideSchedule means the flag's recurring schedule is not active
+	ReasonOutsideSchedule Reason = "outside_schedule"
+
+	// ReasonExpired means the flag's TTL has elapsed
+	ReasonExpired Reason = "expired"
+
+	// ReasonKilled means the user would be enabled but falls inside the flag's KillPercent
+	ReasonKilled Reason = "killed"
+
+	// ReasonOverride means a runtime override forced the variant
+	ReasonOverride Reason = "override"
+
+	// ReasonSegmentOverride means the context matched a segment override that pinned the variant
+	ReasonSegmentOverride Reason = "segment_override"
+
+	// ReasonDryRun means the flag is in dry-run mode and the safe result was returned
+	ReasonDryRun Reason = "dry_run"
+)
+
+// EvaluationResult is the full outcome of evaluating a flag for a context
+type EvaluationResult struct {
+	// Flag is the name of the evaluated flag
+	Flag string `json:"flag"`
+
+	// Enabled reports whether the flag is on (or a variant was granted)
+	Enabled bool `json:"enabled"`
+
+	// Variant is the assigned variant; simple flags report "on" or "off"
+	Variant string `json:"variant"`
+
+	// Reason explains how the result was reached
+	Reason Reason `json:"reason"`
+}
+
+// EvaluationRecord is passed to the evaluation hook after every evaluation
+type EvaluationRecord struct {
+	// Result is the decision computed for the context. For dry-run flags this
+	// is the would-be decision, not the safe result returned to the caller
+	Result EvaluationResult
+
+	// Context is the context the flag was evaluated against
+	Context Context
+
+	// DryRun reports whether the flag is in dry-run mode
+	DryRun bool
+}
+
+// Evaluate evaluates a flag and returns the full result including the reason.
+// Unlike IsEnabled, a variant flag is reported as enabled when a variant is granted
+func (s *Store) Evaluate(name string, ctx Context) (EvaluationResult, error) {
+	flag, err := s.GetFlag(name)
+	if err != nil {
+		return EvaluationResult{}, err
+	}
+	return s.evaluateFlag(flag, ctx)
+}
+
+// EvaluateJSON evaluates a flag and returns the result marshaled as
+// {"flag": ..., "enabled": ..., "variant": ..., "reason": ...}
+func (s *Store) EvaluateJSON(name string, ctx Context) ([]byte, error) {
+	result, err := s.Evaluate(name, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// EligibleVariants returns the names of all variants whose own conditions
+// pass for ctx, ignoring weights, flag-level conditions and rollout.
+// It is meant for debugging variant targeting
+func (s *Store) EligibleVariants(name string, ctx Context) ([]string, error) {
+	flag, err := s.GetFlag(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = s.evaluationContext(ctx)
+	eligible := make([]string, 0, len(flag.Variants))
+	for i := range flag.Variants {
+		variant := &flag.Variants[i]
+		match, err := s.variantEligible(s.evaluator, flag, variant, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			eligible = append(eligible, variant.Name)
+		}
+	}
+	return eligible, nil
+}
+
+// CountEnabled returns how many simple flags are on for ctx, e.g. for a
+// status endpoint. The flags are collected under a single read lock and the
+// context is prepared once for all of them. Variant flags are not counted;
+// flags that fail to evaluate are reported to the error handler and skipped
+func (s *Store) CountEnabled(ctx Context) int {
+	count := 0
+	for _, enabled := range s.evaluateBatch(s.Snapshot().flags, ctx) {
+		if enabled {
+			count++
+		}
+	}
+	return count
+}
+
+// EvaluatePrefix evaluates every flag whose name starts with prefix, e.g.
+// "checkout." for a namespace, and returns IsEnabled's result by name. The
+// flags are collected under a single read lock and the context is prepared once
+func (s *Store) EvaluatePrefix(prefix string, ctx Context) map[string]bool {
+	return s.evaluateBatch(s.flagsWithPrefix(prefix), ctx)
+}
+
+// evaluateBatch evaluates flags against ctx with IsEnabled semantics,
+// preparing the context once. Variant flags are off; flags that fail to
+// evaluate are reported to the error handler and off
+func (s *Store) evaluateBatch(flags map[string]*Flag, ctx Context) map[string]bool {
+	ctx = s.evaluationContext(ctx)
+
+	results := make(map[string]bool, len(flags))
+	for name, flag := range flags {
+		if flag.HasVariants() {
+			results[name] = false
+			continue
+		}
+		result, err := s.evaluateInContext(flag, ctx)
+		if err != nil {
+			s.reportError(flag.Name, err)
+		}
+		results[name] = err == nil && result.Enabled
+	}
+	return results
+}
+
+// evaluateFlag computes the decision for a flag, reports it to the evaluation
+// hook and substitutes the safe result for dry-run flags
+func (s *Store) evaluateFlag(flag *Flag, ctx Context) (EvaluationResult, error) {
+	return s.evaluateInContext(flag, s.evaluationContext(ctx))
+}
+
+// evaluateInContext is evaluateFlag for a context already prepared by evaluationContext
+func (s *Store) evaluateInContext(flag *Flag, ctx Context) (EvaluationResult, error) {
+	if s.usage != nil {
+		s.usage.record(flag.Name, s.clock())
+	}
+	// Read before the override so a concurrent change to either is detected
+	generation, cacheable := s.cacheGeneration(flag)
+	flag, override, overridden := s.applyOverride(flag)
+
+	var result EvaluationResult
+	var err error
+	if overridden && override.Variant != "" && flag.Enabled {
+		result = EvaluationResult{Flag: flag.Name, Enabled: true, Variant: override.Variant, Reason: ReasonOverride}
+	} else if result, err = s.measuredDecide(flag, ctx, generation, cacheable); err != nil {
+		return EvaluationResult{}, err
+	}
+
+	if s.hook != nil || s.sink != nil {
+		record := EvaluationRecord{Result: result, Context: ctx.resolved(), DryRun: flag.DryRun}
+		if s.hook != nil {
+			s.hook(record)
+		}
+		if s.sink != nil {
+			s.sink.send(record)
+		}
+	}
+
+	if flag.DryRun {
+		return EvaluationResult{Flag: flag.Name, Variant: flag.DefaultVariant, Reason: ReasonDryRun}, nil
+	}
+	return result, nil
+}
+
+// evaluationContext layers the per-call context over the store's static attributes.
+// Contexts holding lazy values are copied with each function wrapped in a
+// lazyValue, so a value is computed at most once per call and the caller's
+// map is never written. Keys are lowercased for stores with case-insensitive attributes
+func (s *Store) evaluationContext(ctx Context) Context {
+	lazy := ctx.hasLazyValues() || s.staticAttrs.hasLazyValues()
+	if len(s.staticAttrs) == 0 && !lazy && !s.foldCase {
+		return ctx
+	}
+	merged := make(Context, len(s.staticAttrs)+len(ctx))
+	if s.foldCase {
+		foldContextCase(merged, s.staticAttrs)
+		foldContextCase(merged, ctx)
+	} else {
+		for k, v := range s.staticAttrs {
+			merged[k] = v
+		}
+		for k, v := range ctx {
+			merged[k] = v
+		}
+	}
+	if lazy {
+		merged.wrapLazyValues()
+	}
+	return merged
+}
+
+// cacheGeneration reads the cache generation for a flag about to be
+// evaluated. It reports false when the decision must not be cached: caching
+// is off, the flag is no longer the store's current version, or its
+// decisions are random per call or depend on the clock
+func (s *Store) cacheGeneration(flag *Flag) (uint64, bool) {
+	if s.cache == nil || !flag.IsSticky() || flag.readsClock() || timeBasedStrategy(s.rolloutStrategy) {
+		return 0, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.flags[flag.Name] != flag {
+		return 0, false
+	}
+	return s.cache.generation(flag.Name), true
+}
+
+// timeBasedStrategy reports whether a rollout strategy's decisions change
+// with the clock or runtime state such as a paused step rollout
+func timeBasedStrategy(strategy RolloutStrategy) bool {
+	switch strategy.(type) {
+	case *SwitchbackRolloutStrategy, *StepRolloutStrategy, *DualHashStrategy:
+		return true
+	}
+	return false
+}
+
+// cachedDecide serves decisions from the evaluation cache when cacheable,
+// storing new ones unless the flag changed since generation was read
+func (s *Store) cachedDecide(flag *Flag, ctx Context, generation uint64, cacheable bool) (EvaluationResult, error) {
+	// Context hash decisions depend on every attribute and are not cached
+	if !cacheable || s.usesContextHash(flag, ctx) {
+		return s.timedDecide(flag, ctx)
+	}
+
+	key := cacheKey(flag, ctx)
+	if result, ok := s.cache.get(key, s.clock()); ok {
+		return result, nil
+	}
+
+	result, err := s.timedDecide(flag, ctx)
+	if err != nil {
+		return EvaluationResult{}, err
+	}
+	s.cache.put(key, flag.Name, generation, result, s.clock())
+	return result, nil
+}
+
+// measuredDecide is cachedDecide, timed for the store's metrics if any
+func (s *Store) measuredDecide(flag *Flag, ctx Context, generation uint64, cacheable bool) (EvaluationResult, error) {
+	if s.metrics == nil {
+		return s.cachedDecide(flag, ctx, generation, cacheable)
+	}
+	start := time.Now()
+	result, err := s.cachedDecide(flag, ctx, generation, cacheable)
+	s.metrics.ObserveEvaluation(flag.Name, time.Since(start))
+	return result, err
+}
+
+// timedDecide runs decide under the store's evaluation timeout, if any. The
+// deadline is checked before each condition, so a slow condition delays the
+// timeout until it returns but no further conditions run
+func (s *Store) timedDecide(flag *Flag, ctx Context) (EvaluationResult, error) {
+	if s.timeout <= 0 {
+		return s.decide(s.evaluator, flag, ctx)
+	}
+
+	deadline, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	result, err := s.decide(s.evaluator.withDeadline(deadline), flag, ctx)
+	if errors.Is(err, ErrEvaluationTimeout) || (err == nil && deadline.Err() != nil) {
+		return EvaluationResult{}, fmt.Errorf("%w: %s after %s", ErrEvaluationTimeout, flag.Name, s.timeout)
+	}
+	return result, err
+}
+
+// matchConditions checks the flag's global conditions, with AND logic or
+// against its ScoreThreshold
+func (s *Store) matchConditions(eval *conditionEvaluator, flag *Flag, ctx Context) (bool, error) {
+	if flag.ScoreThreshold <= 0 {
+		return eval.evaluateAllWithSchema(flag.Conditions, ctx, flag.AttributeSchema)
+	}
+	score, err := eval.evaluateScore(flag.Conditions, ctx, flag.AttributeSchema)
+	return score >= flag.ScoreThreshold, err
+}
+
+// decide runs conditions, rollout and variant selection for a flag, with
+// conditions checked by eval
+func (s *Store) decide(eval *conditionEvaluator, flag *Flag, ctx Context) (EvaluationResult, error) {
+	result := EvaluationResult{Flag: flag.Name, Variant: flag.DefaultVariant}
+
+	// If flag is disabled, return default variant
+	if !flag.Enabled {
+		result.Reason = ReasonDisabled
+		return result, nil
+	}
+
+	if flag.ExpiredAt(s.clock()) {
+		result.Reason = ReasonExpired
+		return result, nil
+	}
+
+	if flag.Schedule != nil && !flag.Schedule.ActiveAt(s.clock()) {
+		result.Reason = ReasonOutsideSchedule
+		return result, nil
+	}
+
+	if s.evaluator.strict {
+		for _, attr := range flag.RequiredAttributes {
+			if _, ok := ctx.Get(attr); !ok {
+				return EvaluationResult{}, fmt.Errorf("%w: %s", ErrRequiredAttributeMissing, attr)
+			}
+		}
+	}
+
+	// Evaluate global flag conditions
+	match, err := s.matchConditions(eval, flag, ctx)
+	if err != nil {
+		return EvaluationResult{}, err
+	}
+
+	// If global conditions don't match, return default variant
+	if !match {
+		result.Reason = ReasonConditionsNotMet
+		return result, nil
+	}
+
+	// Rollout key rules pick the attribute this context is bucketed by
+	if flag, err = s.withRolloutKeyRule(eval, flag, ctx); err != nil {
+		return EvaluationResult{}, err
+	}
+
+	// Rollout hashing may use a context hash in place of a missing rollout key
+	bucketCtx := s.bucketingContext(flag, ctx)
+
+	// If no variants configured, this is a simple on/off flag
+	if !flag.HasVariants() {
+		// Apply rollout
+		shouldRollout, err := s.rolloutStrategy.ShouldRollout(s.withEffectiveRollout(flag, bucketCtx), bucketCtx)
+		if err != nil {
+			return EvaluationResult{}, err
+		}
+		switch {
+		case !shouldRollout:
+			result.Variant, result.Reason = "off", ReasonRolloutExcluded
+		case s.killed(flag, bucketCtx):
+			result.Variant, result.Reason = "off", ReasonKilled
+		default:
+			result.Enabled, result.Variant, result.Reason = true, "on", ReasonRolloutIncluded
+		}
+		return result, nil
+	}
+
+	if s.killed(flag, bucketCtx) {
+		result.Reason = ReasonKilled
+		return result, nil
+	}
+
+	segmentVariant, ok, err := s.matchSegmentOverride(eval, flag, ctx)
+	if err != nil {
+		return EvaluationResult{}, err
+	}
+	if ok {
+		result.Enabled, result.Variant, result.Reason = true, segmentVariant, ReasonSegmentOverride
+		return result, nil
+	}
+
+	// Get variant based on rollout strategy, among variants inside their window
+	variantName, err := s.selectVariant(withActiveVariants(flag, s.clock()), bucketCtx)
+	if err != nil {
+		return EvaluationResult{}, err
+	}
+
+	// Find the variant and check its conditions
+	if variant, ok := flag.GetVariantByName(variantName); ok {
+		match, err := s.variantEligible(eval, flag, variant, ctx)
+		if err != nil {
+			return EvaluationResult{}, err
+		}
+		if match {
+			result.Enabled, result.Variant, result.Reason = true, variant.Name, ReasonVariantSelected
+			return result, nil
+		}
+	}
+
+	return s.resolveDefaultVariant(eval, flag, ctx, result)
+}
+
+// selectVariant picks the weighted variant: deterministically through the
+// rollout strategy for sticky flags, by a fresh random draw otherwise
+func (s *Store) selectVariant(flag *Flag, ctx Context) (string, error) {
+	if flag.IsSticky() {
+		return s.rolloutStrategy.GetVariant(flag, ctx)
+	}
+
+	return variantAtBucket(flag, s.randIntn(100)), nil
+}
+
+// variantEligible checks a variant's window, own conditions and condition groups
+func (s *Store) variantEligible(eval *conditionEvaluator, flag *Flag, variant *Variant, ctx Context) (bool, error) {
+	if !variant.ActiveAt(s.clock()) {
+		return false, nil
+	}
+	match, err := eval.evaluateAllWithSchema(variant.Conditions, ctx, flag.AttributeSchema)
+	if err != nil || !match {
+		return false, err
+	}
+	return eval.evaluateGroups(variant.Groups, ctx, flag.AttributeSchema)
+}
+
+// resolveDefaultVariant is the last step of variant resolution, used when the
+// selected variant is ineligible. The resolution order is:
+//  1. If DefaultVariant is empty, the empty variant is returned and the flag
+//     is reported as not enabled. Validate guarantees a non-empty
+//     DefaultVariant names one of the flag's variants.
+//  2. Otherwise that variant's conditions are evaluated. When they pass the
+//     default is granted and reported as enabled; when they fail the default
+//     is denied and an empty variant is returned.
+func (s *Store) resolveDefaultVariant(eval *conditionEvaluator, flag *Flag, ctx Context, result EvaluationResult) (EvaluationResult, error) {
+	result.Reason = ReasonDefaultVariant
+
+	variant, ok := flag.GetVariantByName(flag.DefaultVariant)
+	if !ok {
+		return result, nil
+	}
+
+	match, err := s.variantEligible(eval, flag, variant, ctx)
+	if err != nil {
+		return EvaluationResult{}, err
+	}
+	if !match {
+		result.Variant, result.Reason = "", ReasonDefaultVariantDenied
+		return result, nil
+	}
+	result.Enabled, result.Variant = true, variant.Name
+	return result, nil
+}
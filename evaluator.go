@@ -1,33 +1,150 @@
 package toggo
 
 import (
+	"container/list"
+	"context"
 	"fmt"
+	"math"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/toggo/internal/hash"
 )
 
 // conditionEvaluator handles the evaluation of conditions against contexts
-type conditionEvaluator struct{}
+type conditionEvaluator struct {
+	hasher hash.Hasher
+
+	// strict turns type mismatches against an attribute schema into errors
+	strict bool
+
+	// reorder evaluates cheap conditions before expensive ones
+	reorder bool
+
+	// clock supplies the current time for conditions on "@now"
+	clock func() time.Time
+
+	// anyMatch compares list context values element-wise for equality and
+	// comparison operators, matching if any element does
+	anyMatch bool
+
+	// deadline, if set, is checked before each condition
+	deadline context.Context
+}
+
+// withDeadline returns a copy of the evaluator that fails with
+// ErrEvaluationTimeout once deadline is done
+func (e *conditionEvaluator) withDeadline(deadline context.Context) *conditionEvaluator {
+	timed := *e
+	timed.deadline = deadline
+	return &timed
+}
 
 // newConditionEvaluator creates a new condition evaluator
 func newConditionEvaluator() *conditionEvaluator {
-	return &conditionEvaluator{}
+	return &conditionEvaluator{
+		hasher: hash.NewFNV(),
+		clock:  time.Now,
+	}
 }
 
 // evaluate checks if a single condition matches the context
 func (e *conditionEvaluator) evaluate(condition Condition, ctx Context) (bool, error) {
+	return e.evaluateWithSchema(condition, ctx, nil)
+}
+
+// evaluateWithSchema checks if a single condition matches the context,
+// coercing the context value to the type declared in schema if any
+func (e *conditionEvaluator) evaluateWithSchema(condition Condition, ctx Context, schema map[string]string) (bool, error) {
+	if e.deadline != nil && e.deadline.Err() != nil {
+		return false, ErrEvaluationTimeout
+	}
 	if err := condition.Validate(); err != nil {
 		return false, err
 	}
 
-	value, exists := ctx.Get(condition.Attribute)
+	var value interface{}
+	var exists bool
+	if condition.usesClock() {
+		// In UTC so time of day and weekday don't depend on the host's zone
+		value, exists = e.clock().UTC(), true
+	} else {
+		value, exists = ctx.Get(condition.Attribute)
+	}
 	if !exists {
-		// If attribute doesn't exist in context, condition fails
-		return e.applyNegate(false, condition.Negate), nil
+		switch {
+		case condition.Default != nil:
+			value = condition.Default
+		case condition.Operator.treatsMissingAsEmpty():
+			// Emptiness checks see a missing attribute as empty (nil has length 0)
+		default:
+			// If attribute doesn't exist in context, condition fails
+			return e.applyNegate(false, condition.Negate), nil
+		}
 	}
 
-	result, err := e.evaluateOperator(condition.Operator, value, condition.Value)
+	if condition.JSONPath != "" {
+		extracted, ok := extractJSONPath(value, condition.JSONPath)
+		if !ok {
+			// Invalid JSON or a missing path fails the condition regardless of Negate
+			return false, nil
+		}
+		value = extracted
+	}
+
+	if len(condition.Transform) > 0 {
+		transformed, ok := applyTransforms(value, condition.Transform)
+		if !ok {
+			// An untransformable value fails the condition regardless of Negate
+			return false, nil
+		}
+		value = transformed
+	}
+
+	if condition.RegexExtract != "" {
+		extracted, ok := extractRegex(value, condition.RegexExtract)
+		if !ok {
+			// A value the pattern does not match fails the condition regardless of Negate
+			return false, nil
+		}
+		value = extracted
+	}
+
+	if typ, ok := schema[condition.Attribute]; ok {
+		coerced, ok := coerceAttribute(value, typ)
+		if !ok {
+			if e.strict {
+				return false, ErrAttributeType
+			}
+			// A mismatching type fails the condition regardless of Negate
+			return false, nil
+		}
+		value = coerced
+	}
+
+	condValue := condition.Value
+	if condition.set != nil {
+		condValue = condition.set
+	} else if isTemplate(condValue) {
+		resolved, ok := resolveTemplate(condValue.(string), ctx)
+		if !ok {
+			// An unresolvable template fails the condition regardless of Negate
+			return false, nil
+		}
+		condValue = resolved
+	}
+	var result bool
+	var err error
+	if condition.Quantifier != "" {
+		result, err = e.evaluateQuantified(condition, value, condValue)
+	} else {
+		result, err = e.evaluateOperator(condition.Operator, value, condValue)
+	}
 	if err != nil {
 		return false, err
 	}
@@ -37,8 +154,16 @@ func (e *conditionEvaluator) evaluate(condition Condition, ctx Context) (bool, e
 
 // evaluateAll checks if all conditions match (AND logic)
 func (e *conditionEvaluator) evaluateAll(conditions []Condition, ctx Context) (bool, error) {
+	return e.evaluateAllWithSchema(conditions, ctx, nil)
+}
+
+// evaluateAllWithSchema checks if all conditions match (AND logic) under an attribute schema
+func (e *conditionEvaluator) evaluateAllWithSchema(conditions []Condition, ctx Context, schema map[string]string) (bool, error) {
+	if e.reorder {
+		conditions = orderByCost(conditions)
+	}
 	for _, cond := range conditions {
-		match, err := e.evaluate(cond, ctx)
+		match, err := e.evaluateWithSchema(cond, ctx, schema)
 		if err != nil {
 			return false, err
 		}
@@ -49,6 +174,57 @@ func (e *conditionEvaluator) evaluateAll(conditions []Condition, ctx Context) (b
 	return true, nil
 }
 
+// evaluateScore sums the Score of every matching condition
+func (e *conditionEvaluator) evaluateScore(conditions []Condition, ctx Context, schema map[string]string) (int, error) {
+	total := 0
+	for _, cond := range conditions {
+		match, err := e.evaluateWithSchema(cond, ctx, schema)
+		if err != nil {
+			return 0, err
+		}
+		if match {
+			total += cond.Score
+		}
+	}
+	return total, nil
+}
+
+// orderByCost returns the conditions sorted by estimated evaluation cost,
+// cheapest first, so a failing cheap condition short-circuits before an
+// expensive one runs. The input is returned as-is when already ordered
+func orderByCost(conditions []Condition) []Condition {
+	sorted := sort.SliceIsSorted(conditions, func(i, j int) bool {
+		return conditionCost(conditions[i]) < conditionCost(conditions[j])
+	})
+	if sorted {
+		return conditions
+	}
+
+	ordered := make([]Condition, len(conditions))
+	copy(ordered, conditions)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return conditionCost(ordered[i]) < conditionCost(ordered[j])
+	})
+	return ordered
+}
+
+// conditionCost estimates the relative cost of evaluating a condition
+func conditionCost(c Condition) int {
+	cost := 1
+	switch c.Operator {
+	case OperatorContains, OperatorStartsWith, OperatorEndsWith, OperatorDivisibleBy:
+		cost = 2
+	case OperatorBucketIn, OperatorSemverSatisfies, OperatorWithinRadius:
+		cost = 4
+	case OperatorRegex, OperatorNotMatchesRegex:
+		cost = 10
+	}
+	if c.JSONPath != "" {
+		cost += 5
+	}
+	return cost
+}
+
 // applyNegate applies negation to the result if negate is true
 func (e *conditionEvaluator) applyNegate(result, negate bool) bool {
 	if negate {
@@ -59,6 +235,16 @@ func (e *conditionEvaluator) applyNegate(result, negate bool) bool {
 
 // evaluateOperator performs the actual comparison based on operator
 func (e *conditionEvaluator) evaluateOperator(op Operator, ctxValue, condValue interface{}) (bool, error) {
+	if e.anyMatch && isList(ctxValue) {
+		switch op {
+		case OperatorEqual, OperatorGreaterThan, OperatorGreaterThanOrEqual, OperatorLessThan, OperatorLessThanOrEqual:
+			return e.evaluateAnyMatch(op, ctxValue, condValue)
+		case OperatorNotEqual:
+			matched, err := e.evaluateAnyMatch(OperatorEqual, ctxValue, condValue)
+			return !matched, err
+		}
+	}
+
 	switch op {
 	case OperatorEqual:
 		return e.evaluateEqual(ctxValue, condValue), nil
@@ -84,25 +270,94 @@ func (e *conditionEvaluator) evaluateOperator(op Operator, ctxValue, condValue i
 		return e.evaluateEndsWith(ctxValue, condValue), nil
 	case OperatorRegex:
 		return e.evaluateRegex(ctxValue, condValue)
+	case OperatorNotMatchesRegex:
+		matched, err := e.evaluateRegex(ctxValue, condValue)
+		return !matched && err == nil, err
+	case OperatorDivisibleBy:
+		return e.evaluateDivisibleBy(ctxValue, condValue), nil
+	case OperatorWithinRadius:
+		return e.evaluateWithinRadius(ctxValue, condValue), nil
+	case OperatorBucketIn:
+		return e.evaluateBucketIn(ctxValue, condValue)
+	case OperatorSemverSatisfies:
+		return e.evaluateSemverSatisfies(ctxValue, condValue)
+	case OperatorCountGreaterThan:
+		return e.evaluateCount(ctxValue, condValue, func(n, limit float64) bool { return n > limit }), nil
+	case OperatorCountLessThan:
+		return e.evaluateCount(ctxValue, condValue, func(n, limit float64) bool { return n < limit }), nil
+	case OperatorTimeOfDayBetween:
+		return evaluateTimeOfDay(ctxValue, condValue), nil
+	case OperatorDayOfWeekIn:
+		return evaluateDayOfWeek(ctxValue, condValue), nil
+	case OperatorSamplePercent:
+		return e.evaluateSamplePercent(ctxValue, condValue), nil
+	case OperatorOlderThan:
+		return e.evaluateAge(ctxValue, condValue, func(age, limit time.Duration) bool { return age > limit }), nil
+	case OperatorNewerThan:
+		return e.evaluateAge(ctxValue, condValue, func(age, limit time.Duration) bool { return age < limit }), nil
+	case OperatorIsType:
+		return evaluateIsType(ctxValue, condValue), nil
+	case OperatorIsEmpty:
+		n, ok := valueLength(ctxValue)
+		return ok && n == 0, nil
+	case OperatorIsNotEmpty:
+		n, ok := valueLength(ctxValue)
+		return ok && n > 0, nil
+	case OperatorLengthBetween:
+		return evaluateLengthBetween(ctxValue, condValue), nil
+	case OperatorPercentileAbove:
+		return evaluatePercentile(ctxValue, condValue, func(rank, p float64) bool { return rank > p }), nil
+	case OperatorTopPercent:
+		return evaluatePercentile(ctxValue, condValue, func(rank, p float64) bool { return p > 0 && rank >= 100-p }), nil
 	default:
 		return false, ErrInvalidOperator
 	}
 }
 
+// evaluateAnyMatch applies op to each element of a list context value and
+// matches if any element does
+func (e *conditionEvaluator) evaluateAnyMatch(op Operator, ctxValue, condValue interface{}) (bool, error) {
+	for _, item := range listItems(ctxValue) {
+		matched, err := e.evaluateOperator(op, item, condValue)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+// evaluateIsType checks if the context value has one of the named types and is non-empty
+func evaluateIsType(ctxValue, condValue interface{}) bool {
+	types, ok := parseTypeNames(condValue)
+	if !ok {
+		return false
+	}
+	for _, typ := range types {
+		if hasType(ctxValue, typ) {
+			return true
+		}
+	}
+	return false
+}
+
 // evaluateEqual checks equality
 func (e *conditionEvaluator) evaluateEqual(ctxValue, condValue interface{}) bool {
 	return fmt.Sprint(ctxValue) == fmt.Sprint(condValue)
 }
 
 // evaluateIn checks if value is in a list
+// Both sides are compared in their canonical string form so that mixed-type
+// lists match consistently
 func (e *conditionEvaluator) evaluateIn(ctxValue, condValue interface{}) bool {
-	ctxStr := fmt.Sprint(ctxValue)
+	ctxStr := canonicalString(ctxValue)
 
 	// Handle slice of interfaces
 	switch v := condValue.(type) {
+	case StringSet:
+		return v.Has(ctxStr)
 	case []interface{}:
 		for _, item := range v {
-			if fmt.Sprint(item) == ctxStr {
+			if canonicalString(item) == ctxStr {
 				return true
 			}
 		}
@@ -113,6 +368,14 @@ func (e *conditionEvaluator) evaluateIn(ctxValue, condValue interface{}) bool {
 			}
 		}
 	default:
+		if isList(condValue) {
+			for _, item := range listItems(condValue) {
+				if canonicalString(item) == ctxStr {
+					return true
+				}
+			}
+			return false
+		}
 		// If it's not a slice, treat as single value comparison
 		return e.evaluateEqual(ctxValue, condValue)
 	}
@@ -122,10 +385,17 @@ func (e *conditionEvaluator) evaluateIn(ctxValue, condValue interface{}) bool {
 
 // evaluateGreaterThan checks if context value is greater than condition value
 func (e *conditionEvaluator) evaluateGreaterThan(ctxValue, condValue interface{}, orEqual bool) bool {
-	ctxNum, err1 := e.toFloat64(ctxValue)
-	condNum, err2 := e.toFloat64(condValue)
+	ctxNum, err1 := toFloat64(ctxValue)
+	condNum, err2 := toFloat64(condValue)
 
 	if err1 != nil || err2 != nil {
+		// Dates and timestamps compare by instant
+		if cmp, ok := compareTimes(ctxValue, condValue); ok {
+			if orEqual {
+				return cmp >= 0
+			}
+			return cmp > 0
+		}
 		// Fallback to string comparison
 		ctxStr := fmt.Sprint(ctxValue)
 		condStr := fmt.Sprint(condValue)
@@ -143,10 +413,17 @@ func (e *conditionEvaluator) evaluateGreaterThan(ctxValue, condValue interface{}
 
 // evaluateLessThan checks if context value is less than condition value
 func (e *conditionEvaluator) evaluateLessThan(ctxValue, condValue interface{}, orEqual bool) bool {
-	ctxNum, err1 := e.toFloat64(ctxValue)
-	condNum, err2 := e.toFloat64(condValue)
+	ctxNum, err1 := toFloat64(ctxValue)
+	condNum, err2 := toFloat64(condValue)
 
 	if err1 != nil || err2 != nil {
+		// Dates and timestamps compare by instant
+		if cmp, ok := compareTimes(ctxValue, condValue); ok {
+			if orEqual {
+				return cmp <= 0
+			}
+			return cmp < 0
+		}
 		// Fallback to string comparison
 		ctxStr := fmt.Sprint(ctxValue)
 		condStr := fmt.Sprint(condValue)
@@ -185,18 +462,157 @@ func (e *conditionEvaluator) evaluateEndsWith(ctxValue, condValue interface{}) b
 
 // evaluateRegex checks if context string matches regex pattern
 func (e *conditionEvaluator) evaluateRegex(ctxValue, condValue interface{}) (bool, error) {
-	ctxStr := fmt.Sprint(ctxValue)
-	pattern := fmt.Sprint(condValue)
+	re, err := compileRegex(fmt.Sprint(condValue))
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(fmt.Sprint(ctxValue)), nil
+}
+
+// regexCacheSize bounds the compiled patterns kept by compileRegex. Patterns
+// built from context values through templates are unbounded in number, so the
+// least recently used are evicted
+const regexCacheSize = 1024
+
+// regexCache is an LRU of compiled condition patterns by source
+var regexCache = struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}{entries: make(map[string]*list.Element), order: list.New()}
+
+// compileRegex compiles a pattern and serves it from the cache afterwards
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	regexCache.mu.Lock()
+	if elem, ok := regexCache.entries[pattern]; ok {
+		regexCache.order.MoveToFront(elem)
+		regexCache.mu.Unlock()
+		return elem.Value.(*regexp.Regexp), nil
+	}
+	regexCache.mu.Unlock()
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	regexCache.mu.Lock()
+	defer regexCache.mu.Unlock()
+
+	if _, ok := regexCache.entries[pattern]; !ok {
+		regexCache.entries[pattern] = regexCache.order.PushFront(re)
+		if regexCache.order.Len() > regexCacheSize {
+			oldest := regexCache.order.Back()
+			regexCache.order.Remove(oldest)
+			delete(regexCache.entries, oldest.Value.(*regexp.Regexp).String())
+		}
+	}
+	return re, nil
+}
+
+// evaluateDivisibleBy checks if the numeric context value is a multiple of the condition value
+// Non-integral context values never match
+func (e *conditionEvaluator) evaluateDivisibleBy(ctxValue, condValue interface{}) bool {
+	ctxNum, err1 := toFloat64(ctxValue)
+	divisor, err2 := toFloat64(condValue)
+	if err1 != nil || err2 != nil || divisor == 0 {
+		return false
+	}
+	if ctxNum != math.Trunc(ctxNum) || divisor != math.Trunc(divisor) {
+		return false
+	}
+	return int64(ctxNum)%int64(divisor) == 0
+}
+
+// evaluateCount compares the number of elements in a list attribute against
+// the condition value. Non-list attributes never match
+func (e *conditionEvaluator) evaluateCount(ctxValue, condValue interface{}, compare func(n, limit float64) bool) bool {
+	if !isList(ctxValue) {
+		return false
+	}
+	limit, err := toFloat64(condValue)
+	if err != nil {
+		return false
+	}
+	return compare(float64(reflect.ValueOf(ctxValue).Len()), limit)
+}
+
+// evaluateAge compares the time elapsed since a timestamp attribute, measured
+// on the evaluator clock, against a duration value
+func (e *conditionEvaluator) evaluateAge(ctxValue, condValue interface{}, compare func(age, limit time.Duration) bool) bool {
+	t, ok := parseTimeValue(ctxValue)
+	if !ok {
+		return false
+	}
+	limit, ok := parseAge(condValue)
+	if !ok {
+		return false
+	}
+	return compare(e.clock().Sub(t), limit)
+}
+
+// evaluateWithinRadius checks if the context location is within the condition radius
+// Malformed coordinates never match
+func (e *conditionEvaluator) evaluateWithinRadius(ctxValue, condValue interface{}) bool {
+	lat, lng, ok := parseGeoPoint(ctxValue)
+	if !ok {
+		return false
+	}
+	area, ok := parseGeoRadius(condValue)
+	if !ok {
+		return false
+	}
+	return haversineKm(lat, lng, area.lat, area.lng) <= area.radiusKm
+}
 
-	matched, err := regexp.MatchString(pattern, ctxStr)
+// evaluateBucketIn checks if the context value hashes into one of the condition's buckets
+func (e *conditionEvaluator) evaluateBucketIn(ctxValue, condValue interface{}) (bool, error) {
+	buckets, err := parseBucketSet(condValue)
 	if err != nil {
 		return false, err
 	}
-	return matched, nil
+	return buckets[e.hasher.Hash(bucketHashKey(ctxValue))], nil
+}
+
+// evaluateSamplePercent checks if the attribute value hashes into the sampled percentage
+func (e *conditionEvaluator) evaluateSamplePercent(ctxValue, condValue interface{}) bool {
+	percent, err := toFloat64(condValue)
+	if err != nil {
+		return false
+	}
+	return float64(e.hasher.Hash(sampleHashKey(ctxValue))) < percent
+}
+
+// evaluatePercentile compares a percentile rank attribute in [0, 100] against
+// a percentage value. Non-numeric and out-of-range ranks never match
+func evaluatePercentile(ctxValue, condValue interface{}, compare func(rank, p float64) bool) bool {
+	rank, err := toFloat64(ctxValue)
+	if err != nil || rank < 0 || rank > 100 {
+		return false
+	}
+	p, err := toFloat64(condValue)
+	if err != nil {
+		return false
+	}
+	return compare(rank, p)
+}
+
+// evaluateSemverSatisfies checks if the context version satisfies the condition range
+// Unparseable context versions never match
+func (e *conditionEvaluator) evaluateSemverSatisfies(ctxValue, condValue interface{}) (bool, error) {
+	r, err := parseSemverRange(fmt.Sprint(condValue))
+	if err != nil {
+		return false, err
+	}
+	v, err := parseSemver(fmt.Sprint(ctxValue))
+	if err != nil {
+		return false, nil
+	}
+	return r.satisfiedBy(v), nil
 }
 
 // toFloat64 converts interface{} to float64
-func (e *conditionEvaluator) toFloat64(value interface{}) (float64, error) {
+func toFloat64(value interface{}) (float64, error) {
 	switch v := value.(type) {
 	case float64:
 		return v, nil
@@ -214,3 +630,19 @@ func (e *conditionEvaluator) toFloat64(value interface{}) (float64, error) {
 		return 0, fmt.Errorf("cannot convert %T to float64", value)
 	}
 }
+
+// canonicalString formats a value for list membership comparisons.
+// Integral floats are formatted without exponent or decimals so that a JSON
+// number 1 (float64) and a YAML integer 1 (int) compare equal
+func canonicalString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -1,7 +1,9 @@
 package toggo
 
 import (
+	"fmt"
 	"testing"
+	"time"
 )
 
 func TestConditionEvaluator_Equal(t *testing.T) {
@@ -176,6 +178,119 @@ func TestConditionEvaluator_Comparison(t *testing.T) {
 	}
 }
 
+func TestConditionEvaluator_IsType(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		ctxValue interface{}
+		expected bool
+	}{
+		{"string matches string", "string", "dev-123", true},
+		{"number fails string", "string", 123, false},
+		{"empty string fails", "string", "", false},
+		{"numeric string is not a number", "number", "123", false},
+		{"int is a number", "number", 42, true},
+		{"float is a number", "number", 4.2, true},
+		{"bool matches bool", "bool", false, true},
+		{"non-empty list", "list", []interface{}{"a"}, true},
+		{"empty list fails", "list", []string{}, false},
+		{"one of several types", []interface{}{"string", "number"}, 7, true},
+		{"none of several types", []interface{}{"string", "number"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond := Condition{Attribute: "device_id", Operator: OperatorIsType, Value: tt.value}
+			result, err := eval.evaluate(cond, Context{"device_id": tt.ctxValue})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	missing, _ := eval.evaluate(Condition{Attribute: "device_id", Operator: OperatorIsType, Value: "string"}, Context{})
+	if missing {
+		t.Error("expected missing attribute not to match")
+	}
+
+	for _, value := range []interface{}{"uuid", 1, []interface{}{}, []interface{}{"string", "map"}} {
+		cond := Condition{Attribute: "device_id", Operator: OperatorIsType, Value: value}
+		if err := cond.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected ErrInvalidCondition for %v, got %v", value, err)
+		}
+	}
+}
+
+func TestConditionEvaluator_DateComparison(t *testing.T) {
+	eval := newConditionEvaluator()
+	newYear := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		condition Condition
+		ctx       Context
+		expected  bool
+	}{
+		{"date string after date", Condition{Attribute: "d", Operator: OperatorGreaterThan, Value: "2024-01-01"}, Context{"d": "2024-02-01"}, true},
+		{"same instant in different forms", Condition{Attribute: "d", Operator: OperatorGreaterThanOrEqual, Value: "2024-01-01"}, Context{"d": "2024-01-01T00:00:00Z"}, true},
+		{"time.Time against date string", Condition{Attribute: "d", Operator: OperatorLessThan, Value: "2024-01-01"}, Context{"d": newYear.Add(-time.Hour)}, true},
+		{"date string against time.Time", Condition{Attribute: "d", Operator: OperatorLessThanOrEqual, Value: newYear}, Context{"d": "2024-01-01"}, true},
+		{"timezone offsets compare by instant", Condition{Attribute: "d", Operator: OperatorGreaterThan, Value: "2024-01-01T00:00:00Z"}, Context{"d": "2024-01-01T00:30:00+01:00"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluate(tt.condition, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestConditionEvaluator_ListAnyMatch(t *testing.T) {
+	roles := Context{"roles": []string{"viewer", "editor"}, "scores": []interface{}{3, 12}}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		anyMatch  bool
+		expected  bool
+	}{
+		{"equal matches one element", Condition{Attribute: "roles", Operator: OperatorEqual, Value: "editor"}, true, true},
+		{"equal matches no element", Condition{Attribute: "roles", Operator: OperatorEqual, Value: "admin"}, true, false},
+		{"not equal with matching element", Condition{Attribute: "roles", Operator: OperatorNotEqual, Value: "editor"}, true, false},
+		{"not equal with no matching element", Condition{Attribute: "roles", Operator: OperatorNotEqual, Value: "admin"}, true, true},
+		{"greater than matches one element", Condition{Attribute: "scores", Operator: OperatorGreaterThan, Value: 10}, true, true},
+		{"less than or equal matches one element", Condition{Attribute: "scores", Operator: OperatorLessThanOrEqual, Value: 3}, true, true},
+		{"less than matches no element", Condition{Attribute: "scores", Operator: OperatorLessThan, Value: 3}, true, false},
+		{"negated equal", Condition{Attribute: "roles", Operator: OperatorEqual, Value: "editor", Negate: true}, true, false},
+		{"disabled compares whole list", Condition{Attribute: "roles", Operator: OperatorEqual, Value: "editor"}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval := newConditionEvaluator()
+			eval.anyMatch = tt.anyMatch
+			result, err := eval.evaluate(tt.condition, roles)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestConditionEvaluator_StringOperations(t *testing.T) {
 	eval := newConditionEvaluator()
 
@@ -302,3 +417,1014 @@ func TestConditionEvaluator_EvaluateAll(t *testing.T) {
 		})
 	}
 }
+
+func TestConditionEvaluator_DivisibleBy(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	tests := []struct {
+		name     string
+		ctx      Context
+		expected bool
+	}{
+		{name: "divisible", ctx: Context{"user_num": 12}, expected: true},
+		{name: "not divisible", ctx: Context{"user_num": 13}, expected: false},
+		{name: "numeric string", ctx: Context{"user_num": "8"}, expected: true},
+		{name: "non-integral", ctx: Context{"user_num": 4.5}, expected: false},
+		{name: "non-numeric", ctx: Context{"user_num": "abc"}, expected: false},
+	}
+
+	condition := Condition{Attribute: "user_num", Operator: OperatorDivisibleBy, Value: 4}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluate(condition, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestCondition_Validate_DivisibleBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr bool
+	}{
+		{name: "valid divisor", value: 4, wantErr: false},
+		{name: "zero divisor", value: 0, wantErr: true},
+		{name: "fractional divisor", value: 2.5, wantErr: true},
+		{name: "non-numeric divisor", value: "four", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := Condition{Attribute: "user_num", Operator: OperatorDivisibleBy, Value: tt.value}
+			err := condition.Validate()
+			if tt.wantErr && err != ErrInvalidCondition {
+				t.Errorf("expected ErrInvalidCondition, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConditionEvaluator_WithinRadius(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	// 10km around Berlin Alexanderplatz
+	condition := Condition{
+		Attribute: "location",
+		Operator:  OperatorWithinRadius,
+		Value:     map[string]interface{}{"lat": 52.5219, "lng": 13.4132, "radius_km": 10},
+	}
+
+	tests := []struct {
+		name     string
+		ctx      Context
+		expected bool
+	}{
+		{
+			name:     "inside radius (Brandenburg Gate)",
+			ctx:      Context{"location": map[string]interface{}{"lat": 52.5163, "lng": 13.3777}},
+			expected: true,
+		},
+		{
+			name:     "inside radius as pair",
+			ctx:      Context{"location": []interface{}{52.5163, 13.3777}},
+			expected: true,
+		},
+		{
+			name:     "outside radius (Potsdam)",
+			ctx:      Context{"location": map[string]interface{}{"lat": 52.3906, "lng": 13.0645}},
+			expected: false,
+		},
+		{
+			name:     "malformed coordinates",
+			ctx:      Context{"location": map[string]interface{}{"lat": "north", "lng": 13.3777}},
+			expected: false,
+		},
+		{
+			name:     "out of range latitude",
+			ctx:      Context{"location": []interface{}{152.5, 13.3777}},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluate(condition, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	invalid := Condition{
+		Attribute: "location",
+		Operator:  OperatorWithinRadius,
+		Value:     map[string]interface{}{"lat": 52.5, "lng": 13.4},
+	}
+	if err := invalid.Validate(); err != ErrInvalidCondition {
+		t.Errorf("expected ErrInvalidCondition for missing radius, got %v", err)
+	}
+}
+
+func TestConditionEvaluator_BucketIn(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	lower := Condition{Attribute: "user_id", Operator: OperatorBucketIn, Value: []interface{}{"0-49"}}
+	upper := Condition{Attribute: "user_id", Operator: OperatorBucketIn, Value: []interface{}{"50-98", 99}}
+	slice := Condition{Attribute: "user_id", Operator: OperatorBucketIn, Value: []interface{}{"0-9"}}
+
+	inSlice := 0
+	for i := 0; i < 1000; i++ {
+		ctx := Context{"user_id": fmt.Sprintf("user_%d", i)}
+
+		inLower, err := eval.evaluate(lower, ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		inUpper, _ := eval.evaluate(upper, ctx)
+		if inLower == inUpper {
+			t.Fatalf("expected %v to be in exactly one half of the buckets", ctx["user_id"])
+		}
+
+		// Same key lands in the same bucket across calls
+		again, _ := eval.evaluate(lower, ctx)
+		if again != inLower {
+			t.Fatalf("bucketing is not deterministic for %v", ctx["user_id"])
+		}
+
+		if match, _ := eval.evaluate(slice, ctx); match {
+			inSlice++
+		}
+	}
+
+	if inSlice < 50 || inSlice > 150 {
+		t.Errorf("expected roughly 10%% of keys in buckets 0-9, got %d/1000", inSlice)
+	}
+
+	for _, value := range []interface{}{"0-9", []interface{}{100}, []interface{}{"9-0"}, []interface{}{"a-b"}} {
+		invalid := Condition{Attribute: "user_id", Operator: OperatorBucketIn, Value: value}
+		if err := invalid.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected ErrInvalidCondition for %v, got %v", value, err)
+		}
+	}
+}
+
+func TestConditionEvaluator_Default(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	condition := Condition{
+		Attribute: "purchase_count",
+		Operator:  OperatorLessThan,
+		Value:     1,
+		Default:   0,
+	}
+
+	tests := []struct {
+		name     string
+		ctx      Context
+		expected bool
+	}{
+		{name: "missing attribute uses default", ctx: Context{}, expected: true},
+		{name: "present attribute ignores default", ctx: Context{"purchase_count": 3}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluate(condition, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	// Without a default a missing attribute still fails
+	condition.Default = nil
+	if result, _ := eval.evaluate(condition, Context{}); result {
+		t.Error("expected missing attribute without default to fail")
+	}
+}
+
+func TestConditionEvaluator_SemverSatisfies(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	tests := []struct {
+		name     string
+		rng      string
+		version  string
+		expected bool
+	}{
+		{name: "caret allows minor bump", rng: "^2.3.0", version: "2.3.5", expected: true},
+		{name: "caret allows higher minor", rng: "^2.3.0", version: "2.9.0", expected: true},
+		{name: "caret rejects major bump", rng: "^2.3.0", version: "3.0.0", expected: false},
+		{name: "caret rejects lower", rng: "^2.3.0", version: "2.2.9", expected: false},
+		{name: "caret zero major", rng: "^0.2.3", version: "0.3.0", expected: false},
+		{name: "tilde allows patch bump", rng: "~1.4.0", version: "1.4.9", expected: true},
+		{name: "tilde rejects minor bump", rng: "~1.4.0", version: "1.5.0", expected: false},
+		{name: "explicit bounds", rng: ">=1.0.0 <2.0.0", version: "1.9.9", expected: true},
+		{name: "explicit upper bound", rng: ">=1.0.0 <2.0.0", version: "2.0.0", expected: false},
+		{name: "x wildcard", rng: "1.2.x", version: "1.2.7", expected: true},
+		{name: "x wildcard mismatch", rng: "1.x", version: "2.0.0", expected: false},
+		{name: "or alternatives", rng: "^1.0.0 || ^3.0.0", version: "3.1.0", expected: true},
+		{name: "v prefix and prerelease", rng: "^2.3.0", version: "v2.4.0-beta.1", expected: true},
+		{name: "unparseable version", rng: "^2.3.0", version: "latest", expected: false},
+		{name: "space after operator", rng: ">= 1.0.0 < 2.0.0", version: "1.5.0", expected: true},
+		{name: "space after operator upper bound", rng: ">= 1.0.0 < 2.0.0", version: "2.0.0", expected: false},
+		{name: "space after caret", rng: "^ 2.3.0", version: "2.4.0", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := Condition{Attribute: "app_version", Operator: OperatorSemverSatisfies, Value: tt.rng}
+			result, err := eval.evaluate(condition, Context{"app_version": tt.version})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	for _, rng := range []interface{}{"^two", ">=1.2.3.4", 2, "", "   ", "^1.0.0 ||", ">="} {
+		invalid := Condition{Attribute: "app_version", Operator: OperatorSemverSatisfies, Value: rng}
+		if err := invalid.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected ErrInvalidCondition for %v, got %v", rng, err)
+		}
+	}
+}
+
+func TestConditionEvaluator_Count(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	tests := []struct {
+		name     string
+		operator Operator
+		value    interface{}
+		items    interface{}
+		expected bool
+	}{
+		{name: "3 items more than 1", operator: OperatorCountGreaterThan, value: 1, items: []interface{}{"a", "b", "c"}, expected: true},
+		{name: "3 items more than 3", operator: OperatorCountGreaterThan, value: 3, items: []string{"a", "b", "c"}, expected: false},
+		{name: "3 items fewer than 5", operator: OperatorCountLessThan, value: 5, items: []int{1, 2, 3}, expected: true},
+		{name: "empty fewer than 1", operator: OperatorCountLessThan, value: 1.0, items: []string{}, expected: true},
+		{name: "string is not a list", operator: OperatorCountGreaterThan, value: 1, items: "abc", expected: false},
+		{name: "number is not a list", operator: OperatorCountLessThan, value: 5, items: 3, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := Condition{Attribute: "cart_items", Operator: tt.operator, Value: tt.value}
+			result, err := eval.evaluate(condition, Context{"cart_items": tt.items})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	invalid := Condition{Attribute: "cart_items", Operator: OperatorCountGreaterThan, Value: "many"}
+	if err := invalid.Validate(); err != ErrInvalidCondition {
+		t.Errorf("expected ErrInvalidCondition, got %v", err)
+	}
+}
+
+func TestConditionEvaluator_Template(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	tests := []struct {
+		name      string
+		condition Condition
+		ctx       Context
+		expected  bool
+	}{
+		{
+			name:      "matching template",
+			condition: Condition{Attribute: "country", Operator: OperatorEqual, Value: "{{home_country}}"},
+			ctx:       Context{"country": "US", "home_country": "US"},
+			expected:  true,
+		},
+		{
+			name:      "non-matching template",
+			condition: Condition{Attribute: "country", Operator: OperatorEqual, Value: "{{home_country}}"},
+			ctx:       Context{"country": "FR", "home_country": "US"},
+			expected:  false,
+		},
+		{
+			name:      "unresolvable template",
+			condition: Condition{Attribute: "country", Operator: OperatorNotEqual, Value: "{{home_country}}"},
+			ctx:       Context{"country": "FR"},
+			expected:  false,
+		},
+		{
+			name:      "numeric template keeps its type",
+			condition: Condition{Attribute: "spend", Operator: OperatorGreaterThan, Value: "{{ budget }}"},
+			ctx:       Context{"spend": 150, "budget": 99},
+			expected:  true,
+		},
+		{
+			name:      "embedded template",
+			condition: Condition{Attribute: "email", Operator: OperatorEndsWith, Value: "@{{company_domain}}"},
+			ctx:       Context{"email": "jane@acme.io", "company_domain": "acme.io"},
+			expected:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluate(tt.condition, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestConditionEvaluator_TimeOperators(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	// Wednesday 2024-05-15
+	tests := []struct {
+		name     string
+		operator Operator
+		value    interface{}
+		at       interface{}
+		expected bool
+	}{
+		{name: "inside business hours", operator: OperatorTimeOfDayBetween, value: []string{"09:00", "17:00"}, at: "2024-05-15T10:30:00Z", expected: true},
+		{name: "end is exclusive", operator: OperatorTimeOfDayBetween, value: []string{"09:00", "17:00"}, at: "2024-05-15T17:00:00Z", expected: false},
+		{name: "overnight window after midnight", operator: OperatorTimeOfDayBetween, value: []interface{}{"22:00", "06:00"}, at: "2024-05-15T02:00:00Z", expected: true},
+		{name: "overnight window midday", operator: OperatorTimeOfDayBetween, value: []interface{}{"22:00", "06:00"}, at: "2024-05-15T12:00:00Z", expected: false},
+		{name: "weekday listed", operator: OperatorDayOfWeekIn, value: []string{"mon", "Wed"}, at: time.Date(2024, 5, 15, 8, 0, 0, 0, time.UTC), expected: true},
+		{name: "weekday not listed", operator: OperatorDayOfWeekIn, value: []string{"sat", "sun"}, at: "2024-05-15", expected: false},
+		{name: "not a timestamp", operator: OperatorDayOfWeekIn, value: []string{"wed"}, at: "yesterday", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := Condition{Attribute: "at", Operator: tt.operator, Value: tt.value}
+			result, err := eval.evaluate(condition, Context{"at": tt.at})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	invalid := []Condition{
+		{Attribute: "at", Operator: OperatorTimeOfDayBetween, Value: []string{"09:00"}},
+		{Attribute: "at", Operator: OperatorTimeOfDayBetween, Value: []string{"9am", "5pm"}},
+		{Attribute: "at", Operator: OperatorDayOfWeekIn, Value: []string{"someday"}},
+		{Attribute: "", Operator: OperatorEqual, Value: "x"},
+	}
+	for _, cond := range invalid {
+		if err := cond.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected ErrInvalidCondition for %v, got %v", cond, err)
+		}
+	}
+}
+
+func TestConditionEvaluator_Now(t *testing.T) {
+	eval := newConditionEvaluator()
+	eval.clock = func() time.Time { return time.Date(2024, 5, 18, 11, 0, 0, 0, time.UTC) } // Saturday
+
+	tests := []struct {
+		name      string
+		condition Condition
+		expected  bool
+	}{
+		{name: "@now time of day", condition: Condition{Attribute: NowAttribute, Operator: OperatorTimeOfDayBetween, Value: []string{"09:00", "17:00"}}, expected: true},
+		{name: "empty attribute uses clock", condition: Condition{Operator: OperatorDayOfWeekIn, Value: []string{"sat", "sun"}}, expected: true},
+		{name: "@now ignores context", condition: Condition{Attribute: NowAttribute, Operator: OperatorDayOfWeekIn, Value: []string{"mon"}}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluate(tt.condition, Context{"@now": "2024-05-13T11:00:00Z"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	// A clock in another zone is read in UTC: 20:00 in Tokyo on Saturday
+	// is 11:00 UTC the same day
+	tokyo := time.FixedZone("JST", 9*60*60)
+	eval.clock = func() time.Time { return time.Date(2024, 5, 18, 20, 0, 0, 0, tokyo) }
+	for _, tt := range tests[:2] {
+		if result, _ := eval.evaluate(tt.condition, Context{}); !result {
+			t.Errorf("%s: expected @now in UTC to match", tt.name)
+		}
+	}
+}
+
+func TestConditionEvaluator_Age(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	eval := newConditionEvaluator()
+	eval.clock = func() time.Time { return now }
+
+	tenDaysAgo := now.Add(-10 * 24 * time.Hour)
+	fresh := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		operator Operator
+		value    interface{}
+		signup   interface{}
+		expected bool
+	}{
+		{name: "10 days older than 7d", operator: OperatorOlderThan, value: "7d", signup: tenDaysAgo, expected: true},
+		{name: "10 days older than 168h", operator: OperatorOlderThan, value: "168h", signup: tenDaysAgo.Format(time.RFC3339), expected: true},
+		{name: "fresh not older than 7d", operator: OperatorOlderThan, value: "7d", signup: fresh, expected: false},
+		{name: "fresh newer than 168h", operator: OperatorNewerThan, value: "168h", signup: fresh.Unix(), expected: true},
+		{name: "10 days not newer than 7d", operator: OperatorNewerThan, value: "7d", signup: tenDaysAgo, expected: false},
+		{name: "not a timestamp", operator: OperatorOlderThan, value: "7d", signup: "last week", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := Condition{Attribute: "signup_time", Operator: tt.operator, Value: tt.value}
+			result, err := eval.evaluate(condition, Context{"signup_time": tt.signup})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	for _, value := range []interface{}{"a week", "-7d", 7} {
+		invalid := Condition{Attribute: "signup_time", Operator: OperatorOlderThan, Value: value}
+		if err := invalid.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected ErrInvalidCondition for %v, got %v", value, err)
+		}
+	}
+}
+
+func TestConditionEvaluator_SamplePercent(t *testing.T) {
+	eval := newConditionEvaluator()
+	condition := Condition{Attribute: "error_code", Operator: OperatorSamplePercent, Value: 1}
+
+	matched := 0
+	for i := 0; i < 10000; i++ {
+		code := fmt.Sprintf("E%05d", i)
+		first, err := eval.evaluate(condition, Context{"error_code": code})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		second, _ := eval.evaluate(condition, Context{"error_code": code})
+		if first != second {
+			t.Fatalf("expected deterministic result for %s", code)
+		}
+		if first {
+			matched++
+		}
+	}
+	if matched < 60 || matched > 140 {
+		t.Errorf("expected roughly 1%% of codes sampled, got %d/10000", matched)
+	}
+
+	all := Condition{Attribute: "error_code", Operator: OperatorSamplePercent, Value: 100}
+	none := Condition{Attribute: "error_code", Operator: OperatorSamplePercent, Value: 0}
+	for i := 0; i < 100; i++ {
+		ctx := Context{"error_code": i}
+		if result, _ := eval.evaluate(all, ctx); !result {
+			t.Errorf("expected 100%% sample to match %d", i)
+		}
+		if result, _ := eval.evaluate(none, ctx); result {
+			t.Errorf("expected 0%% sample not to match %d", i)
+		}
+	}
+
+	for _, value := range []interface{}{"some", 101, -1} {
+		invalid := Condition{Attribute: "error_code", Operator: OperatorSamplePercent, Value: value}
+		if err := invalid.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected ErrInvalidCondition for %v, got %v", value, err)
+		}
+	}
+}
+
+func TestConditionEvaluator_Groups(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	usOrInternal := ConditionGroup{
+		Logic: LogicOr,
+		Conditions: []Condition{
+			{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+			{Attribute: "internal", Operator: OperatorEqual, Value: true},
+		},
+	}
+	premiumAndNested := ConditionGroup{
+		Conditions: []Condition{{Attribute: "plan", Operator: OperatorEqual, Value: "premium"}},
+		Groups:     []ConditionGroup{usOrInternal},
+	}
+	negated := func(group ConditionGroup) ConditionGroup {
+		group.Negate = true
+		return group
+	}
+	premiumExceptUS := ConditionGroup{
+		Conditions: []Condition{{Attribute: "plan", Operator: OperatorEqual, Value: "premium"}},
+		Groups:     []ConditionGroup{negated(usOrInternal)},
+	}
+
+	tests := []struct {
+		name     string
+		group    ConditionGroup
+		ctx      Context
+		expected bool
+	}{
+		{name: "or first branch", group: usOrInternal, ctx: Context{"country": "US"}, expected: true},
+		{name: "or second branch", group: usOrInternal, ctx: Context{"country": "DE", "internal": true}, expected: true},
+		{name: "or no branch", group: usOrInternal, ctx: Context{"country": "DE"}, expected: false},
+		{name: "and with nested or", group: premiumAndNested, ctx: Context{"plan": "premium", "internal": true}, expected: true},
+		{name: "and failing condition", group: premiumAndNested, ctx: Context{"plan": "free", "country": "US"}, expected: false},
+		{name: "and failing nested or", group: premiumAndNested, ctx: Context{"plan": "premium", "country": "DE"}, expected: false},
+		{name: "negated and matching", group: negated(premiumAndNested), ctx: Context{"plan": "premium", "country": "US"}, expected: false},
+		{name: "negated and failing", group: negated(premiumAndNested), ctx: Context{"plan": "free", "country": "US"}, expected: true},
+		{name: "negated or matching", group: negated(usOrInternal), ctx: Context{"country": "DE", "internal": true}, expected: false},
+		{name: "negated or failing", group: negated(usOrInternal), ctx: Context{"country": "DE"}, expected: true},
+		{name: "and with negated nested or", group: premiumExceptUS, ctx: Context{"plan": "premium", "country": "DE"}, expected: true},
+		{name: "and with negated nested or excluded", group: premiumExceptUS, ctx: Context{"plan": "premium", "country": "US"}, expected: false},
+		{name: "double negation", group: negated(premiumExceptUS), ctx: Context{"plan": "premium", "country": "US"}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluateGroup(tt.group, tt.ctx, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	invalid := []ConditionGroup{
+		{},
+		{Logic: "xor", Conditions: []Condition{{Attribute: "a", Operator: OperatorEqual, Value: 1}}},
+		{Groups: []ConditionGroup{{}}},
+	}
+	for _, group := range invalid {
+		if err := group.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected ErrInvalidCondition for %+v, got %v", group, err)
+		}
+	}
+}
+
+func TestConditionEvaluator_NotMatchesRegex(t *testing.T) {
+	eval := newConditionEvaluator()
+	botPattern := `(?i)(bot|crawler|spider)`
+
+	tests := []struct {
+		name     string
+		ctx      Context
+		expected bool
+	}{
+		{name: "bot matches pattern", ctx: Context{"user_agent": "Googlebot/2.1"}, expected: false},
+		{name: "browser does not match", ctx: Context{"user_agent": "Mozilla/5.0 Firefox/120.0"}, expected: true},
+		{name: "missing attribute", ctx: Context{}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := Condition{Attribute: "user_agent", Operator: OperatorNotMatchesRegex, Value: botPattern}
+			result, err := eval.evaluate(condition, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	invalid := Condition{Attribute: "user_agent", Operator: OperatorNotMatchesRegex, Value: "(unclosed"}
+	if err := invalid.Validate(); err != ErrInvalidCondition {
+		t.Errorf("expected ErrInvalidCondition, got %v", err)
+	}
+}
+
+func TestCompileRegex_Bounded(t *testing.T) {
+	first, err := compileRegex(`^first$`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again, _ := compileRegex(`^first$`); again != first {
+		t.Error("expected a repeated pattern to be served from the cache")
+	}
+
+	for i := 0; i < regexCacheSize+10; i++ {
+		if _, err := compileRegex(fmt.Sprintf("^user_%d$", i)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	regexCache.mu.Lock()
+	size := regexCache.order.Len()
+	_, kept := regexCache.entries[`^first$`]
+	regexCache.mu.Unlock()
+	if size != regexCacheSize {
+		t.Errorf("expected %d cached patterns, got %d", regexCacheSize, size)
+	}
+	if kept {
+		t.Error("expected the least recently used pattern to be evicted")
+	}
+}
+
+func TestConditionEvaluator_JSONPath(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	profile := `{"subscription": {"tier": "gold", "seats": 12}, "orders": [{"amount": 150}]}`
+
+	tests := []struct {
+		name      string
+		condition Condition
+		ctx       Context
+		expected  bool
+	}{
+		{
+			name:      "nested field matches",
+			condition: Condition{Attribute: "profile", JSONPath: "$.subscription.tier", Operator: OperatorEqual, Value: "gold"},
+			ctx:       Context{"profile": profile},
+			expected:  true,
+		},
+		{
+			name:      "nested field does not match",
+			condition: Condition{Attribute: "profile", JSONPath: "$.subscription.tier", Operator: OperatorEqual, Value: "silver"},
+			ctx:       Context{"profile": profile},
+			expected:  false,
+		},
+		{
+			name:      "numeric field with comparison",
+			condition: Condition{Attribute: "profile", JSONPath: "$.subscription.seats", Operator: OperatorGreaterThan, Value: 10},
+			ctx:       Context{"profile": profile},
+			expected:  true,
+		},
+		{
+			name:      "list index",
+			condition: Condition{Attribute: "profile", JSONPath: "$.orders[0].amount", Operator: OperatorGreaterThanOrEqual, Value: 100},
+			ctx:       Context{"profile": profile},
+			expected:  true,
+		},
+		{
+			name:      "missing path",
+			condition: Condition{Attribute: "profile", JSONPath: "$.subscription.plan", Operator: OperatorEqual, Value: "gold"},
+			ctx:       Context{"profile": profile},
+			expected:  false,
+		},
+		{
+			name:      "malformed JSON",
+			condition: Condition{Attribute: "profile", JSONPath: "$.subscription.tier", Operator: OperatorEqual, Value: "gold"},
+			ctx:       Context{"profile": `{"subscription": `},
+			expected:  false,
+		},
+		{
+			name:      "malformed JSON with negate still fails",
+			condition: Condition{Attribute: "profile", JSONPath: "$.subscription.tier", Operator: OperatorEqual, Value: "gold", Negate: true},
+			ctx:       Context{"profile": "not json"},
+			expected:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluate(tt.condition, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestConditionEvaluator_ReorderingInvariance(t *testing.T) {
+	plain := newConditionEvaluator()
+	reordering := newConditionEvaluator()
+	reordering.reorder = true
+
+	conditions := []Condition{
+		{Attribute: "email", Operator: OperatorRegex, Value: `^[a-z]+@example\.com$`},
+		{Attribute: "version", Operator: OperatorSemverSatisfies, Value: "^2.0.0"},
+		{Attribute: "name", Operator: OperatorStartsWith, Value: "a"},
+		{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+	}
+
+	emails := []string{"alice@example.com", "bob@other.com"}
+	versions := []string{"2.1.0", "3.0.0"}
+	names := []string{"alice", "bob"}
+	countries := []string{"US", "DE"}
+
+	for _, email := range emails {
+		for _, version := range versions {
+			for _, name := range names {
+				for _, country := range countries {
+					ctx := Context{"email": email, "version": version, "name": name, "country": country}
+
+					expected, err := plain.evaluateAll(conditions, ctx)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					result, err := reordering.evaluateAll(conditions, ctx)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if result != expected {
+						t.Errorf("reordering changed result for %v: expected %v, got %v", ctx, expected, result)
+					}
+				}
+			}
+		}
+	}
+
+	if conditions[0].Operator != OperatorRegex {
+		t.Error("expected reordering to leave the flag's condition slice untouched")
+	}
+}
+
+func BenchmarkConditionEvaluator_EvaluateAll(b *testing.B) {
+	conditions := []Condition{
+		{Attribute: "email", Operator: OperatorRegex, Value: `^[a-z.]+@(example|corp)\.com$`},
+		{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+	}
+	ctx := Context{"email": "jane.doe@example.com", "country": "DE"}
+
+	for _, reorder := range []bool{false, true} {
+		name := "config_order"
+		if reorder {
+			name = "reordered"
+		}
+		b.Run(name, func(b *testing.B) {
+			eval := newConditionEvaluator()
+			eval.reorder = reorder
+			for i := 0; i < b.N; i++ {
+				eval.evaluateAll(conditions, ctx)
+			}
+		})
+	}
+}
+
+func BenchmarkConditionEvaluator_InLargeSet(b *testing.B) {
+	ids := make([]string, 10000)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("user_%d", i)
+	}
+	ctx := Context{"user_id": "user_9999"}
+
+	values := map[string]interface{}{
+		"list": ids,
+		"set":  NewStringSet(ids),
+	}
+	for _, name := range []string{"list", "set"} {
+		cond := Condition{Attribute: "user_id", Operator: OperatorIn, Value: values[name]}
+		b.Run(name, func(b *testing.B) {
+			eval := newConditionEvaluator()
+			for i := 0; i < b.N; i++ {
+				eval.evaluate(cond, ctx)
+			}
+		})
+	}
+}
+
+func TestConditionEvaluator_Percentile(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	tests := []struct {
+		name     string
+		operator Operator
+		value    interface{}
+		rank     interface{}
+		expected bool
+	}{
+		{"95th percentile in top 10%", OperatorTopPercent, 10, 95, true},
+		{"90th percentile on the top 10% boundary", OperatorTopPercent, 10, 90, true},
+		{"85th percentile outside top 10%", OperatorTopPercent, 10, 85.5, false},
+		{"top 0% matches nobody", OperatorTopPercent, 0, 100, false},
+		{"top 100% matches everyone", OperatorTopPercent, 100, 0, true},
+		{"above threshold", OperatorPercentileAbove, 90, 95, true},
+		{"at threshold", OperatorPercentileAbove, 90, 90, false},
+		{"numeric string rank", OperatorPercentileAbove, 50, "75", true},
+		{"rank above 100", OperatorTopPercent, 10, 250, false},
+		{"negative rank", OperatorPercentileAbove, 0, -1, false},
+		{"non-numeric rank", OperatorTopPercent, 10, "high", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond := Condition{Attribute: "spend_percentile", Operator: tt.operator, Value: tt.value}
+			result, err := eval.evaluate(cond, Context{"spend_percentile": tt.rank})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	for _, value := range []interface{}{-1, 101, "ten"} {
+		cond := Condition{Attribute: "spend_percentile", Operator: OperatorTopPercent, Value: value}
+		if err := cond.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected ErrInvalidCondition for %v, got %v", value, err)
+		}
+	}
+}
+
+func TestConditionEvaluator_Quantifiers(t *testing.T) {
+	eval := newConditionEvaluator()
+	orders := []interface{}{
+		map[string]interface{}{"amount": 40.0, "country": "US"},
+		map[string]interface{}{"amount": "150", "country": "CA"},
+	}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		ctx       Context
+		expected  bool
+	}{
+		{"any element greater than", Condition{Attribute: "past_orders", Operator: OperatorGreaterThan, Value: 100, Quantifier: QuantifierAny, ElementField: "amount"}, Context{"past_orders": orders}, true},
+		{"no element greater than", Condition{Attribute: "past_orders", Operator: OperatorGreaterThan, Value: 200, Quantifier: QuantifierAny, ElementField: "amount"}, Context{"past_orders": orders}, false},
+		{"all elements greater than", Condition{Attribute: "past_orders", Operator: OperatorGreaterThan, Value: 100, Quantifier: QuantifierAll, ElementField: "amount"}, Context{"past_orders": orders}, false},
+		{"all elements in set", Condition{Attribute: "past_orders", Operator: OperatorIn, Value: []interface{}{"US", "CA"}, Quantifier: QuantifierAll, ElementField: "country"}, Context{"past_orders": orders}, true},
+		{"not all elements in set", Condition{Attribute: "past_orders", Operator: OperatorIn, Value: []interface{}{"US"}, Quantifier: QuantifierAll, ElementField: "country"}, Context{"past_orders": orders}, false},
+		{"scalar elements", Condition{Attribute: "scores", Operator: OperatorGreaterThanOrEqual, Value: 3, Quantifier: QuantifierAll}, Context{"scores": []int{3, 4, 5}}, true},
+		{"missing field fails all", Condition{Attribute: "past_orders", Operator: OperatorGreaterThan, Value: 0, Quantifier: QuantifierAll, ElementField: "tax"}, Context{"past_orders": orders}, false},
+		{"empty list", Condition{Attribute: "scores", Operator: OperatorGreaterThan, Value: 0, Quantifier: QuantifierAll}, Context{"scores": []int{}}, false},
+		{"non-list attribute", Condition{Attribute: "scores", Operator: OperatorGreaterThan, Value: 0, Quantifier: QuantifierAny}, Context{"scores": 5}, false},
+		{"negated any", Condition{Attribute: "past_orders", Operator: OperatorGreaterThan, Value: 100, Quantifier: QuantifierAny, ElementField: "amount", Negate: true}, Context{"past_orders": orders}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluate(tt.condition, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	invalid := []Condition{
+		{Attribute: "scores", Operator: OperatorEqual, Value: 1, Quantifier: "some"},
+		{Attribute: "scores", Operator: OperatorEqual, Value: 1, ElementField: "amount"},
+	}
+	for _, cond := range invalid {
+		if err := cond.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected ErrInvalidCondition for %+v, got %v", cond, err)
+		}
+	}
+}
+
+func TestConditionEvaluator_Length(t *testing.T) {
+	eval := newConditionEvaluator()
+
+	tests := []struct {
+		name      string
+		condition Condition
+		ctx       Context
+		expected  bool
+	}{
+		{"empty slice is empty", Condition{Attribute: "tags", Operator: OperatorIsEmpty}, Context{"tags": []string{}}, true},
+		{"empty slice is not non-empty", Condition{Attribute: "tags", Operator: OperatorIsNotEmpty}, Context{"tags": []interface{}{}}, false},
+		{"non-empty string", Condition{Attribute: "nickname", Operator: OperatorIsNotEmpty}, Context{"nickname": "ada"}, true},
+		{"empty string", Condition{Attribute: "nickname", Operator: OperatorIsEmpty}, Context{"nickname": ""}, true},
+		{"empty map", Condition{Attribute: "prefs", Operator: OperatorIsEmpty}, Context{"prefs": map[string]interface{}{}}, true},
+		{"missing is empty", Condition{Attribute: "tags", Operator: OperatorIsEmpty}, Context{}, true},
+		{"missing is not non-empty", Condition{Attribute: "tags", Operator: OperatorIsNotEmpty}, Context{}, false},
+		{"number has no length", Condition{Attribute: "age", Operator: OperatorIsEmpty}, Context{"age": 0}, false},
+		{"length within range", Condition{Attribute: "cart", Operator: OperatorLengthBetween, Value: []interface{}{1, 5}}, Context{"cart": []string{"a", "b", "c"}}, true},
+		{"length on upper bound", Condition{Attribute: "cart", Operator: OperatorLengthBetween, Value: []interface{}{1, 3}}, Context{"cart": []int{1, 2, 3}}, true},
+		{"length above range", Condition{Attribute: "cart", Operator: OperatorLengthBetween, Value: []interface{}{1, 2}}, Context{"cart": []int{1, 2, 3}}, false},
+		{"string length counts characters", Condition{Attribute: "name", Operator: OperatorLengthBetween, Value: []interface{}{2, 2}}, Context{"name": "né"}, true},
+		{"missing has length 0", Condition{Attribute: "cart", Operator: OperatorLengthBetween, Value: []interface{}{0, 5}}, Context{}, true},
+		{"missing outside range", Condition{Attribute: "cart", Operator: OperatorLengthBetween, Value: []interface{}{1, 5}}, Context{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluate(tt.condition, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	for _, value := range []interface{}{nil, 3, []interface{}{1}, []interface{}{5, 1}, []interface{}{-1, 2}, []interface{}{1.5, 2}} {
+		cond := Condition{Attribute: "cart", Operator: OperatorLengthBetween, Value: value}
+		if err := cond.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected ErrInvalidCondition for %v, got %v", value, err)
+		}
+	}
+}
+
+func TestConditionEvaluator_Transform(t *testing.T) {
+	eval := newConditionEvaluator()
+	partners := []interface{}{"acme.com", "example.org"}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		ctx       Context
+		expected  bool
+	}{
+		{"email domain in list", Condition{Attribute: "email", Operator: OperatorIn, Value: partners, Transform: []Transform{TransformEmailDomain}}, Context{"email": "jane@acme.com"}, true},
+		{"email domain not in list", Condition{Attribute: "email", Operator: OperatorIn, Value: partners, Transform: []Transform{TransformEmailDomain}}, Context{"email": "jane@gmail.com"}, false},
+		{"trim then lower domain", Condition{Attribute: "email", Operator: OperatorIn, Value: partners, Transform: []Transform{TransformTrim, TransformLower, TransformEmailDomain}}, Context{"email": "  Jane@ACME.com "}, true},
+		{"trim and lower equality", Condition{Attribute: "plan", Operator: OperatorEqual, Value: "premium", Transform: []Transform{TransformTrim, TransformLower}}, Context{"plan": "  Premium\n"}, true},
+		{"without transform", Condition{Attribute: "plan", Operator: OperatorEqual, Value: "premium"}, Context{"plan": "  Premium\n"}, false},
+		{"upper", Condition{Attribute: "country", Operator: OperatorEqual, Value: "US", Transform: []Transform{TransformUpper}}, Context{"country": "us"}, true},
+		{"invalid email fails", Condition{Attribute: "email", Operator: OperatorIn, Value: partners, Transform: []Transform{TransformEmailDomain}}, Context{"email": "not-an-email"}, false},
+		{"invalid email fails negated", Condition{Attribute: "email", Operator: OperatorIn, Value: partners, Transform: []Transform{TransformEmailDomain}, Negate: true}, Context{"email": "not-an-email"}, false},
+		{"non-string unchanged", Condition{Attribute: "age", Operator: OperatorGreaterThan, Value: 18, Transform: []Transform{TransformLower}}, Context{"age": 21}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluate(tt.condition, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	cond := Condition{Attribute: "email", Operator: OperatorEqual, Value: "x", Transform: []Transform{"reverse"}}
+	if err := cond.Validate(); err != ErrInvalidCondition {
+		t.Errorf("expected ErrInvalidCondition, got %v", err)
+	}
+}
+
+func TestConditionEvaluator_RegexExtract(t *testing.T) {
+	eval := newConditionEvaluator()
+	consumer := []interface{}{"gmail.com", "yahoo.com"}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		ctx       Context
+		expected  bool
+	}{
+		{"extracted domain in list", Condition{Attribute: "email", RegexExtract: "@(.+)$", Operator: OperatorIn, Value: consumer}, Context{"email": "jane@gmail.com"}, true},
+		{"extracted domain not in list", Condition{Attribute: "email", RegexExtract: "@(.+)$", Operator: OperatorIn, Value: consumer}, Context{"email": "jane@acme.com"}, false},
+		{"extracted domain negated", Condition{Attribute: "email", RegexExtract: "@(.+)$", Operator: OperatorIn, Value: consumer, Negate: true}, Context{"email": "jane@acme.com"}, true},
+		{"no match fails", Condition{Attribute: "email", RegexExtract: "@(.+)$", Operator: OperatorIn, Value: consumer}, Context{"email": "not-an-email"}, false},
+		{"no match fails negated", Condition{Attribute: "email", RegexExtract: "@(.+)$", Operator: OperatorIn, Value: consumer, Negate: true}, Context{"email": "not-an-email"}, false},
+		{"tld", Condition{Attribute: "email", RegexExtract: `\.([a-z]+)$`, Operator: OperatorEqual, Value: "de"}, Context{"email": "hans@example.de"}, true},
+		{"path segment", Condition{Attribute: "path", RegexExtract: "^/api/(v[0-9]+)/", Operator: OperatorEqual, Value: "v2"}, Context{"path": "/api/v2/orders"}, true},
+		{"after transform", Condition{Attribute: "email", Transform: []Transform{TransformLower}, RegexExtract: "@(.+)$", Operator: OperatorIn, Value: consumer}, Context{"email": "Jane@GMAIL.com"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.evaluate(tt.condition, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	for _, pattern := range []string{"@.+$", "([a-z"} {
+		cond := Condition{Attribute: "email", RegexExtract: pattern, Operator: OperatorEqual, Value: "x"}
+		if err := cond.Validate(); err != ErrInvalidCondition {
+			t.Errorf("expected ErrInvalidCondition for %q, got %v", pattern, err)
+		}
+	}
+}
@@ -1,29 +1,144 @@
 package toggo
 
+import (
+	"fmt"
+	"time"
+)
+
 // Flag represents a feature flag configuration
 type Flag struct {
 	// Name is the unique identifier for this flag
 	Name string `json:"name" yaml:"name"`
 
+	// Extends names a parent flag whose conditions are prepended to this flag's
+	// and whose rollout settings are used when this flag leaves them unset.
+	// Inheritance is resolved when the flag is added to a store
+	Extends string `json:"extends,omitempty" yaml:"extends,omitempty"`
+
 	// Enabled controls whether this flag is active
 	Enabled bool `json:"enabled" yaml:"enabled"`
 
+	// DryRun computes the full decision and reports it to the evaluation hook,
+	// but callers always receive the disabled / DefaultVariant result
+	DryRun bool `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+
+	// Schedule optionally limits the flag to recurring active windows,
+	// evaluated against the store clock. Outside them the flag is disabled
+	Schedule *Schedule `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+
+	// TTL is the lifetime of a temporary flag, written as a duration string
+	// such as "168h" in configuration. Once the store clock is more than TTL
+	// past CreatedAt the flag evaluates as disabled and is listed by
+	// Store.ExpiredFlags. Zero means the flag never expires
+	TTL Duration `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+
+	// CreatedAt is when the flag was created, and is required when TTL is
+	// set. It is part of the configuration rather than stamped on load, so
+	// reloading or restarting never extends a flag's lifetime
+	CreatedAt *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+
 	// Rollout is the percentage (0-100) of users who should see this flag
 	// when all conditions are met
 	Rollout int `json:"rollout,omitempty" yaml:"rollout,omitempty"`
 
+	// Ramp optionally moves the rollout percentage over time.
+	// When set, it takes precedence over Rollout
+	Ramp *Ramp `json:"ramp,omitempty" yaml:"ramp,omitempty"`
+
+	// RampJitter staggers each user's position on the Ramp by a deterministic
+	// offset of up to this duration, so a steep ramp is spread over the
+	// jitter window instead of flipping everyone at once. It is written as a
+	// duration string such as "10m" in configuration
+	RampJitter Duration `json:"ramp_jitter,omitempty" yaml:"ramp_jitter,omitempty"`
+
+	// CohortRollout optionally picks the rollout percentage from a date
+	// attribute's cohort. When the context matches a cohort step it takes
+	// precedence over Rollout and Ramp
+	CohortRollout *CohortRollout `json:"cohort_rollout,omitempty" yaml:"cohort_rollout,omitempty"`
+
+	// RolloutFromAttribute optionally names a context attribute holding the
+	// rollout percentage, e.g. a per-tenant "tenant_rollout". A missing or
+	// out-of-range value falls back to Rollout
+	RolloutFromAttribute string `json:"rollout_from_attribute,omitempty" yaml:"rollout_from_attribute,omitempty"`
+
+	// KillPercent deterministically disables this percentage (0-100) of the
+	// users who would otherwise be enabled. Raising it gradually winds a
+	// feature down; the killed subset only grows as the percentage increases
+	KillPercent int `json:"kill_percent,omitempty" yaml:"kill_percent,omitempty"`
+
 	// RolloutKey specifies which context attribute to use for rollout hashing
-	// Defaults to "user_id" if not specified
+	// Defaults to the store's WithDefaultRolloutKey, or "user_id" if not specified
 	RolloutKey string `json:"rollout_key,omitempty" yaml:"rollout_key,omitempty"`
 
+	// RolloutKeyRules choose the rollout key per context, e.g. "account_id"
+	// for B2B users. The first rule whose conditions match wins; contexts
+	// matching none use RolloutKey
+	RolloutKeyRules []RolloutKeyRule `json:"rollout_key_rules,omitempty" yaml:"rollout_key_rules,omitempty"`
+
+	// SeedAttribute optionally names a context attribute, such as a session
+	// seed, combined with the rollout key in every hash. Changing the seed
+	// reshuffles assignment deterministically; contexts without it are treated
+	// like contexts missing the rollout key
+	SeedAttribute string `json:"seed_attribute,omitempty" yaml:"seed_attribute,omitempty"`
+
+	// RolloutScope optionally salts the rollout hash with a segment name.
+	// Rollout is only applied to contexts that pass Conditions, so Rollout
+	// already means "percentage of the conditioned segment"; a scope additionally
+	// gives the segment its own independent bucketing
+	RolloutScope string `json:"rollout_scope,omitempty" yaml:"rollout_scope,omitempty"`
+
 	// Conditions are the rules that must ALL be satisfied for the flag to be enabled
 	Conditions []Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
 
+	// ScoreThreshold switches Conditions from AND logic to scoring: the
+	// Scores of the matching conditions are summed and the conditions pass
+	// when the total reaches the threshold. 0 keeps AND logic
+	ScoreThreshold int `json:"score_threshold,omitempty" yaml:"score_threshold,omitempty"`
+
+	// ConditionsRef names a shared condition set from the configuration's
+	// definitions section. Loaders prepend the referenced conditions to
+	// Conditions before validation
+	ConditionsRef string `json:"conditions_ref,omitempty" yaml:"conditions_ref,omitempty"`
+
+	// AttributeSchema optionally declares the expected type of context attributes
+	// ("number", "string" or "bool"). Values are coerced before comparison and
+	// conditions on mismatching values fail (or error in strict mode)
+	AttributeSchema map[string]string `json:"attribute_schema,omitempty" yaml:"attribute_schema,omitempty"`
+
+	// RequiredAttributes lists context attributes the caller must provide.
+	// In strict mode evaluation fails with ErrRequiredAttributeMissing when one is absent
+	RequiredAttributes []string `json:"required_attributes,omitempty" yaml:"required_attributes,omitempty"`
+
 	// Variants enables A/B testing with multiple variations
 	// If set, IsEnabled returns false and GetVariant should be used instead
 	Variants []Variant `json:"variants,omitempty" yaml:"variants,omitempty"`
 
-	// DefaultVariant is returned when no variant matches
+	// SegmentOverrides pin contexts matching a segment's conditions to a
+	// variant before weighted assignment. The first matching segment wins
+	SegmentOverrides []SegmentOverride `json:"segment_overrides,omitempty" yaml:"segment_overrides,omitempty"`
+
+	// Dimensions model a factorial experiment: each dimension is assigned
+	// independently, see Store.GetMultivariate
+	Dimensions []Dimension `json:"dimensions,omitempty" yaml:"dimensions,omitempty"`
+
+	// Sticky controls whether variant assignment is deterministic per rollout
+	// key (the default). When set to false every evaluation makes a fresh
+	// weighted random draw, for one-shot interactions that should not stick
+	Sticky *bool `json:"sticky,omitempty" yaml:"sticky,omitempty"`
+
+	// Version salts every hash of the flag (rollout, variant, kill and jitter
+	// buckets). Bumping it deliberately re-randomizes all assignments; keeping
+	// it while appending a variant keeps existing users on their variant, see
+	// DefaultRolloutStrategy.GetVariant. Version 0 leaves hashes unsalted
+	Version int `json:"version,omitempty" yaml:"version,omitempty"`
+
+	// Salt replaces the flag name in every hash of the flag, so a renamed
+	// flag can keep its buckets. Defaults to Name
+	Salt string `json:"salt,omitempty" yaml:"salt,omitempty"`
+
+	// DefaultVariant is returned when no variant matches.
+	// When the flag has variants it must name one of them, and that
+	// variant's conditions gate the fallback as well
 	DefaultVariant string `json:"default_variant,omitempty" yaml:"default_variant,omitempty"`
 }
 
@@ -32,55 +147,306 @@ type Variant struct {
 	// Name is the variant identifier
 	Name string `json:"name" yaml:"name"`
 
-	// Weight is the percentage (0-100) of traffic allocated to this variant
+	// Weight is the percentage (0-100) of the overall population allocated to
+	// this variant. Weights are absolute caps, not shares of each other: when
+	// they sum to less than 100 the remainder falls through to DefaultVariant
 	Weight int `json:"weight" yaml:"weight"`
 
 	// Conditions are additional conditions specific to this variant
 	Conditions []Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+
+	// Groups must all match in addition to Conditions. They allow OR logic,
+	// e.g. a variant for "US users OR internal testers"
+	Groups []ConditionGroup `json:"groups,omitempty" yaml:"groups,omitempty"`
+
+	// StartsAt, if set, is when the variant becomes eligible for selection
+	StartsAt *time.Time `json:"starts_at,omitempty" yaml:"starts_at,omitempty"`
+
+	// EndsAt, if set, is when the variant stops being eligible. Outside its
+	// window a variant's weight is shared among the active variants
+	EndsAt *time.Time `json:"ends_at,omitempty" yaml:"ends_at,omitempty"`
+
+	// Payload is optional configuration delivered with the variant,
+	// e.g. {"button_color": "blue"}. It must be JSON-serializable
+	Payload interface{} `json:"payload,omitempty" yaml:"payload,omitempty"`
 }
 
-// Validate checks if the flag configuration is valid
+// Validate checks if the flag configuration is valid.
+// Errors are *FlagValidationError values wrapping the matching sentinel
 func (f *Flag) Validate() error {
 	if f.Name == "" {
-		return ErrInvalidCondition
+		return f.invalid("name", "must not be empty", ErrInvalidCondition)
 	}
 
 	if f.Rollout < 0 || f.Rollout > 100 {
-		return ErrInvalidRollout
+		return f.invalid("rollout", fmt.Sprintf("%d is not between 0 and 100", f.Rollout), ErrInvalidRollout)
+	}
+
+	if f.ScoreThreshold < 0 {
+		return f.invalid("score_threshold", "must not be negative", ErrInvalidCondition)
+	}
+
+	if f.KillPercent < 0 || f.KillPercent > 100 {
+		return f.invalid("kill_percent", fmt.Sprintf("%d is not between 0 and 100", f.KillPercent), ErrInvalidRollout)
+	}
+
+	if f.Schedule != nil {
+		if err := f.Schedule.Validate(); err != nil {
+			return f.invalidErr("schedule", err)
+		}
+	}
+
+	if f.Ramp != nil {
+		if err := f.Ramp.Validate(); err != nil {
+			return f.invalidErr("ramp", err)
+		}
+	}
+
+	if f.TTL < 0 {
+		return f.invalid("ttl", "must not be negative", ErrInvalidTTL)
+	}
+	if f.TTL > 0 && f.CreatedAt == nil {
+		return f.invalid("created_at", "is required when ttl is set", ErrInvalidTTL)
 	}
 
-	for _, cond := range f.Conditions {
+	if f.RampJitter < 0 {
+		return f.invalid("ramp_jitter", "must not be negative", ErrInvalidRollout)
+	}
+
+	if f.CohortRollout != nil {
+		if err := f.CohortRollout.Validate(); err != nil {
+			return f.invalidErr("cohort_rollout", err)
+		}
+	}
+
+	for i, cond := range f.Conditions {
 		if err := cond.Validate(); err != nil {
-			return err
+			return f.invalidErr(fmt.Sprintf("conditions[%d]", i), err)
+		}
+	}
+
+	if err := validateSchema(f.AttributeSchema); err != nil {
+		return f.invalidErr("attribute_schema", err)
+	}
+
+	for i, attr := range f.RequiredAttributes {
+		if attr == "" {
+			return f.invalid(fmt.Sprintf("required_attributes[%d]", i), "must not be empty", ErrInvalidCondition)
 		}
 	}
 
 	// Validate variants
 	totalWeight := 0
-	for _, variant := range f.Variants {
+	names := make(map[string]bool, len(f.Variants))
+	for i, variant := range f.Variants {
+		if variant.Name == "" {
+			return f.invalid(fmt.Sprintf("variants[%d].name", i), "must not be empty", ErrInvalidVariant)
+		}
+		if names[variant.Name] {
+			return f.invalid(fmt.Sprintf("variants[%d].name", i), fmt.Sprintf("duplicate variant %q", variant.Name), ErrInvalidVariant)
+		}
+		names[variant.Name] = true
 		if variant.Weight < 0 || variant.Weight > 100 {
-			return ErrInvalidRollout
+			return f.invalid(fmt.Sprintf("variants[%d].weight", i), fmt.Sprintf("%d is not between 0 and 100", variant.Weight), ErrInvalidRollout)
 		}
 		totalWeight += variant.Weight
-		for _, cond := range variant.Conditions {
+		if variant.StartsAt != nil && variant.EndsAt != nil && !variant.EndsAt.After(*variant.StartsAt) {
+			return f.invalid(fmt.Sprintf("variants[%d].ends_at", i), "must be after starts_at", ErrInvalidVariant)
+		}
+		for j, cond := range variant.Conditions {
 			if err := cond.Validate(); err != nil {
-				return err
+				return f.invalidErr(fmt.Sprintf("variants[%d].conditions[%d]", i, j), err)
+			}
+		}
+		for j := range variant.Groups {
+			if err := variant.Groups[j].Validate(); err != nil {
+				return f.invalidErr(fmt.Sprintf("variants[%d].groups[%d]", i, j), err)
 			}
 		}
 	}
 
 	if len(f.Variants) > 0 && totalWeight > 100 {
-		return ErrInvalidRollout
+		return f.invalid("variants", fmt.Sprintf("weights sum to %d, more than 100", totalWeight), ErrInvalidRollout)
+	}
+	if len(f.Variants) > 0 && f.DefaultVariant != "" && !names[f.DefaultVariant] {
+		return f.invalid("default_variant", fmt.Sprintf("%q does not name a variant", f.DefaultVariant), ErrInvalidVariant)
+	}
+
+	for i, segment := range f.SegmentOverrides {
+		if len(segment.Conditions) == 0 {
+			return f.invalid(fmt.Sprintf("segment_overrides[%d].conditions", i), "must not be empty", ErrInvalidCondition)
+		}
+		for j, cond := range segment.Conditions {
+			if err := cond.Validate(); err != nil {
+				return f.invalidErr(fmt.Sprintf("segment_overrides[%d].conditions[%d]", i, j), err)
+			}
+		}
+		if !names[segment.Variant] {
+			return f.invalid(fmt.Sprintf("segment_overrides[%d].variant", i), fmt.Sprintf("%q does not name a variant", segment.Variant), ErrInvalidVariant)
+		}
+	}
+
+	for i, rule := range f.RolloutKeyRules {
+		if rule.Key == "" {
+			return f.invalid(fmt.Sprintf("rollout_key_rules[%d].key", i), "must not be empty", ErrInvalidCondition)
+		}
+		if len(rule.Conditions) == 0 {
+			return f.invalid(fmt.Sprintf("rollout_key_rules[%d].conditions", i), "must not be empty", ErrInvalidCondition)
+		}
+		for j, cond := range rule.Conditions {
+			if err := cond.Validate(); err != nil {
+				return f.invalidErr(fmt.Sprintf("rollout_key_rules[%d].conditions[%d]", i, j), err)
+			}
+		}
+	}
+
+	for i := range f.Dimensions {
+		if err := f.Dimensions[i].Validate(); err != nil {
+			return f.invalidErr(fmt.Sprintf("dimensions[%d]", i), err)
+		}
 	}
 
 	return nil
 }
 
+// invalid builds a validation error for one of the flag's fields
+func (f *Flag) invalid(field, reason string, err error) error {
+	return &FlagValidationError{FlagName: f.Name, Field: field, Reason: reason, Err: err}
+}
+
+// invalidErr builds a validation error from a nested validation error,
+// using its message as the reason
+func (f *Flag) invalidErr(field string, err error) error {
+	return f.invalid(field, err.Error(), err)
+}
+
+// Normalize canonicalizes condition values on the flag and its variants.
+// Loaders call it before validation
+func (f *Flag) Normalize() {
+	f.EachConditionList(func(conditions []Condition) {
+		for i := range conditions {
+			conditions[i].Normalize()
+		}
+	})
+}
+
+// EachConditionList calls fn with every condition list the flag holds: its
+// Conditions, the conditions and nested groups of its variants and dimension
+// variants, its segment overrides and its rollout key rules. fn may modify
+// the conditions in place, e.g. a loader resolving condition values
+func (f *Flag) EachConditionList(fn func(conditions []Condition)) {
+	for _, list := range f.conditionLists() {
+		fn(*list)
+	}
+}
+
+// conditionLists returns a pointer to every condition list the flag holds
+func (f *Flag) conditionLists() []*[]Condition {
+	lists := []*[]Condition{&f.Conditions}
+	lists = appendVariantLists(lists, f.Variants)
+	for i := range f.SegmentOverrides {
+		lists = append(lists, &f.SegmentOverrides[i].Conditions)
+	}
+	for i := range f.RolloutKeyRules {
+		lists = append(lists, &f.RolloutKeyRules[i].Conditions)
+	}
+	for i := range f.Dimensions {
+		lists = appendVariantLists(lists, f.Dimensions[i].Variants)
+	}
+	return lists
+}
+
+func appendVariantLists(lists []*[]Condition, variants []Variant) []*[]Condition {
+	for i := range variants {
+		lists = append(lists, &variants[i].Conditions)
+		lists = appendGroupLists(lists, variants[i].Groups)
+	}
+	return lists
+}
+
+func appendGroupLists(lists []*[]Condition, groups []ConditionGroup) []*[]Condition {
+	for i := range groups {
+		lists = append(lists, &groups[i].Conditions)
+		lists = appendGroupLists(lists, groups[i].Groups)
+	}
+	return lists
+}
+
+// mapConditionLists returns a copy of the flag with every condition list
+// replaced by fn's result, stopping at the first error. The structures
+// holding the lists are copied, so f is left unchanged
+func (f *Flag) mapConditionLists(fn func([]Condition) ([]Condition, error)) (*Flag, error) {
+	mapped := *f
+	mapped.Variants = copyVariantHolders(f.Variants)
+	if f.SegmentOverrides != nil {
+		mapped.SegmentOverrides = append([]SegmentOverride(nil), f.SegmentOverrides...)
+	}
+	if f.RolloutKeyRules != nil {
+		mapped.RolloutKeyRules = append([]RolloutKeyRule(nil), f.RolloutKeyRules...)
+	}
+	if f.Dimensions != nil {
+		mapped.Dimensions = make([]Dimension, len(f.Dimensions))
+		for i, dim := range f.Dimensions {
+			mapped.Dimensions[i] = dim
+			mapped.Dimensions[i].Variants = copyVariantHolders(dim.Variants)
+		}
+	}
+
+	for _, list := range mapped.conditionLists() {
+		var err error
+		if *list, err = fn(*list); err != nil {
+			return nil, err
+		}
+	}
+	return &mapped, nil
+}
+
+// copyVariantHolders copies variants and their group trees, sharing the
+// condition lists themselves
+func copyVariantHolders(variants []Variant) []Variant {
+	if variants == nil {
+		return nil
+	}
+	copied := make([]Variant, len(variants))
+	for i, variant := range variants {
+		copied[i] = variant
+		copied[i].Groups = copyGroupHolders(variant.Groups)
+	}
+	return copied
+}
+
+func copyGroupHolders(groups []ConditionGroup) []ConditionGroup {
+	if groups == nil {
+		return nil
+	}
+	copied := make([]ConditionGroup, len(groups))
+	for i, group := range groups {
+		copied[i] = group
+		copied[i].Groups = copyGroupHolders(group.Groups)
+	}
+	return copied
+}
+
 // HasVariants returns true if this flag has A/B test variants configured
 func (f *Flag) HasVariants() bool {
 	return len(f.Variants) > 0
 }
 
+// IsSticky reports whether variant assignment is deterministic per rollout key
+func (f *Flag) IsSticky() bool {
+	return f.Sticky == nil || *f.Sticky
+}
+
+// GetVariantByName returns the variant with the given name, if configured
+func (f *Flag) GetVariantByName(name string) (*Variant, bool) {
+	for i := range f.Variants {
+		if f.Variants[i].Name == name {
+			return &f.Variants[i], true
+		}
+	}
+	return nil, false
+}
+
 // GetRolloutKey returns the key to use for rollout hashing
 func (f *Flag) GetRolloutKey() string {
 	if f.RolloutKey != "" {
@@ -88,3 +454,37 @@ func (f *Flag) GetRolloutKey() string {
 	}
 	return "user_id" // default
 }
+
+// hashSalt returns the string identifying the flag in hash keys
+func (f *Flag) hashSalt() string {
+	if f.Salt != "" {
+		return f.Salt
+	}
+	return f.Name
+}
+
+// ExpiredAt reports whether the flag's TTL has elapsed at now
+func (f *Flag) ExpiredAt(now time.Time) bool {
+	return f.TTL > 0 && f.CreatedAt != nil && now.Sub(*f.CreatedAt) > time.Duration(f.TTL)
+}
+
+// rolloutKeyValue returns the value hashed for rollout decisions: the rollout
+// key's value, prefixed with the SeedAttribute value when the flag has one
+// and with the Version when it is set
+func (f *Flag) rolloutKeyValue(ctx Context) (interface{}, bool) {
+	keyValue, exists := ctx.Get(f.GetRolloutKey())
+	if !exists {
+		return nil, false
+	}
+	if f.SeedAttribute != "" {
+		seed, exists := ctx.Get(f.SeedAttribute)
+		if !exists {
+			return nil, false
+		}
+		keyValue = fmt.Sprintf("%v:%v", seed, keyValue)
+	}
+	if f.Version != 0 {
+		keyValue = fmt.Sprintf("v%d:%v", f.Version, keyValue)
+	}
+	return keyValue, true
+}
@@ -0,0 +1,83 @@
+package toggo
+
+import "math"
+
+// earthRadiusKm is the mean Earth radius used for haversine distances
+const earthRadiusKm = 6371.0
+
+// geoRadius is the parsed condition value of OperatorWithinRadius
+type geoRadius struct {
+	lat, lng, radiusKm float64
+}
+
+// parseGeoRadius reads a {"lat": ..., "lng": ..., "radius_km": ...} condition value
+func parseGeoRadius(value interface{}) (geoRadius, bool) {
+	lat, lng, ok := parseGeoPoint(value)
+	if !ok {
+		return geoRadius{}, false
+	}
+	radius, ok := mapNumber(value, "radius_km")
+	if !ok || radius <= 0 {
+		return geoRadius{}, false
+	}
+	return geoRadius{lat: lat, lng: lng, radiusKm: radius}, true
+}
+
+// parseGeoPoint reads a location given either as a map with "lat" and "lng"
+// keys or as a two-element [lat, lng] list
+func parseGeoPoint(value interface{}) (float64, float64, bool) {
+	var lat, lng float64
+	var ok1, ok2 bool
+
+	switch v := value.(type) {
+	case map[string]interface{}, map[string]float64:
+		lat, ok1 = mapNumber(v, "lat")
+		lng, ok2 = mapNumber(v, "lng")
+	case []interface{}:
+		if len(v) != 2 {
+			return 0, 0, false
+		}
+		var err1, err2 error
+		lat, err1 = toFloat64(v[0])
+		lng, err2 = toFloat64(v[1])
+		ok1, ok2 = err1 == nil, err2 == nil
+	case []float64:
+		if len(v) != 2 {
+			return 0, 0, false
+		}
+		lat, lng, ok1, ok2 = v[0], v[1], true, true
+	}
+
+	if !ok1 || !ok2 || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, false
+	}
+	return lat, lng, true
+}
+
+// mapNumber reads a numeric entry from a string-keyed map
+func mapNumber(value interface{}, key string) (float64, bool) {
+	switch m := value.(type) {
+	case map[string]float64:
+		n, ok := m[key]
+		return n, ok
+	case map[string]interface{}:
+		raw, ok := m[key]
+		if !ok {
+			return 0, false
+		}
+		n, err := toFloat64(raw)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// haversineKm returns the great-circle distance between two points in kilometres
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,125 @@
+package toggo
+
+// GroupLogic is how a ConditionGroup combines its members
+type GroupLogic string
+
+const (
+	// LogicAnd requires every member of the group to match. It is the default
+	LogicAnd GroupLogic = "and"
+
+	// LogicOr requires at least one member of the group to match
+	LogicOr GroupLogic = "or"
+)
+
+// ConditionGroup combines conditions and nested groups with AND or OR logic,
+// e.g. "country == US OR internal == true"
+type ConditionGroup struct {
+	// Logic is LogicAnd (the default when empty) or LogicOr
+	Logic GroupLogic `json:"logic,omitempty" yaml:"logic,omitempty"`
+
+	// Conditions are the group's condition members
+	Conditions []Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+
+	// Groups are nested groups, evaluated as members alongside Conditions
+	Groups []ConditionGroup `json:"groups,omitempty" yaml:"groups,omitempty"`
+
+	// Negate inverts the group's combined result, e.g. NOT (US AND premium).
+	// Members, including negated nested groups, are evaluated first
+	Negate bool `json:"negate,omitempty" yaml:"negate,omitempty"`
+}
+
+// Validate checks the group's logic and, recursively, its members.
+// A group must have at least one member
+func (g *ConditionGroup) Validate() error {
+	if g.Logic != "" && g.Logic != LogicAnd && g.Logic != LogicOr {
+		return ErrInvalidCondition
+	}
+	if len(g.Conditions) == 0 && len(g.Groups) == 0 {
+		return ErrInvalidCondition
+	}
+	for i := range g.Conditions {
+		if err := g.Conditions[i].Validate(); err != nil {
+			return err
+		}
+	}
+	for i := range g.Groups {
+		if err := g.Groups[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Normalize canonicalizes the condition values of the group and its nested groups
+func (g *ConditionGroup) Normalize() {
+	for i := range g.Conditions {
+		g.Conditions[i].Normalize()
+	}
+	for i := range g.Groups {
+		g.Groups[i].Normalize()
+	}
+}
+
+// Clone returns a deep copy of the group
+func (g *ConditionGroup) Clone() ConditionGroup {
+	clone := *g
+	clone.Conditions = cloneConditions(g.Conditions)
+	clone.Groups = cloneGroups(g.Groups)
+	return clone
+}
+
+func cloneGroups(groups []ConditionGroup) []ConditionGroup {
+	if groups == nil {
+		return nil
+	}
+	clone := make([]ConditionGroup, len(groups))
+	for i := range groups {
+		clone[i] = groups[i].Clone()
+	}
+	return clone
+}
+
+// evaluateGroups checks if every group matches (AND logic between groups)
+func (e *conditionEvaluator) evaluateGroups(groups []ConditionGroup, ctx Context, schema map[string]string) (bool, error) {
+	for _, group := range groups {
+		match, err := e.evaluateGroup(group, ctx, schema)
+		if err != nil || !match {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// evaluateGroup evaluates a group, applying its Negate
+func (e *conditionEvaluator) evaluateGroup(group ConditionGroup, ctx Context, schema map[string]string) (bool, error) {
+	match, err := e.combineGroup(group, ctx, schema)
+	if err != nil {
+		return false, err
+	}
+	return e.applyNegate(match, group.Negate), nil
+}
+
+// combineGroup combines the results of a group's members with its logic
+func (e *conditionEvaluator) combineGroup(group ConditionGroup, ctx Context, schema map[string]string) (bool, error) {
+	if group.Logic != LogicOr {
+		match, err := e.evaluateAllWithSchema(group.Conditions, ctx, schema)
+		if err != nil || !match {
+			return false, err
+		}
+		return e.evaluateGroups(group.Groups, ctx, schema)
+	}
+
+	for _, cond := range group.Conditions {
+		match, err := e.evaluateWithSchema(cond, ctx, schema)
+		if err != nil || match {
+			return match, err
+		}
+	}
+	for _, nested := range group.Groups {
+		match, err := e.evaluateGroup(nested, ctx, schema)
+		if err != nil || match {
+			return match, err
+		}
+	}
+	return false, nil
+}
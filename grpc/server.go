@@ -0,0 +1,163 @@
+// Package togglegrpc serves a toggo Store over gRPC so that non-Go services can
+// evaluate flags through a sidecar. The service is defined in
+// togglepb/toggo.proto.
+//
+//	lis, _ := net.Listen("tcp", ":9090")
+//	srv := googlegrpc.NewServer()
+//	togglepb.RegisterFlagServiceServer(srv, togglegrpc.NewServer(store))
+//	srv.Serve(lis)
+package togglegrpc
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/pedrampdd/toggo"
+	"github.com/pedrampdd/toggo/grpc/togglepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultWatchQueueSize is the number of changes a WatchFlags stream may
+// have waiting to be sent before it is ended
+const DefaultWatchQueueSize = 1024
+
+// Server implements togglepb.FlagServiceServer backed by a *toggo.Store
+type Server struct {
+	togglepb.UnimplementedFlagServiceServer
+
+	store          *toggo.Store
+	watchQueueSize int
+}
+
+// ServerOption configures a Server
+type ServerOption func(*Server)
+
+// WithWatchQueueSize sets how many changes a WatchFlags stream may have
+// waiting to be sent. Defaults to DefaultWatchQueueSize
+func WithWatchQueueSize(size int) ServerOption {
+	return func(s *Server) {
+		s.watchQueueSize = size
+	}
+}
+
+// NewServer creates a gRPC flag service for store
+func NewServer(store *toggo.Store, opts ...ServerOption) *Server {
+	s := &Server{store: store}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.watchQueueSize <= 0 {
+		s.watchQueueSize = DefaultWatchQueueSize
+	}
+	return s
+}
+
+// Evaluate returns the full evaluation result for a flag
+func (s *Server) Evaluate(ctx context.Context, req *togglepb.EvaluateRequest) (*togglepb.EvaluateResponse, error) {
+	result, err := s.store.Evaluate(req.GetFlag(), toContext(req.GetContext()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &togglepb.EvaluateResponse{
+		Flag:    result.Flag,
+		Enabled: result.Enabled,
+		Variant: result.Variant,
+		Reason:  string(result.Reason),
+	}, nil
+}
+
+// GetVariant returns the variant assigned to the context
+func (s *Server) GetVariant(ctx context.Context, req *togglepb.GetVariantRequest) (*togglepb.GetVariantResponse, error) {
+	variant, enabled, err := s.store.GetVariantWithError(req.GetFlag(), toContext(req.GetContext()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &togglepb.GetVariantResponse{Variant: variant, Enabled: enabled}, nil
+}
+
+// ListFlags returns the names of all flags, sorted
+func (s *Server) ListFlags(ctx context.Context, req *togglepb.ListFlagsRequest) (*togglepb.ListFlagsResponse, error) {
+	names := s.store.ListFlags()
+	sort.Strings(names)
+	return &togglepb.ListFlagsResponse{Flags: names}, nil
+}
+
+// WatchFlags streams every change published by Store.Subscribe, in order,
+// until the client disconnects. Changes are queued so a slow client never
+// blocks writers to the store. A client that falls more than the watch queue
+// size behind has its stream ended with codes.ResourceExhausted and should
+// resubscribe and re-read the flags it cares about
+func (s *Server) WatchFlags(req *togglepb.WatchFlagsRequest, stream togglepb.FlagService_WatchFlagsServer) error {
+	var mu sync.Mutex
+	var pending []toggo.FlagChange
+	var overflowed bool
+	notify := make(chan struct{}, 1)
+
+	unsubscribe := s.store.Subscribe(func(change toggo.FlagChange) {
+		mu.Lock()
+		if len(pending) >= s.watchQueueSize {
+			// Drop the queue; the stream ends once the sender wakes up
+			overflowed, pending = true, nil
+		} else if !overflowed {
+			pending = append(pending, change)
+		}
+		mu.Unlock()
+
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-notify:
+			mu.Lock()
+			changes, lost := pending, overflowed
+			pending = nil
+			mu.Unlock()
+
+			if lost {
+				return status.Errorf(codes.ResourceExhausted, "more than %d flag changes pending", s.watchQueueSize)
+			}
+
+			for _, change := range changes {
+				event := &togglepb.FlagChangeEvent{Flag: change.Flag, Kind: string(change.Kind)}
+				if err := stream.Send(event); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// toContext converts a protobuf Struct into an evaluation context
+func toContext(s *structpb.Struct) toggo.Context {
+	if s == nil {
+		return toggo.Context{}
+	}
+	return toggo.Context(s.AsMap())
+}
+
+// toStatus maps toggo errors onto gRPC status codes
+func toStatus(err error) error {
+	var validationErr *toggo.FlagValidationError
+	switch {
+	case errors.Is(err, toggo.ErrFlagNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, toggo.ErrEvaluationTimeout):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, toggo.ErrRequiredAttributeMissing), errors.Is(err, toggo.ErrAttributeType),
+		errors.As(err, &validationErr):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
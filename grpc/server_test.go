@@ -0,0 +1,251 @@
+package togglegrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/toggo"
+	"github.com/pedrampdd/toggo/grpc/togglepb"
+	googlegrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// newTestClient serves store over an in-process connection
+func newTestClient(t *testing.T, store *toggo.Store) togglepb.FlagServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := googlegrpc.NewServer()
+	togglepb.RegisterFlagServiceServer(srv, NewServer(store))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := googlegrpc.NewClient("passthrough:///bufnet",
+		googlegrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		googlegrpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return togglepb.NewFlagServiceClient(conn)
+}
+
+func newTestStore(t *testing.T) *toggo.Store {
+	t.Helper()
+
+	store := toggo.NewStore()
+	err := store.AddFlags([]*toggo.Flag{
+		{
+			Name:    "new_checkout",
+			Enabled: true,
+			Rollout: 100,
+			Conditions: []toggo.Condition{
+				{Attribute: "country", Operator: toggo.OperatorEqual, Value: "US"},
+			},
+		},
+		{
+			Name:           "button_color",
+			Enabled:        true,
+			DefaultVariant: "blue",
+			Variants: []toggo.Variant{
+				{Name: "red", Weight: 100},
+				{Name: "blue", Weight: 0},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return store
+}
+
+func mustStruct(t *testing.T, m map[string]interface{}) *structpb.Struct {
+	t.Helper()
+
+	s, err := structpb.NewStruct(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s
+}
+
+func TestServer_Evaluate(t *testing.T) {
+	client := newTestClient(t, newTestStore(t))
+
+	tests := []struct {
+		name        string
+		context     map[string]interface{}
+		wantEnabled bool
+		wantReason  string
+	}{
+		{"matching context", map[string]interface{}{"user_id": "1", "country": "US"}, true, string(toggo.ReasonRolloutIncluded)},
+		{"non-matching context", map[string]interface{}{"user_id": "1", "country": "DE"}, false, string(toggo.ReasonConditionsNotMet)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Evaluate(context.Background(), &togglepb.EvaluateRequest{
+				Flag:    "new_checkout",
+				Context: mustStruct(t, tt.context),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.GetEnabled() != tt.wantEnabled {
+				t.Errorf("expected %v, got %v", tt.wantEnabled, resp.GetEnabled())
+			}
+			if resp.GetReason() != tt.wantReason {
+				t.Errorf("expected %v, got %v", tt.wantReason, resp.GetReason())
+			}
+		})
+	}
+}
+
+func TestServer_Evaluate_NotFound(t *testing.T) {
+	client := newTestClient(t, newTestStore(t))
+
+	_, err := client.Evaluate(context.Background(), &togglepb.EvaluateRequest{Flag: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected %v, got %v", codes.NotFound, status.Code(err))
+	}
+}
+
+func TestServer_GetVariant(t *testing.T) {
+	client := newTestClient(t, newTestStore(t))
+
+	resp, err := client.GetVariant(context.Background(), &togglepb.GetVariantRequest{
+		Flag:    "button_color",
+		Context: mustStruct(t, map[string]interface{}{"user_id": "42"}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.GetVariant() != "red" {
+		t.Errorf("expected %v, got %v", "red", resp.GetVariant())
+	}
+	if !resp.GetEnabled() {
+		t.Error("expected variant to be enabled")
+	}
+}
+
+func TestServer_ListFlags(t *testing.T) {
+	client := newTestClient(t, newTestStore(t))
+
+	resp, err := client.ListFlags(context.Background(), &togglepb.ListFlagsRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"button_color", "new_checkout"}
+	if len(resp.GetFlags()) != len(want) {
+		t.Fatalf("expected %v, got %v", want, resp.GetFlags())
+	}
+	for i, name := range want {
+		if resp.GetFlags()[i] != name {
+			t.Errorf("expected %v, got %v", name, resp.GetFlags()[i])
+		}
+	}
+}
+
+func TestServer_WatchFlags(t *testing.T) {
+	store := newTestStore(t)
+	client := newTestClient(t, store)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchFlags(ctx, &togglepb.WatchFlagsRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Give the server time to subscribe before changing the store
+	time.Sleep(50 * time.Millisecond)
+	if err := store.AddFlag(&toggo.Flag{Name: "dark_mode", Enabled: true, Rollout: 100}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.GetFlag() != "dark_mode" {
+		t.Errorf("expected %v, got %v", "dark_mode", event.GetFlag())
+	}
+	if event.GetKind() != string(toggo.ChangeAdded) {
+		t.Errorf("expected %v, got %v", toggo.ChangeAdded, event.GetKind())
+	}
+}
+
+// stalledStream is a WatchFlags stream whose Send blocks until released
+type stalledStream struct {
+	togglepb.FlagService_WatchFlagsServer
+
+	ctx     context.Context
+	sent    chan struct{}
+	release chan struct{}
+}
+
+func (s *stalledStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *stalledStream) Send(*togglepb.FlagChangeEvent) error {
+	s.sent <- struct{}{}
+	<-s.release
+	return nil
+}
+
+func TestServer_WatchFlagsQueueLimit(t *testing.T) {
+	store := toggo.NewStore()
+	server := NewServer(store, WithWatchQueueSize(2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream := &stalledStream{ctx: ctx, sent: make(chan struct{}, 1), release: make(chan struct{})}
+	done := make(chan error, 1)
+	go func() { done <- server.WatchFlags(&togglepb.WatchFlagsRequest{}, stream) }()
+
+	// Wait for the subscription, then stall the stream on its first send
+	deadline := time.Now().Add(time.Second)
+	for i := 0; ; i++ {
+		store.AddFlag(&toggo.Flag{Name: fmt.Sprintf("first_%d", i), Enabled: true})
+		select {
+		case <-stream.sent:
+		case <-time.After(10 * time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatal("stream never sent the first change")
+			}
+			continue
+		}
+		break
+	}
+
+	// Writers are never blocked while the client is stalled
+	for _, name := range []string{"a", "b", "c"} {
+		if err := store.AddFlag(&toggo.Flag{Name: name, Enabled: true}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	close(stream.release)
+
+	select {
+	case err := <-done:
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Errorf("expected %v, got %v", codes.ResourceExhausted, err)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected the stream to end once the queue overflowed")
+	}
+}
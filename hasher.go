@@ -0,0 +1,19 @@
+package toggo
+
+import "github.com/pedrampdd/toggo/internal/hash"
+
+// Hasher maps a string to a deterministic bucket between 0 and 99
+type Hasher = hash.Hasher
+
+// FloatHasher is a Hasher that also maps a string to a uniform value in [0, 1)
+type FloatHasher = hash.FloatHasher
+
+// NewFNVHasher returns the FNV-1a hasher used by default
+func NewFNVHasher() FloatHasher {
+	return hash.NewFNV()
+}
+
+// NewMurmur3Hasher returns a 32-bit MurmurHash3 hasher
+func NewMurmur3Hasher() FloatHasher {
+	return hash.NewMurmur3()
+}
@@ -0,0 +1,87 @@
+package toggo
+
+import "fmt"
+
+// resolveInheritance merges a flag with the parent named by its Extends field.
+// The parent must already be in flags. The returned flag is a copy whose
+// conditions are the parent's followed by the child's; Rollout, Ramp,
+// RolloutKey and RolloutKeyRules are inherited when the child leaves them unset.
+// Must be called with the store lock held
+func resolveInheritance(flag *Flag, flags map[string]*Flag) (*Flag, error) {
+	if flag.Extends == "" {
+		return flag, nil
+	}
+
+	parent, ok := flags[flag.Extends]
+	if !ok {
+		return nil, fmt.Errorf("%w: parent %q of %q", ErrFlagNotFound, flag.Extends, flag.Name)
+	}
+
+	// Walk the stored chain to make sure the parent does not descend from this flag
+	for ancestor := parent; ancestor != nil; ancestor = flags[ancestor.Extends] {
+		if ancestor.Name == flag.Name {
+			return nil, ErrInheritanceCycle
+		}
+	}
+
+	resolved := *flag
+	resolved.Conditions = make([]Condition, 0, len(parent.Conditions)+len(flag.Conditions))
+	resolved.Conditions = append(resolved.Conditions, parent.Conditions...)
+	resolved.Conditions = append(resolved.Conditions, flag.Conditions...)
+
+	if flag.Rollout == 0 && flag.Ramp == nil {
+		resolved.Rollout = parent.Rollout
+		resolved.Ramp = parent.Ramp
+	}
+	if flag.RolloutKey == "" {
+		resolved.RolloutKey = parent.RolloutKey
+	}
+	if flag.RolloutKeyRules == nil {
+		resolved.RolloutKeyRules = parent.RolloutKeyRules
+	}
+
+	return &resolved, nil
+}
+
+// orderByInheritance sorts a batch of flags so that parents precede the
+// children that extend them. Parents outside the batch are left to be
+// resolved against the store. Returns ErrInheritanceCycle on cycles
+func orderByInheritance(flags []*Flag) ([]*Flag, error) {
+	byName := make(map[string]*Flag, len(flags))
+	for _, flag := range flags {
+		byName[flag.Name] = flag
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(flags))
+	ordered := make([]*Flag, 0, len(flags))
+
+	var visit func(flag *Flag) error
+	visit = func(flag *Flag) error {
+		switch state[flag.Name] {
+		case visiting:
+			return ErrInheritanceCycle
+		case done:
+			return nil
+		}
+		state[flag.Name] = visiting
+		if parent, ok := byName[flag.Extends]; ok && flag.Extends != "" {
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+		state[flag.Name] = done
+		ordered = append(ordered, flag)
+		return nil
+	}
+
+	for _, flag := range flags {
+		if err := visit(flag); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -14,7 +14,17 @@ func NewFNV() *FNVHasher {
 
 // Hash returns a deterministic hash value between 0 and 99
 func (h *FNVHasher) Hash(s string) int {
+	return int(fnv32a(s) % 100)
+}
+
+// HashFloat returns a deterministic hash value in [0, 1) from the full
+// 32-bit FNV-1a output
+func (h *FNVHasher) HashFloat(s string) float64 {
+	return toUnit(fnv32a(s))
+}
+
+func fnv32a(s string) uint32 {
 	hasher := fnv.New32a()
 	hasher.Write([]byte(s))
-	return int(hasher.Sum32() % 100)
+	return hasher.Sum32()
 }
@@ -1,6 +1,9 @@
 package hash
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+)
 
 func TestFNVHasher_Deterministic(t *testing.T) {
 	hasher := NewFNV()
@@ -56,3 +59,41 @@ func TestFNVHasher_Distribution(t *testing.T) {
 		t.Errorf("hash3 out of range: %d", hash3)
 	}
 }
+
+func TestFNVHasher_HashFloat(t *testing.T) {
+	hasher := NewFNV()
+
+	if a, b := hasher.HashFloat("test:user123"), hasher.HashFloat("test:user123"); a != b {
+		t.Errorf("hash is not deterministic: got %v, %v", a, b)
+	}
+
+	const keys, buckets = 100000, 10
+	counts := make([]int, buckets)
+	for i := 0; i < keys; i++ {
+		f := hasher.HashFloat(fmt.Sprintf("flag:user%d", i))
+		if f < 0 || f >= 1 {
+			t.Fatalf("hash out of range [0, 1): got %v", f)
+		}
+		counts[int(f*buckets)]++
+	}
+	for i, count := range counts {
+		// Each decile should hold about 10% of keys
+		if count < keys/buckets*9/10 || count > keys/buckets*11/10 {
+			t.Errorf("decile %d: expected about %d keys, got %d", i, keys/buckets, count)
+		}
+	}
+}
+
+func TestToUnit_Bounds(t *testing.T) {
+	if got := toUnit(0); got != 0 {
+		t.Errorf("expected 0, got %v", got)
+	}
+	if got := toUnit(^uint32(0)); got >= 1 {
+		t.Errorf("expected < 1, got %v", got)
+	}
+}
+
+func TestHashers_ImplementFloatHasher(t *testing.T) {
+	var _ FloatHasher = NewFNV()
+	var _ FloatHasher = NewMurmur3()
+}
@@ -5,3 +5,17 @@ type Hasher interface {
 	// Hash takes a string and returns a hash value between 0 and 99 (percentage)
 	Hash(s string) int
 }
+
+// FloatHasher is a Hasher that can also return a uniform value in [0, 1),
+// for bucketing finer than whole percentages
+type FloatHasher interface {
+	Hasher
+
+	// HashFloat returns a deterministic hash value in [0, 1)
+	HashFloat(s string) float64
+}
+
+// toUnit maps a 32-bit hash to [0, 1) using all of its bits
+func toUnit(sum uint32) float64 {
+	return float64(sum) / (1 << 32)
+}
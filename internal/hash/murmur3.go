@@ -0,0 +1,72 @@
+package hash
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Murmur3Hasher implements deterministic hashing using 32-bit MurmurHash3,
+// which distributes short, similar keys more evenly than FNV-1a
+type Murmur3Hasher struct{}
+
+// NewMurmur3 creates a new MurmurHash3 hasher
+func NewMurmur3() *Murmur3Hasher {
+	return &Murmur3Hasher{}
+}
+
+// Hash returns a deterministic hash value between 0 and 99
+func (h *Murmur3Hasher) Hash(s string) int {
+	return int(murmur3([]byte(s), 0) % 100)
+}
+
+// HashFloat returns a deterministic hash value in [0, 1) from the full
+// 32-bit MurmurHash3 output
+func (h *Murmur3Hasher) HashFloat(s string) float64 {
+	return toUnit(murmur3([]byte(s), 0))
+}
+
+// murmur3 is the x86 32-bit variant of MurmurHash3
+func murmur3(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+
+	h := seed
+	n := len(data) / 4
+	for i := 0; i < n; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	var k uint32
+	tail := data[n*4:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,41 @@
+package hash
+
+import "testing"
+
+func TestMurmur3_KnownValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		seed     uint32
+		expected uint32
+	}{
+		{"", 0, 0},
+		{"", 1, 0x514e28b7},
+		{"hello", 0, 0x248bfa47},
+		{"Hello, world!", 1234, 0xfaf6cdb3},
+		{"The quick brown fox jumps over the lazy dog", 0, 0x2e4ff723},
+	}
+
+	for _, tt := range tests {
+		if got := murmur3([]byte(tt.input), tt.seed); got != tt.expected {
+			t.Errorf("murmur3(%q, %d): expected %#x, got %#x", tt.input, tt.seed, tt.expected, got)
+		}
+	}
+}
+
+func TestMurmur3Hasher_Range(t *testing.T) {
+	hasher := NewMurmur3()
+
+	counts := make([]int, 100)
+	for i := 0; i < 10000; i++ {
+		hash := hasher.Hash("flag:user" + string(rune('a'+i%26)) + string(rune(i)))
+		if hash < 0 || hash >= 100 {
+			t.Fatalf("hash out of range [0, 100): got %d", hash)
+		}
+		counts[hash]++
+	}
+	for bucket, count := range counts {
+		if count == 0 {
+			t.Errorf("expected bucket %d to be used", bucket)
+		}
+	}
+}
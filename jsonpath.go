@@ -0,0 +1,86 @@
+package toggo
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// parseJSONPath splits a simple path such as "$.subscription.tier" or
+// "$.orders[0].amount" into map keys and list indexes
+func parseJSONPath(path string) ([]string, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return nil, false
+	}
+
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		for {
+			open := strings.Index(part, "[")
+			if open < 0 {
+				break
+			}
+			end := strings.Index(part, "]")
+			if end < open {
+				return nil, false
+			}
+			if open > 0 {
+				segments = append(segments, part[:open])
+			}
+			segments = append(segments, part[open:end+1])
+			part = part[end+1:]
+		}
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments, len(segments) > 0
+}
+
+// extractJSONPath decodes a JSON document held in a context value and returns
+// the value at path. Returns false for invalid JSON or a missing path
+func extractJSONPath(value interface{}, path string) (interface{}, bool) {
+	var raw []byte
+	switch v := value.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	case json.RawMessage:
+		raw = v
+	default:
+		return nil, false
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, false
+	}
+
+	segments, ok := parseJSONPath(path)
+	if !ok {
+		return nil, false
+	}
+
+	current := doc
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, "[") {
+			index, err := strconv.Atoi(segment[1 : len(segment)-1])
+			list, ok := current.([]interface{})
+			if err != nil || !ok || index < 0 || index >= len(list) {
+				return nil, false
+			}
+			current = list[index]
+			continue
+		}
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[segment]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,22 @@
+package toggo
+
+import "fmt"
+
+// killed reports whether the rollout key falls inside the flag's KillPercent.
+// The kill bucket is hashed independently of the rollout bucket so that the
+// killed users are spread evenly over the enabled population. Contexts
+// without a rollout key are never killed
+func (s *Store) killed(flag *Flag, ctx Context) bool {
+	if flag.KillPercent <= 0 {
+		return false
+	}
+	if flag.KillPercent >= 100 {
+		return true
+	}
+
+	keyValue, exists := flag.rolloutKeyValue(ctx)
+	if !exists {
+		return false
+	}
+	return s.evaluator.hasher.Hash(fmt.Sprintf("%s:kill:%s", flag.hashSalt(), fmt.Sprint(keyValue))) < flag.KillPercent
+}
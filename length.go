@@ -0,0 +1,56 @@
+package toggo
+
+import (
+	"reflect"
+	"unicode/utf8"
+)
+
+// valueLength returns the number of characters in a string or elements in a
+// list or map. nil, a missing attribute, has length 0. Other types have no length
+func valueLength(value interface{}) (int, bool) {
+	if value == nil {
+		return 0, true
+	}
+	if s, ok := value.(string); ok {
+		return utf8.RuneCountInString(s), true
+	}
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len(), true
+	}
+	return 0, false
+}
+
+// parseLengthRange parses a [min, max] pair of non-negative whole numbers
+// with min <= max
+func parseLengthRange(value interface{}) (int, int, bool) {
+	if !isList(value) {
+		return 0, 0, false
+	}
+	items := listItems(value)
+	if len(items) != 2 {
+		return 0, 0, false
+	}
+	bounds := make([]int, 2)
+	for i, item := range items {
+		n, err := toFloat64(item)
+		if err != nil || n < 0 || n != float64(int(n)) {
+			return 0, 0, false
+		}
+		bounds[i] = int(n)
+	}
+	if bounds[0] > bounds[1] {
+		return 0, 0, false
+	}
+	return bounds[0], bounds[1], true
+}
+
+// evaluateLengthBetween checks if the context value's length is within the condition range
+func evaluateLengthBetween(ctxValue, condValue interface{}) bool {
+	n, ok := valueLength(ctxValue)
+	if !ok {
+		return false
+	}
+	min, max, ok := parseLengthRange(condValue)
+	return ok && n >= min && n <= max
+}
@@ -0,0 +1,92 @@
+package loader
+
+import (
+	"encoding/gob"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pedrampdd/toggo"
+)
+
+func init() {
+	// Concrete types that condition values, defaults and payloads hold once
+	// decoded from JSON or YAML
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+	gob.Register(time.Time{})
+	gob.Register(toggo.StringSet{})
+}
+
+// GobLoader loads feature flags from the binary encoding written by DumpGob.
+// Decoding skips text parsing, so a dump cached from a previous run starts
+// faster than reloading the original JSON or YAML; flags are still validated
+type GobLoader struct {
+	source interface{} // can be string (file path) or io.Reader
+}
+
+// NewGobFile creates a loader that reads a DumpGob file
+func NewGobFile(filepath string) *GobLoader {
+	return &GobLoader{source: filepath}
+}
+
+// NewGobReader creates a loader that reads DumpGob output from an io.Reader
+func NewGobReader(reader io.Reader) *GobLoader {
+	return &GobLoader{source: reader}
+}
+
+// DumpGob writes the store's flags to w in the binary form read by
+// NewGobReader. Flags are written as the store resolved them, so inheritance
+// is already applied and "@set:" references were replaced by the registered
+// sets when the flags were added; the loading store does not need the sets
+// registered
+func DumpGob(store *toggo.Store, w io.Writer) error {
+	snapshot := store.Snapshot()
+
+	var config Config
+	for _, name := range snapshot.Names() {
+		flag, _ := snapshot.Flag(name)
+		clone := flag.Clone()
+		// Inheritance is already resolved; resolving it again would repeat the parent's conditions
+		clone.Extends = ""
+		config.Flags = append(config.Flags, clone)
+	}
+
+	return gob.NewEncoder(w).Encode(&config)
+}
+
+// Load decodes and validates the flags
+func (l *GobLoader) Load() ([]*toggo.Flag, error) {
+	var reader io.Reader
+	var baseDir string
+
+	switch src := l.source.(type) {
+	case string:
+		file, err := os.Open(src)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+		baseDir = filepath.Dir(src)
+	case io.Reader:
+		reader = src
+	}
+
+	var config Config
+	if err := gob.NewDecoder(reader).Decode(&config); err != nil {
+		return nil, err
+	}
+
+	return config.prepare(baseDir)
+}
+
+// LoadIntoStore is a convenience method that loads flags directly into a store
+func (l *GobLoader) LoadIntoStore(store *toggo.Store) error {
+	flags, err := l.Load()
+	if err != nil {
+		return err
+	}
+	return store.AddFlags(flags)
+}
@@ -1,31 +1,48 @@
 package loader
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/pedrampdd/toggo"
 )
 
 // JSONLoader loads feature flags from JSON files or readers
 type JSONLoader struct {
-	source interface{} // can be string (file path) or io.Reader
+	source  interface{} // can be string (file path) or io.Reader
+	options options
+}
+
+// JSONOption configures a JSONLoader. It is the same type as Option, which
+// the YAML loader accepts too
+type JSONOption = Option
+
+// WithComments accepts "//" and "/* */" comments and trailing commas in JSON
+// configuration. Without it loading is strict JSON. Other loaders ignore it
+func WithComments() Option {
+	return func(o *options) {
+		o.comments = true
+	}
 }
 
 // NewJSONFile creates a loader that reads from a JSON file
-func NewJSONFile(filepath string) *JSONLoader {
-	return &JSONLoader{source: filepath}
+func NewJSONFile(filepath string, opts ...Option) *JSONLoader {
+	return &JSONLoader{source: filepath, options: newOptions(opts)}
 }
 
 // NewJSONReader creates a loader that reads from an io.Reader
-func NewJSONReader(reader io.Reader) *JSONLoader {
-	return &JSONLoader{source: reader}
+func NewJSONReader(reader io.Reader, opts ...Option) *JSONLoader {
+	return &JSONLoader{source: reader, options: newOptions(opts)}
 }
 
 // Load reads and parses the JSON configuration
 func (l *JSONLoader) Load() ([]*toggo.Flag, error) {
 	var reader io.Reader
+	var baseDir string
 
 	switch src := l.source.(type) {
 	case string:
@@ -35,24 +52,58 @@ func (l *JSONLoader) Load() ([]*toggo.Flag, error) {
 		}
 		defer file.Close()
 		reader = file
+		baseDir = filepath.Dir(src)
 	case io.Reader:
 		reader = src
 	}
 
-	var config Config
-	decoder := json.NewDecoder(reader)
-	if err := decoder.Decode(&config); err != nil {
+	if !l.options.comments && !l.options.defaultEnabled {
+		var config Config
+		if err := json.NewDecoder(reader).Decode(&config); err != nil {
+			return nil, err
+		}
+		return config.prepare(baseDir)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
 		return nil, err
 	}
+	if l.options.comments {
+		data = stripJSONC(data)
+	}
 
-	// Validate all flags
-	for _, flag := range config.Flags {
-		if err := flag.Validate(); err != nil {
+	var config Config
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&config); err != nil {
+		return nil, err
+	}
+	if l.options.defaultEnabled {
+		// Decode again keeping each flag's fields to tell an omitted "enabled" from false
+		var raw struct {
+			Flags []map[string]json.RawMessage `json:"flags"`
+		}
+		if err := json.NewDecoder(bytes.NewReader(data)).Decode(&raw); err != nil {
 			return nil, err
 		}
+		for i, fields := range raw.Flags {
+			if !hasField(fields, "enabled") && i < len(config.Flags) {
+				config.Flags[i].Enabled = true
+			}
+		}
 	}
 
-	return config.Flags, nil
+	return config.prepare(baseDir)
+}
+
+// hasField reports whether a JSON object has the field, matching keys
+// case-insensitively as encoding/json does when decoding into a struct
+func hasField(fields map[string]json.RawMessage, name string) bool {
+	for key := range fields {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
 }
 
 // LoadIntoStore is a convenience method that loads flags directly into a store
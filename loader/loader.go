@@ -1,16 +1,185 @@
 package loader
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/pedrampdd/toggo"
 )
 
+// ErrUnknownDefinition is returned when a flag's conditions_ref names a
+// condition set missing from the definitions section
+var ErrUnknownDefinition = errors.New("unknown condition definition")
+
+// FileRefPrefix marks an in/not_in condition value naming a file of values,
+// one per line, e.g. "@file:employees.txt". Blank lines and lines starting
+// with # are ignored. Relative paths are resolved against the directory of
+// the configuration file, or the working directory for readers
+const FileRefPrefix = "@file:"
+
 // Loader defines the interface for loading feature flags from various sources
 type Loader interface {
 	// Load reads flags from a source and returns them
 	Load() ([]*toggo.Flag, error)
 }
 
+// Option configures how a loader reads its configuration
+type Option func(*options)
+
+// options are the settings shared by the JSON and YAML loaders
+type options struct {
+	comments       bool
+	defaultEnabled bool
+}
+
+// newOptions applies opts to the zero settings
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithDefaultEnabled enables flags that omit the "enabled" field instead of
+// leaving them off. Explicit true and false values are kept
+func WithDefaultEnabled() Option {
+	return func(o *options) {
+		o.defaultEnabled = true
+	}
+}
+
 // Config represents the structure of a feature flags configuration file
 type Config struct {
+	// Definitions are named condition sets flags can reuse via conditions_ref
+	Definitions map[string][]toggo.Condition `json:"definitions,omitempty" yaml:"definitions,omitempty"`
+
 	Flags []*toggo.Flag `json:"flags" yaml:"flags"`
 }
+
+// prepare resolves condition and file references, then normalizes and
+// validates all flags. baseDir is used to resolve relative file references
+func (c *Config) prepare(baseDir string) ([]*toggo.Flag, error) {
+	for _, flag := range c.Flags {
+		if err := c.resolveConditionsRef(flag); err != nil {
+			return nil, err
+		}
+		if err := resolveFileRefs(flag, baseDir); err != nil {
+			return nil, err
+		}
+		normalizeTimeValues(flag)
+		flag.Normalize()
+		if err := flag.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.Flags, nil
+}
+
+// resolveConditionsRef prepends the referenced definition to the flag's conditions.
+// Each flag gets its own copy so normalizing one flag cannot affect another
+func (c *Config) resolveConditionsRef(flag *toggo.Flag) error {
+	if flag.ConditionsRef == "" {
+		return nil
+	}
+
+	shared, ok := c.Definitions[flag.ConditionsRef]
+	if !ok {
+		return fmt.Errorf("%w: %q referenced by %q", ErrUnknownDefinition, flag.ConditionsRef, flag.Name)
+	}
+
+	conditions := make([]toggo.Condition, 0, len(shared)+len(flag.Conditions))
+	for i := range shared {
+		conditions = append(conditions, shared[i].Clone())
+	}
+	conditions = append(conditions, flag.Conditions...)
+	flag.Conditions = conditions
+	flag.ConditionsRef = ""
+	return nil
+}
+
+// resolveFileRefs replaces file references in list conditions with a set
+// of the file's values
+func resolveFileRefs(flag *toggo.Flag, baseDir string) error {
+	var err error
+	flag.EachConditionList(func(conditions []toggo.Condition) {
+		if err == nil {
+			err = resolveConditionFiles(conditions, baseDir)
+		}
+	})
+	return err
+}
+
+func resolveConditionFiles(conditions []toggo.Condition, baseDir string) error {
+	for i := range conditions {
+		cond := &conditions[i]
+		ref, ok := cond.Value.(string)
+		if !ok || !cond.Operator.IsListOperator() || !strings.HasPrefix(ref, FileRefPrefix) {
+			continue
+		}
+
+		path := strings.TrimPrefix(ref, FileRefPrefix)
+		if !filepath.IsAbs(path) && baseDir != "" {
+			path = filepath.Join(baseDir, path)
+		}
+		values, err := readSetFile(path)
+		if err != nil {
+			return err
+		}
+		cond.Value = toggo.NewStringSet(values)
+	}
+	return nil
+}
+
+// normalizeTimeValues rewrites time.Time condition values, which YAML
+// produces for unquoted dates such as 2024-01-01, as RFC 3339 strings so a
+// flag reads the same whether it was loaded from YAML or JSON
+func normalizeTimeValues(flag *toggo.Flag) {
+	flag.EachConditionList(normalizeConditionTimes)
+}
+
+func normalizeConditionTimes(conditions []toggo.Condition) {
+	for i := range conditions {
+		conditions[i].Value = normalizeTime(conditions[i].Value)
+		conditions[i].Default = normalizeTime(conditions[i].Default)
+	}
+}
+
+// normalizeTime formats a time.Time, or the times in a list, as RFC 3339
+func normalizeTime(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeTime(v[i])
+		}
+	}
+	return value
+}
+
+// readSetFile reads one value per line, skipping blank lines and # comments
+func readSetFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var values []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values = append(values, line)
+	}
+	return values, scanner.Err()
+}
@@ -1,8 +1,18 @@
 package loader
 
 import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/pedrampdd/toggo"
 )
@@ -69,6 +79,47 @@ func TestJSONLoader_LoadFromReader(t *testing.T) {
 	}
 }
 
+func TestJSONLoader_WithComments(t *testing.T) {
+	clean := `{
+		"flags": [
+			{"name": "new_checkout", "enabled": true, "rollout": 50, "conditions": [{"attribute": "url", "operator": "==", "value": "http://example.com/*"}]},
+			{"name": "dark_mode", "enabled": false, "variants": [{"name": "on", "weight": 100}]}
+		]
+	}`
+	commented := `{
+		// Flags for the storefront
+		"flags": [
+			{
+				"name": "new_checkout", /* owned by payments */
+				"enabled": true,
+				"rollout": 50,
+				"conditions": [
+					// "//" and "/*" inside strings are not comments
+					{"attribute": "url", "operator": "==", "value": "http://example.com/*"},
+				],
+			},
+			{"name": "dark_mode", "enabled": false, "variants": [{"name": "on", "weight": 100},],},
+		],
+	}`
+
+	want, err := NewJSONReader(strings.NewReader(clean)).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := NewJSONReader(strings.NewReader(commented), WithComments()).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	// Strict loading still rejects comments
+	if _, err := NewJSONReader(strings.NewReader(commented)).Load(); err == nil {
+		t.Error("expected error for commented JSON without WithComments")
+	}
+}
+
 func TestYAMLLoader_LoadFromFile(t *testing.T) {
 	loader := NewYAMLFile("../testdata/flags.yaml")
 
@@ -179,3 +230,770 @@ func TestLoader_InvalidFlag(t *testing.T) {
 		t.Error("expected error for invalid flag")
 	}
 }
+
+func TestLoader_MixedTypeListValues(t *testing.T) {
+	yamlData := `
+flags:
+  - name: mixed_list
+    enabled: true
+    rollout: 100
+    conditions:
+      - attribute: tier
+        operator: in
+        value: [1, "2", true, 1000000]
+`
+	jsonData := `{
+		"flags": [
+			{
+				"name": "mixed_list",
+				"enabled": true,
+				"rollout": 100,
+				"conditions": [
+					{"attribute": "tier", "operator": "in", "value": [1, "2", true, 1000000]}
+				]
+			}
+		]
+	}`
+
+	loaders := map[string]Loader{
+		"yaml": NewYAMLReader(strings.NewReader(yamlData)),
+		"json": NewJSONReader(strings.NewReader(jsonData)),
+	}
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected bool
+	}{
+		{name: "int matches number", value: 1, expected: true},
+		{name: "float matches number", value: 1.0, expected: true},
+		{name: "string matches number", value: "1", expected: true},
+		{name: "int matches string", value: 2, expected: true},
+		{name: "bool matches bool", value: true, expected: true},
+		{name: "large float matches", value: 1000000.0, expected: true},
+		{name: "not in list", value: 3, expected: false},
+	}
+
+	for format, l := range loaders {
+		flags, err := l.Load()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+
+		store := toggo.NewStore()
+		if err := store.AddFlags(flags); err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				ctx := toggo.Context{"user_id": "u1", "tier": tt.value}
+				if result := store.IsEnabled("mixed_list", ctx); result != tt.expected {
+					t.Errorf("expected %v, got %v", tt.expected, result)
+				}
+			})
+		}
+	}
+}
+
+func TestLoader_ListOperatorRequiresList(t *testing.T) {
+	yamlData := `
+flags:
+  - name: scalar_in
+    enabled: true
+    conditions:
+      - attribute: country
+        operator: in
+        value: US
+`
+
+	_, err := NewYAMLReader(strings.NewReader(yamlData)).Load()
+	if !errors.Is(err, toggo.ErrInvalidCondition) {
+		t.Errorf("expected ErrInvalidCondition, got %v", err)
+	}
+}
+
+func TestLoader_ConditionsRef(t *testing.T) {
+	yamlData := `
+definitions:
+  us_premium:
+    - attribute: country
+      operator: ==
+      value: US
+    - attribute: plan
+      operator: ==
+      value: premium
+flags:
+  - name: new_dashboard
+    enabled: true
+    rollout: 100
+    conditions_ref: us_premium
+  - name: beta_reports
+    enabled: true
+    rollout: 100
+    conditions_ref: us_premium
+    conditions:
+      - attribute: beta
+        operator: ==
+        value: true
+`
+
+	store := toggo.NewStore()
+	if err := NewYAMLReader(strings.NewReader(yamlData)).LoadIntoStore(store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		flag     string
+		ctx      toggo.Context
+		expected bool
+	}{
+		{"dashboard matches shared set", "new_dashboard", toggo.Context{"user_id": "u1", "country": "US", "plan": "premium"}, true},
+		{"dashboard fails shared set", "new_dashboard", toggo.Context{"user_id": "u1", "country": "CA", "plan": "premium"}, false},
+		{"reports needs shared set and own condition", "beta_reports", toggo.Context{"user_id": "u1", "country": "US", "plan": "premium", "beta": true}, true},
+		{"reports fails own condition", "beta_reports", toggo.Context{"user_id": "u1", "country": "US", "plan": "premium", "beta": false}, false},
+		{"reports fails shared set", "beta_reports", toggo.Context{"user_id": "u1", "country": "US", "plan": "free", "beta": true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := store.IsEnabled(tt.flag, tt.ctx); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	dashboard, _ := store.GetFlag("new_dashboard")
+	if len(dashboard.Conditions) != 2 {
+		t.Errorf("expected 2 conditions, got %d", len(dashboard.Conditions))
+	}
+}
+
+func TestLoader_UnknownConditionsRef(t *testing.T) {
+	jsonData := `{
+		"flags": [
+			{"name": "orphan", "enabled": true, "conditions_ref": "missing"}
+		]
+	}`
+
+	_, err := NewJSONReader(strings.NewReader(jsonData)).Load()
+	if !errors.Is(err, ErrUnknownDefinition) {
+		t.Errorf("expected ErrUnknownDefinition, got %v", err)
+	}
+}
+
+func TestLoader_FileSetRef(t *testing.T) {
+	dir := t.TempDir()
+	ids := "# internal employees\nemp_1\n\nemp_2\n"
+	if err := os.WriteFile(filepath.Join(dir, "employees.txt"), []byte(ids), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := `{
+		"flags": [
+			{
+				"name": "internal_tools",
+				"enabled": true,
+				"rollout": 100,
+				"conditions": [
+					{"attribute": "user_id", "operator": "in", "value": "@file:employees.txt"}
+				]
+			}
+		]
+	}`
+	configPath := filepath.Join(dir, "flags.json")
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := toggo.NewStore()
+	if err := NewJSONFile(configPath).LoadIntoStore(store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		userID   string
+		expected bool
+	}{
+		{"emp_1", true},
+		{"emp_2", true},
+		{"# internal employees", false},
+		{"cust_1", false},
+	}
+
+	for _, tt := range tests {
+		if result := store.IsEnabled("internal_tools", toggo.Context{"user_id": tt.userID}); result != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.userID, tt.expected, result)
+		}
+	}
+}
+
+func TestLoader_SegmentOverrideRefs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "emps.txt"), []byte("emp_1\nemp_2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := `
+flags:
+  - name: checkout_test
+    enabled: true
+    default_variant: control
+    variants:
+      - name: control
+        weight: 50
+      - name: treatment
+        weight: 50
+      - name: staff
+        weight: 0
+      - name: early
+        weight: 0
+    segment_overrides:
+      - conditions:
+          - attribute: user_id
+            operator: in
+            value: "@file:emps.txt"
+        variant: staff
+      - conditions:
+          - attribute: signup_date
+            operator: "<"
+            value: 2024-01-01
+        variant: early
+`
+	configPath := filepath.Join(dir, "flags.yaml")
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := toggo.NewStore()
+	if err := NewYAMLFile(configPath).LoadIntoStore(store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	flag, _ := store.GetFlag("checkout_test")
+	if value, ok := flag.SegmentOverrides[1].Conditions[0].Value.(string); !ok || value != "2024-01-01T00:00:00Z" {
+		t.Errorf("expected RFC 3339 string, got %T %v", flag.SegmentOverrides[1].Conditions[0].Value, flag.SegmentOverrides[1].Conditions[0].Value)
+	}
+
+	tests := []struct {
+		ctx      toggo.Context
+		expected string
+	}{
+		{toggo.Context{"user_id": "emp_2"}, "staff"},
+		{toggo.Context{"user_id": "cust_1", "signup_date": "2023-06-01"}, "early"},
+	}
+	for _, tt := range tests {
+		if variant, _ := store.GetVariant("checkout_test", tt.ctx); variant != tt.expected {
+			t.Errorf("%v: expected %s, got %s", tt.ctx, tt.expected, variant)
+		}
+	}
+}
+
+func TestLoader_RolloutKeyRuleRefs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "b2b.txt"), []byte("acme\nglobex\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := `
+flags:
+  - name: mixed
+    enabled: true
+    rollout: 50
+    rollout_key_rules:
+      - conditions:
+          - attribute: company
+            operator: in
+            value: "@file:b2b.txt"
+          - attribute: contract_start
+            operator: ">="
+            value: 2024-01-01
+        key: account_id
+`
+	configPath := filepath.Join(dir, "flags.yaml")
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := toggo.NewStore()
+	if err := NewYAMLFile(configPath).LoadIntoStore(store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	flag, _ := store.GetFlag("mixed")
+	conditions := flag.RolloutKeyRules[0].Conditions
+	if _, ok := conditions[0].Value.(toggo.StringSet); !ok {
+		t.Errorf("expected the file reference to be resolved, got %T %v", conditions[0].Value, conditions[0].Value)
+	}
+	if value, ok := conditions[1].Value.(string); !ok || value != "2024-01-01T00:00:00Z" {
+		t.Errorf("expected RFC 3339 string, got %T %v", conditions[1].Value, conditions[1].Value)
+	}
+
+	// Users of one matching account share a decision
+	first := store.IsEnabled("mixed", toggo.Context{"company": "acme", "contract_start": "2024-03-01", "user_id": "u0", "account_id": "a1"})
+	for i := 1; i < 20; i++ {
+		ctx := toggo.Context{"company": "acme", "contract_start": "2024-03-01", "user_id": fmt.Sprintf("u%d", i), "account_id": "a1"}
+		if store.IsEnabled("mixed", ctx) != first {
+			t.Fatalf("expected every user of account a1 to get %v", first)
+		}
+	}
+}
+
+// countingLoader is a Loader that records how many times it was called
+type countingLoader struct {
+	mu    sync.Mutex
+	loads int
+}
+
+func (l *countingLoader) Load() ([]*toggo.Flag, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loads++
+	return []*toggo.Flag{{Name: "polled", Enabled: true, Rollout: 100}}, nil
+}
+
+func (l *countingLoader) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.loads
+}
+
+func TestPoller_CloseStopsGoroutine(t *testing.T) {
+	source := &countingLoader{}
+	store := toggo.NewStore()
+
+	poller := NewPoller(source, store, time.Millisecond)
+	poller.Start()
+
+	deadline := time.Now().Add(time.Second)
+	for source.count() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("poller never reloaded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := poller.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-poller.done:
+	default:
+		t.Fatal("expected polling goroutine to have exited")
+	}
+
+	loads := source.count()
+	time.Sleep(10 * time.Millisecond)
+	if source.count() != loads {
+		t.Errorf("expected no reloads after Close, got %d more", source.count()-loads)
+	}
+
+	if !store.IsEnabled("polled", toggo.Context{"user_id": "1"}) {
+		t.Error("expected polled flag to be loaded into store")
+	}
+
+	// Closing twice is safe
+	if err := poller.Close(); err != nil {
+		t.Fatalf("unexpected error on second Close: %v", err)
+	}
+}
+
+func TestPoller_NonPositiveInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		poller := NewPoller(&countingLoader{}, toggo.NewStore(), interval)
+		if poller.interval != DefaultPollInterval {
+			t.Errorf("%v: expected %v, got %v", interval, DefaultPollInterval, poller.interval)
+		}
+
+		// Starting must not panic in the polling goroutine
+		poller.Start()
+		if err := poller.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestYAMLLoader_Environments(t *testing.T) {
+	config := `
+flags:
+  - name: new_checkout
+    enabled: true
+    rollout: 5
+    conditions:
+      - attribute: country
+        operator: "=="
+        value: US
+    environments:
+      prod:
+        rollout: 100
+      staging:
+        rollout: 10
+        conditions: []
+      dev:
+        enabled: false
+  - name: plain
+    enabled: true
+    rollout: 50
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flags.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		env        string
+		enabled    bool
+		rollout    int
+		conditions int
+	}{
+		{"prod", true, 100, 1},
+		{"staging", true, 10, 0},
+		{"dev", false, 5, 1},
+		{"unknown", true, 5, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			flags, err := NewYAMLFileForEnv(path, tt.env).Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(flags) != 2 {
+				t.Fatalf("expected 2 flags, got %d", len(flags))
+			}
+			flag := flags[0]
+			if flag.Enabled != tt.enabled {
+				t.Errorf("expected enabled %v, got %v", tt.enabled, flag.Enabled)
+			}
+			if flag.Rollout != tt.rollout {
+				t.Errorf("expected rollout %d, got %d", tt.rollout, flag.Rollout)
+			}
+			if len(flag.Conditions) != tt.conditions {
+				t.Errorf("expected %d conditions, got %d", tt.conditions, len(flag.Conditions))
+			}
+			if flags[1].Rollout != 50 {
+				t.Errorf("expected 50, got %d", flags[1].Rollout)
+			}
+		})
+	}
+}
+
+func TestYAMLLoader_EnvironmentsIgnoredWithoutEnv(t *testing.T) {
+	config := `
+flags:
+  - name: new_checkout
+    enabled: true
+    rollout: 5
+    environments:
+      prod:
+        rollout: 100
+`
+	flags, err := NewYAMLReader(strings.NewReader(config)).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flags[0].Rollout != 5 {
+		t.Errorf("expected 5, got %d", flags[0].Rollout)
+	}
+}
+
+func TestLoader_DefaultEnabled(t *testing.T) {
+	jsonConfig := `{"flags": [
+		{"name": "on", "enabled": true},
+		{"name": "off", "enabled": false},
+		{"name": "omitted"},
+		{"name": "capitalized", "Enabled": false}
+	]}`
+	yamlConfig := `
+flags:
+  - name: on
+    enabled: true
+  - name: off
+    enabled: false
+  - name: omitted
+`
+	tests := []struct {
+		name   string
+		loader Loader
+		want   []bool
+	}{
+		{"json default", NewJSONReader(strings.NewReader(jsonConfig)), []bool{true, false, false, false}},
+		{"json default enabled", NewJSONReader(strings.NewReader(jsonConfig), []JSONOption{WithDefaultEnabled()}...), []bool{true, false, true, false}},
+		{"yaml default", NewYAMLReader(strings.NewReader(yamlConfig)), []bool{true, false, false}},
+		{"yaml default enabled", NewYAMLReader(strings.NewReader(yamlConfig), WithDefaultEnabled()), []bool{true, false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, err := tt.loader.Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i, flag := range flags {
+				if flag.Enabled != tt.want[i] {
+					t.Errorf("%s: expected %v, got %v", flag.Name, tt.want[i], flag.Enabled)
+				}
+			}
+		})
+	}
+}
+
+func TestGobLoader_RoundTrip(t *testing.T) {
+	original := toggo.NewStore()
+	if err := NewJSONFile("../testdata/flags.json").LoadIntoStore(original); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	original.AddFlags([]*toggo.Flag{
+		{
+			Name:    "checkout_payload",
+			Enabled: true,
+			Variants: []toggo.Variant{
+				{Name: "on", Weight: 100, Payload: map[string]interface{}{"color": "blue", "sizes": []interface{}{1.0, 2.0}}},
+			},
+		},
+		{Name: "internal", Enabled: true, Rollout: 100, Conditions: []toggo.Condition{
+			{Attribute: "user_id", Operator: toggo.OperatorIn, Value: toggo.NewStringSet([]string{"emp_1", "emp_2"})},
+		}},
+		{Name: "internal_beta", Enabled: true, Extends: "internal", Conditions: []toggo.Condition{
+			{Attribute: "beta_tester", Operator: toggo.OperatorEqual, Value: true},
+		}},
+	})
+	// The restoring store never registers this set
+	original.RegisterSet("markets", []string{"US", "CA"})
+	original.AddFlag(&toggo.Flag{Name: "markets_launch", Enabled: true, Rollout: 100, Conditions: []toggo.Condition{
+		{Attribute: "country", Operator: toggo.OperatorIn, Value: toggo.SetRefPrefix + "markets"},
+	}})
+
+	var buf bytes.Buffer
+	if err := DumpGob(original, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored := toggo.NewStore()
+	if err := NewGobReader(&buf).LoadIntoStore(restored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if restored.Size() != original.Size() {
+		t.Fatalf("expected %d flags, got %d", original.Size(), restored.Size())
+	}
+	for _, name := range original.Snapshot().Names() {
+		originalFlag, _ := original.GetFlag(name)
+		restoredFlag, err := restored.GetFlag(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resolved := originalFlag.Clone()
+		// Inheritance was resolved before dumping
+		resolved.Extends = ""
+		want, _ := json.Marshal(resolved)
+		got, _ := json.Marshal(restoredFlag)
+		if !bytes.Equal(want, got) {
+			t.Errorf("%s: expected %s, got %s", name, want, got)
+		}
+	}
+
+	contexts := []toggo.Context{
+		{"user_id": "emp_1", "beta_tester": true, "country": "US", "plan": "premium"},
+		{"user_id": "emp_3", "beta_tester": true, "country": "CA", "plan": "premium"},
+		{"user_id": "user_42", "country": "DE"},
+	}
+	for _, ctx := range contexts {
+		for _, name := range original.Snapshot().Names() {
+			want, _ := original.Evaluate(name, ctx)
+			got, _ := restored.Evaluate(name, ctx)
+			if want != got {
+				t.Errorf("%s %v: expected %+v, got %+v", name, ctx, want, got)
+			}
+		}
+	}
+}
+
+func TestGobLoader_Invalid(t *testing.T) {
+	if _, err := NewGobReader(strings.NewReader("not gob")).Load(); err == nil {
+		t.Error("expected error decoding invalid input")
+	}
+
+	var buf bytes.Buffer
+	gob.NewEncoder(&buf).Encode(&Config{Flags: []*toggo.Flag{{Name: "bad", Rollout: 150}}})
+	if _, err := NewGobReader(&buf).Load(); !errors.Is(err, toggo.ErrInvalidRollout) {
+		t.Errorf("expected ErrInvalidRollout, got %v", err)
+	}
+}
+
+func BenchmarkLoad_JSON(b *testing.B) {
+	data, _ := benchmarkConfigs(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewJSONReader(bytes.NewReader(data)).Load(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoad_Gob(b *testing.B) {
+	_, data := benchmarkConfigs(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewGobReader(bytes.NewReader(data)).Load(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkConfigs returns the same 2000 flags encoded as JSON and as gob
+func benchmarkConfigs(b *testing.B) ([]byte, []byte) {
+	store := toggo.NewStore()
+	config := Config{}
+	for i := 0; i < 2000; i++ {
+		config.Flags = append(config.Flags, &toggo.Flag{
+			Name:    fmt.Sprintf("flag_%d", i),
+			Enabled: true,
+			Rollout: i % 100,
+			Conditions: []toggo.Condition{
+				{Attribute: "country", Operator: toggo.OperatorIn, Value: []interface{}{"US", "CA", "DE"}},
+				{Attribute: "plan", Operator: toggo.OperatorEqual, Value: "premium"},
+			},
+			Variants: []toggo.Variant{{Name: "control", Weight: 50}, {Name: "treatment", Weight: 50}},
+		})
+	}
+	if err := store.AddFlags(config.Flags); err != nil {
+		b.Fatal(err)
+	}
+
+	jsonData, err := json.Marshal(&config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var gobData bytes.Buffer
+	if err := DumpGob(store, &gobData); err != nil {
+		b.Fatal(err)
+	}
+	return jsonData, gobData.Bytes()
+}
+
+func TestLoader_DateValues(t *testing.T) {
+	yamlConfig := `
+flags:
+  - name: new_pricing
+    enabled: true
+    rollout: 100
+    conditions:
+      - attribute: signup_date
+        operator: ">="
+        value: 2024-01-01
+      - attribute: signup_date
+        operator: "<"
+        value: 2024-07-01T00:00:00Z
+`
+	jsonConfig := `{"flags": [{
+		"name": "new_pricing",
+		"enabled": true,
+		"rollout": 100,
+		"conditions": [
+			{"attribute": "signup_date", "operator": ">=", "value": "2024-01-01"},
+			{"attribute": "signup_date", "operator": "<", "value": "2024-07-01T00:00:00Z"}
+		]
+	}]}`
+
+	fromYAML := toggo.NewStore()
+	if err := NewYAMLReader(strings.NewReader(yamlConfig)).LoadIntoStore(fromYAML); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fromJSON := toggo.NewStore()
+	if err := NewJSONReader(strings.NewReader(jsonConfig)).LoadIntoStore(fromJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	flag, _ := fromYAML.GetFlag("new_pricing")
+	if value, ok := flag.Conditions[0].Value.(string); !ok || value != "2024-01-01T00:00:00Z" {
+		t.Errorf("expected RFC 3339 string, got %T %v", flag.Conditions[0].Value, flag.Conditions[0].Value)
+	}
+
+	tests := []struct {
+		signup   interface{}
+		expected bool
+	}{
+		{"2023-12-31", false},
+		{"2024-01-01", true},
+		{"2024-03-15T12:00:00Z", true},
+		{time.Date(2024, 6, 30, 23, 59, 0, 0, time.UTC), true},
+		{"2024-07-01", false},
+	}
+
+	for _, tt := range tests {
+		ctx := toggo.Context{"user_id": "u1", "signup_date": tt.signup}
+		yamlResult := fromYAML.IsEnabled("new_pricing", ctx)
+		jsonResult := fromJSON.IsEnabled("new_pricing", ctx)
+		if yamlResult != tt.expected || jsonResult != tt.expected {
+			t.Errorf("%v: expected %v, got yaml %v json %v", tt.signup, tt.expected, yamlResult, jsonResult)
+		}
+	}
+}
+
+func TestLoader_TTL(t *testing.T) {
+	yamlConfig := `
+flags:
+  - name: holiday_banner
+    enabled: true
+    rollout: 100
+    ttl: 168h
+    created_at: 2025-12-01T00:00:00Z
+`
+	jsonConfig := `{"flags": [{
+		"name": "holiday_banner",
+		"enabled": true,
+		"rollout": 100,
+		"ttl": "168h",
+		"created_at": "2025-12-01T00:00:00Z"
+	}]}`
+
+	for name, loader := range map[string]Loader{
+		"yaml": NewYAMLReader(strings.NewReader(yamlConfig)),
+		"json": NewJSONReader(strings.NewReader(jsonConfig)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			flags, err := loader.Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if flags[0].TTL != toggo.Duration(168*time.Hour) {
+				t.Errorf("expected %v, got %v", 168*time.Hour, time.Duration(flags[0].TTL))
+			}
+			if err := flags[0].Validate(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	missing := `{"flags": [{"name": "holiday_banner", "enabled": true, "ttl": "168h"}]}`
+	err := NewJSONReader(strings.NewReader(missing)).LoadIntoStore(toggo.NewStore())
+	if !errors.Is(err, toggo.ErrInvalidTTL) {
+		t.Errorf("expected ErrInvalidTTL, got %v", err)
+	}
+}
+
+func TestLoader_RampJitter(t *testing.T) {
+	yamlConfig := `
+flags:
+  - name: new_checkout
+    enabled: true
+    ramp_jitter: 10m
+`
+	jsonConfig := `{"flags": [{"name": "new_checkout", "enabled": true, "ramp_jitter": "10m"}]}`
+
+	for name, loader := range map[string]Loader{
+		"yaml": NewYAMLReader(strings.NewReader(yamlConfig)),
+		"json": NewJSONReader(strings.NewReader(jsonConfig)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			flags, err := loader.Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if flags[0].RampJitter != toggo.Duration(10*time.Minute) {
+				t.Errorf("expected %v, got %v", 10*time.Minute, time.Duration(flags[0].RampJitter))
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package loader
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/toggo"
+)
+
+// Poller periodically reloads flags from a Loader into a store.
+// Flags returned by the loader are added or updated; flags missing from the
+// source are left in place
+type Poller struct {
+	loader   Loader
+	store    *toggo.Store
+	interval time.Duration
+	onError  func(error)
+
+	stop      chan struct{}
+	done      chan struct{}
+	startOnce sync.Once
+	closeOnce sync.Once
+}
+
+// DefaultPollInterval is used by NewPoller when the given interval is not positive
+const DefaultPollInterval = 30 * time.Second
+
+// PollerOption configures a Poller
+type PollerOption func(*Poller)
+
+// WithPollErrorHandler sets a callback invoked when a reload fails
+func WithPollErrorHandler(fn func(error)) PollerOption {
+	return func(p *Poller) {
+		p.onError = fn
+	}
+}
+
+// NewPoller creates a poller that reloads flags from loader into store every
+// interval. A zero or negative interval falls back to DefaultPollInterval
+func NewPoller(loader Loader, store *toggo.Store, interval time.Duration, opts ...PollerOption) *Poller {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	p := &Poller{
+		loader:   loader,
+		store:    store,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Start launches the background polling goroutine
+func (p *Poller) Start() {
+	p.startOnce.Do(func() {
+		go p.run()
+	})
+}
+
+// Close stops the polling goroutine and waits for it to exit.
+// It implements io.Closer and is safe to call more than once
+func (p *Poller) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+		// Make sure done is closed even if Start was never called
+		p.startOnce.Do(func() {
+			close(p.done)
+		})
+	})
+	<-p.done
+	return nil
+}
+
+// run reloads flags on every tick until Close is called
+func (p *Poller) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.reload()
+		}
+	}
+}
+
+// reload loads flags once and adds them to the store
+func (p *Poller) reload() {
+	flags, err := p.loader.Load()
+	if err == nil {
+		err = p.store.AddFlags(flags)
+	}
+	if err != nil && p.onError != nil {
+		p.onError(err)
+	}
+}
@@ -3,6 +3,7 @@ package loader
 import (
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/pedrampdd/toggo"
 	"gopkg.in/yaml.v3"
@@ -10,22 +11,45 @@ import (
 
 // YAMLLoader loads feature flags from YAML files or readers
 type YAMLLoader struct {
-	source interface{} // can be string (file path) or io.Reader
+	source  interface{} // can be string (file path) or io.Reader
+	env     string
+	options options
 }
 
 // NewYAMLFile creates a loader that reads from a YAML file
-func NewYAMLFile(filepath string) *YAMLLoader {
-	return &YAMLLoader{source: filepath}
+func NewYAMLFile(filepath string, opts ...Option) *YAMLLoader {
+	return &YAMLLoader{source: filepath, options: newOptions(opts)}
 }
 
 // NewYAMLReader creates a loader that reads from an io.Reader
-func NewYAMLReader(reader io.Reader) *YAMLLoader {
-	return &YAMLLoader{source: reader}
+func NewYAMLReader(reader io.Reader, opts ...Option) *YAMLLoader {
+	return &YAMLLoader{source: reader, options: newOptions(opts)}
+}
+
+// NewYAMLFileForEnv creates a loader that reads from a YAML file and applies
+// each flag's section for env from its environments map, e.g.
+//
+//	environments:
+//	  prod:
+//	    rollout: 100
+//	  staging:
+//	    rollout: 10
+//
+// Fields set in the section replace the base flag's; flags without a section
+// for env are loaded unchanged
+func NewYAMLFileForEnv(filepath, env string, opts ...Option) *YAMLLoader {
+	return &YAMLLoader{source: filepath, env: env, options: newOptions(opts)}
+}
+
+// NewYAMLReaderForEnv is NewYAMLFileForEnv for an io.Reader
+func NewYAMLReaderForEnv(reader io.Reader, env string, opts ...Option) *YAMLLoader {
+	return &YAMLLoader{source: reader, env: env, options: newOptions(opts)}
 }
 
 // Load reads and parses the YAML configuration
 func (l *YAMLLoader) Load() ([]*toggo.Flag, error) {
 	var reader io.Reader
+	var baseDir string
 
 	switch src := l.source.(type) {
 	case string:
@@ -35,24 +59,83 @@ func (l *YAMLLoader) Load() ([]*toggo.Flag, error) {
 		}
 		defer file.Close()
 		reader = file
+		baseDir = filepath.Dir(src)
 	case io.Reader:
 		reader = src
 	}
 
 	var config Config
 	decoder := yaml.NewDecoder(reader)
-	if err := decoder.Decode(&config); err != nil {
+	if l.env == "" && !l.options.defaultEnabled {
+		if err := decoder.Decode(&config); err != nil {
+			return nil, err
+		}
+		return config.prepare(baseDir)
+	}
+
+	var raw nodeConfig
+	if err := decoder.Decode(&raw); err != nil {
 		return nil, err
 	}
+	config.Definitions = raw.Definitions
+	for i := range raw.Flags {
+		flag, err := decodeFlagNode(&raw.Flags[i], l.env, l.options.defaultEnabled)
+		if err != nil {
+			return nil, err
+		}
+		config.Flags = append(config.Flags, flag)
+	}
 
-	// Validate all flags
-	for _, flag := range config.Flags {
-		if err := flag.Validate(); err != nil {
+	return config.prepare(baseDir)
+}
+
+// nodeConfig is Config with flags kept as nodes so environment sections can
+// be decoded over them and omitted fields detected
+type nodeConfig struct {
+	Definitions map[string][]toggo.Condition `yaml:"definitions,omitempty"`
+	Flags       []yaml.Node                  `yaml:"flags"`
+}
+
+// decodeFlagNode decodes a flag, enabling it if it omits "enabled" and
+// defaultEnabled is set, then decodes its section for env, if any, over it
+func decodeFlagNode(node *yaml.Node, env string, defaultEnabled bool) (*toggo.Flag, error) {
+	var flag toggo.Flag
+	if err := node.Decode(&flag); err != nil {
+		return nil, err
+	}
+	if defaultEnabled && !hasKey(node, "enabled") {
+		flag.Enabled = true
+	}
+	if env == "" {
+		return &flag, nil
+	}
+
+	var sections struct {
+		Environments map[string]yaml.Node `yaml:"environments"`
+	}
+	if err := node.Decode(&sections); err != nil {
+		return nil, err
+	}
+	if section, ok := sections.Environments[env]; ok {
+		if err := section.Decode(&flag); err != nil {
 			return nil, err
 		}
 	}
 
-	return config.Flags, nil
+	return &flag, nil
+}
+
+// hasKey reports whether a mapping node has the key
+func hasKey(node *yaml.Node, key string) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
 }
 
 // LoadIntoStore is a convenience method that loads flags directly into a store
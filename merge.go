@@ -0,0 +1,64 @@
+package toggo
+
+import "fmt"
+
+// MergePolicy decides what Merge does when both stores define a flag
+type MergePolicy int
+
+const (
+	// MergeOverride replaces the existing flag with the other store's
+	MergeOverride MergePolicy = iota
+
+	// MergeSkip keeps the existing flag
+	MergeSkip
+
+	// MergeError aborts the merge with ErrFlagConflict without changing the store
+	MergeError
+)
+
+// Merge copies the flags of other into the store, resolving name collisions
+// with policy, e.g. to layer an overrides store over a defaults store.
+// Flags are copied as other resolved them (inheritance and sets already
+// applied) and no longer follow their parent; runtime overrides and
+// registered sets are not copied
+func (s *Store) Merge(other *Store, policy MergePolicy) error {
+	if other == s {
+		return nil
+	}
+	incoming := other.Snapshot()
+
+	var changes []FlagChange
+	defer func() { s.publish(changes) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	if policy == MergeError {
+		for _, name := range incoming.Names() {
+			if _, exists := s.flags[name]; exists {
+				return fmt.Errorf("%w: %s", ErrFlagConflict, name)
+			}
+		}
+	}
+
+	for _, name := range incoming.Names() {
+		if _, exists := s.flags[name]; exists && policy == MergeSkip {
+			continue
+		}
+		flag, _ := incoming.Flag(name)
+		merged := s.applyDefaults(flag)
+		changes = appendChange(changes, name, s.flags[name], merged)
+		s.flags[name] = merged
+		// The resolved flag already carries its parent's conditions, so it
+		// is kept as a standalone config that PatchFlag does not re-resolve
+		config := *flag
+		config.Extends = ""
+		s.configs[name] = &config
+		s.invalidateCache(name)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package toggo
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics receives evaluation measurements. Adapt it to a metrics library,
+// e.g. a Prometheus HistogramVec labeled by flag:
+//
+//	func (m promMetrics) ObserveEvaluation(flag string, d time.Duration) {
+//		m.latency.WithLabelValues(flag).Observe(d.Seconds())
+//	}
+type Metrics interface {
+	// ObserveEvaluation records how long evaluating flag took
+	ObserveEvaluation(flag string, duration time.Duration)
+}
+
+// WithMetrics reports the duration of every flag decision (conditions,
+// rollout and variant selection) to metrics. Without it nothing is timed
+func WithMetrics(metrics Metrics) StoreOption {
+	return func(s *Store) {
+		s.metrics = metrics
+	}
+}
+
+// DefaultLatencyBuckets are the histogram upper bounds used when
+// NewLatencyHistogram is given none
+var DefaultLatencyBuckets = []time.Duration{
+	time.Microsecond,
+	5 * time.Microsecond,
+	10 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+}
+
+// LatencyHistogram is an in-memory Metrics implementation keeping a
+// Prometheus-style latency histogram per flag
+type LatencyHistogram struct {
+	buckets []time.Duration
+
+	mu     sync.Mutex
+	series map[string]*HistogramSnapshot
+}
+
+// HistogramSnapshot is a copy of one flag's latency distribution.
+// Counts are cumulative like Prometheus buckets: Counts[i] is the number of
+// observations at or below Buckets[i]; observations above the last bucket
+// are only included in Count
+type HistogramSnapshot struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+}
+
+// NewLatencyHistogram creates a histogram with the given bucket upper bounds,
+// or DefaultLatencyBuckets when none are given
+func NewLatencyHistogram(buckets ...time.Duration) *LatencyHistogram {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	sorted := append([]time.Duration(nil), buckets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &LatencyHistogram{
+		buckets: sorted,
+		series:  make(map[string]*HistogramSnapshot),
+	}
+}
+
+// ObserveEvaluation implements Metrics
+func (h *LatencyHistogram) ObserveEvaluation(flag string, duration time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	series, ok := h.series[flag]
+	if !ok {
+		series = &HistogramSnapshot{Buckets: h.buckets, Counts: make([]uint64, len(h.buckets))}
+		h.series[flag] = series
+	}
+	for i, upper := range h.buckets {
+		if duration <= upper {
+			series.Counts[i]++
+		}
+	}
+	series.Count++
+	series.Sum += duration
+}
+
+// Snapshot returns a copy of the flag's histogram and whether it has any observations
+func (h *LatencyHistogram) Snapshot(flag string) (HistogramSnapshot, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	series, ok := h.series[flag]
+	if !ok {
+		return HistogramSnapshot{}, false
+	}
+	snapshot := *series
+	snapshot.Counts = append([]uint64(nil), series.Counts...)
+	return snapshot, true
+}
+
+// Flags returns the names of flags with observations, sorted
+func (h *LatencyHistogram) Flags() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	names := make([]string, 0, len(h.series))
+	for name := range h.series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
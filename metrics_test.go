@@ -0,0 +1,92 @@
+package toggo
+
+import (
+	"testing"
+	"time"
+)
+
+// recordingMetrics records every observed evaluation
+type recordingMetrics struct {
+	flags []string
+}
+
+func (m *recordingMetrics) ObserveEvaluation(flag string, duration time.Duration) {
+	m.flags = append(m.flags, flag)
+}
+
+func TestStore_WithMetrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	store := NewStore(WithMetrics(metrics))
+	store.AddFlag(&Flag{Name: "feature", Enabled: true, Rollout: 100})
+	store.AddFlag(&Flag{
+		Name:           "experiment",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Variants: []Variant{
+			{Name: "control", Weight: 50},
+			{Name: "treatment", Weight: 50},
+		},
+	})
+
+	ctx := Context{"user_id": "user-1"}
+	store.IsEnabled("feature", ctx)
+	store.IsEnabled("feature", ctx)
+	store.GetVariant("experiment", ctx)
+	store.IsEnabled("missing", ctx)
+
+	expected := []string{"feature", "feature", "experiment"}
+	if len(metrics.flags) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, metrics.flags)
+	}
+	for i := range expected {
+		if metrics.flags[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, metrics.flags)
+		}
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	histogram := NewLatencyHistogram(time.Millisecond, 10*time.Microsecond)
+	histogram.ObserveEvaluation("feature", 5*time.Microsecond)
+	histogram.ObserveEvaluation("feature", 500*time.Microsecond)
+	histogram.ObserveEvaluation("feature", 2*time.Millisecond)
+
+	snapshot, ok := histogram.Snapshot("feature")
+	if !ok {
+		t.Fatal("expected observations for feature")
+	}
+	if snapshot.Count != 3 {
+		t.Errorf("expected %v, got %v", 3, snapshot.Count)
+	}
+	if snapshot.Sum != 2505*time.Microsecond {
+		t.Errorf("expected %v, got %v", 2505*time.Microsecond, snapshot.Sum)
+	}
+	if snapshot.Buckets[0] != 10*time.Microsecond {
+		t.Errorf("expected sorted buckets, got %v", snapshot.Buckets)
+	}
+	if snapshot.Counts[0] != 1 || snapshot.Counts[1] != 2 {
+		t.Errorf("expected cumulative counts [1 2], got %v", snapshot.Counts)
+	}
+
+	if _, ok := histogram.Snapshot("other"); ok {
+		t.Error("expected no observations for other")
+	}
+	if flags := histogram.Flags(); len(flags) != 1 || flags[0] != "feature" {
+		t.Errorf("expected [feature], got %v", flags)
+	}
+}
+
+func TestStore_WithMetrics_Histogram(t *testing.T) {
+	histogram := NewLatencyHistogram()
+	store := NewStore(WithMetrics(histogram))
+	store.AddFlag(&Flag{Name: "feature", Enabled: true, Rollout: 50})
+
+	for i := 0; i < 10; i++ {
+		store.IsEnabled("feature", Context{"user_id": i})
+	}
+
+	snapshot, ok := histogram.Snapshot("feature")
+	if !ok || snapshot.Count != 10 {
+		t.Errorf("expected %v, got %v", 10, snapshot.Count)
+	}
+}
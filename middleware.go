@@ -0,0 +1,32 @@
+package toggo
+
+// FlagEvaluator is the evaluation surface of a Store. Wrap it with decorators
+// to add caching, logging or metrics without modifying toggo:
+//
+//	type loggingEvaluator struct{ next toggo.FlagEvaluator }
+//
+//	func (l loggingEvaluator) Enabled(name string, ctx toggo.Context) (bool, error) {
+//		enabled, err := l.next.Enabled(name, ctx)
+//		log.Printf("%s => %v", name, enabled)
+//		return enabled, err
+//	}
+type FlagEvaluator interface {
+	// Enabled reports whether a simple flag is enabled for the context
+	Enabled(name string, ctx Context) (bool, error)
+
+	// Variant returns the variant assigned to the context and whether the flag is enabled
+	Variant(name string, ctx Context) (string, bool, error)
+}
+
+// Ensure Store implements FlagEvaluator
+var _ FlagEvaluator = (*Store)(nil)
+
+// Enabled implements FlagEvaluator; it is equivalent to IsEnabledWithError
+func (s *Store) Enabled(name string, ctx Context) (bool, error) {
+	return s.IsEnabledWithError(name, ctx)
+}
+
+// Variant implements FlagEvaluator; it is equivalent to GetVariantWithError
+func (s *Store) Variant(name string, ctx Context) (string, bool, error) {
+	return s.GetVariantWithError(name, ctx)
+}
@@ -1,5 +1,10 @@
 package toggo
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Operator represents a comparison operator for condition evaluation
 type Operator string
 
@@ -39,6 +44,79 @@ const (
 
 	// OperatorRegex checks if attribute matches regex pattern
 	OperatorRegex Operator = "regex"
+
+	// OperatorNotMatchesRegex checks if attribute exists and does not match a regex pattern.
+	// Unlike Negate on OperatorRegex, a missing attribute does not match
+	OperatorNotMatchesRegex Operator = "not_regex"
+
+	// OperatorDivisibleBy checks if numeric attribute is a multiple of an integer value
+	OperatorDivisibleBy Operator = "divisible_by"
+
+	// OperatorWithinRadius checks if a location attribute is within radius_km of a point
+	// The attribute holds {"lat": ..., "lng": ...} or [lat, lng]; the value holds
+	// {"lat": ..., "lng": ..., "radius_km": ...}
+	OperatorWithinRadius Operator = "within_radius"
+
+	// OperatorBucketIn hashes the attribute into a 0-99 bucket and checks if it is
+	// in a list of buckets and "from-to" ranges, e.g. [0, 1, "10-19"]
+	OperatorBucketIn Operator = "bucket_in"
+
+	// OperatorSemverSatisfies checks if a version attribute satisfies an npm-style
+	// range such as "^2.3.0", "~1.4.0", ">=1.0.0 <2.0.0" or "1.x"
+	OperatorSemverSatisfies Operator = "semver_satisfies"
+
+	// OperatorCountGreaterThan checks if a list attribute has more than value elements
+	OperatorCountGreaterThan Operator = "count_gt"
+
+	// OperatorCountLessThan checks if a list attribute has fewer than value elements
+	OperatorCountLessThan Operator = "count_lt"
+
+	// OperatorTimeOfDayBetween checks if a timestamp's time of day falls within
+	// ["HH:MM", "HH:MM"), wrapping past midnight when the start is after the end
+	OperatorTimeOfDayBetween Operator = "time_of_day_between"
+
+	// OperatorDayOfWeekIn checks if a timestamp falls on one of the listed days, e.g. ["sat", "sun"]
+	OperatorDayOfWeekIn Operator = "day_of_week_in"
+
+	// OperatorOlderThan checks if a timestamp attribute is more than a duration
+	// such as "168h" or "7d" before the store clock
+	OperatorOlderThan Operator = "older_than"
+
+	// OperatorNewerThan checks if a timestamp attribute is less than a duration
+	// such as "30m" or "2d" before the store clock
+	OperatorNewerThan Operator = "newer_than"
+
+	// OperatorSamplePercent deterministically matches value percent (0-100) of
+	// distinct attribute values, e.g. a stable 1% sample of error codes.
+	// It is independent of the flag's rollout key and of bucket_in
+	OperatorSamplePercent Operator = "sample_percent"
+
+	// OperatorIsType checks if an attribute is of a type and non-empty. The
+	// value names "string", "number", "bool" or "list", or lists several of them
+	OperatorIsType Operator = "is_type"
+
+	// OperatorPercentileAbove checks if an attribute holding a precomputed
+	// percentile rank (0-100, higher ranks higher) is above value, e.g.
+	// spend_percentile percentile_above 90. Ranks outside 0-100 never match
+	OperatorPercentileAbove Operator = "percentile_above"
+
+	// OperatorTopPercent checks if a percentile rank attribute falls in the top
+	// value percent, i.e. rank >= 100 - value, so a user at the 95th percentile
+	// is in the top 10%. "top_percent 0" matches nobody
+	OperatorTopPercent Operator = "top_percent"
+
+	// OperatorIsEmpty checks if a string, list or map attribute has no
+	// elements. A missing attribute is treated as empty; other types never match
+	OperatorIsEmpty Operator = "is_empty"
+
+	// OperatorIsNotEmpty checks if a string, list or map attribute has at
+	// least one element. A missing attribute is treated as empty
+	OperatorIsNotEmpty Operator = "is_not_empty"
+
+	// OperatorLengthBetween checks if the length of a string, list or map
+	// attribute is within an inclusive [min, max] pair, e.g. [1, 5]. A missing
+	// attribute has length 0
+	OperatorLengthBetween Operator = "length_between"
 )
 
 // IsValid checks if the operator is supported
@@ -48,8 +126,99 @@ func (o Operator) IsValid() bool {
 		OperatorGreaterThan, OperatorGreaterThanOrEqual,
 		OperatorLessThan, OperatorLessThanOrEqual,
 		OperatorContains, OperatorStartsWith, OperatorEndsWith,
-		OperatorRegex:
+		OperatorRegex, OperatorNotMatchesRegex, OperatorDivisibleBy, OperatorWithinRadius,
+		OperatorBucketIn, OperatorSemverSatisfies,
+		OperatorCountGreaterThan, OperatorCountLessThan,
+		OperatorTimeOfDayBetween, OperatorDayOfWeekIn,
+		OperatorOlderThan, OperatorNewerThan, OperatorSamplePercent, OperatorIsType,
+		OperatorPercentileAbove, OperatorTopPercent,
+		OperatorIsEmpty, OperatorIsNotEmpty, OperatorLengthBetween:
 		return true
 	}
 	return false
 }
+
+// treatsMissingAsEmpty reports whether a missing attribute is evaluated as
+// an empty value rather than failing the condition
+func (o Operator) treatsMissingAsEmpty() bool {
+	return o == OperatorIsEmpty || o == OperatorIsNotEmpty || o == OperatorLengthBetween
+}
+
+// IsTimeOperator returns true if the operator compares against a point in time.
+// Time operators may leave the condition attribute empty to use the store clock
+func (o Operator) IsTimeOperator() bool {
+	return o == OperatorTimeOfDayBetween || o == OperatorDayOfWeekIn
+}
+
+// IsListOperator returns true if the operator expects a list of values
+func (o Operator) IsListOperator() bool {
+	return o == OperatorIn || o == OperatorNotIn
+}
+
+// operators lists every operator in declaration order with its description
+var operators = []struct {
+	op          Operator
+	description string
+}{
+	{OperatorEqual, "Equal"},
+	{OperatorNotEqual, "Not equal"},
+	{OperatorIn, "In list"},
+	{OperatorNotIn, "Not in list"},
+	{OperatorGreaterThan, "Greater than"},
+	{OperatorGreaterThanOrEqual, "Greater than or equal"},
+	{OperatorLessThan, "Less than"},
+	{OperatorLessThanOrEqual, "Less than or equal"},
+	{OperatorContains, "String contains"},
+	{OperatorStartsWith, "String starts with"},
+	{OperatorEndsWith, "String ends with"},
+	{OperatorRegex, "Regex match"},
+	{OperatorNotMatchesRegex, "Attribute present and does not match regex"},
+	{OperatorDivisibleBy, "Multiple of an integer"},
+	{OperatorWithinRadius, "Location within a radius in km of a point"},
+	{OperatorBucketIn, "Hashed 0-99 bucket in list or range"},
+	{OperatorSemverSatisfies, "Version satisfies an npm-style range"},
+	{OperatorCountGreaterThan, "List attribute has more than N elements"},
+	{OperatorCountLessThan, "List attribute has fewer than N elements"},
+	{OperatorTimeOfDayBetween, "Timestamp's time of day within a range"},
+	{OperatorDayOfWeekIn, "Timestamp falls on a listed weekday"},
+	{OperatorOlderThan, "Timestamp is more than a duration before now"},
+	{OperatorNewerThan, "Timestamp is less than a duration before now"},
+	{OperatorSamplePercent, "Attribute value in a stable sample of N percent of values"},
+	{OperatorIsType, "Attribute is of a type and non-empty"},
+	{OperatorPercentileAbove, "Percentile rank attribute (0-100) is above value"},
+	{OperatorTopPercent, "Percentile rank attribute is in the top value percent"},
+	{OperatorIsEmpty, "String, list or map attribute is empty or missing"},
+	{OperatorIsNotEmpty, "String, list or map attribute has at least one element"},
+	{OperatorLengthBetween, "Attribute length is within an inclusive range"},
+}
+
+// AllOperators returns every supported operator in declaration order,
+// e.g. to populate an operator picker
+func AllOperators() []Operator {
+	all := make([]Operator, len(operators))
+	for i, entry := range operators {
+		all[i] = entry.op
+	}
+	return all
+}
+
+// ParseOperator converts a string such as "==" or "starts_with" to an
+// Operator, returning ErrInvalidOperator for unknown operators
+func ParseOperator(s string) (Operator, error) {
+	op := Operator(strings.TrimSpace(s))
+	if !op.IsValid() {
+		return "", fmt.Errorf("%w: %q", ErrInvalidOperator, s)
+	}
+	return op, nil
+}
+
+// Description returns a short human-readable label for the operator, or ""
+// if it is not supported
+func (o Operator) Description() string {
+	for _, entry := range operators {
+		if entry.op == o {
+			return entry.description
+		}
+	}
+	return ""
+}
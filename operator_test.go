@@ -0,0 +1,85 @@
+package toggo
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestParseOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Operator
+		valid    bool
+	}{
+		{"==", OperatorEqual, true},
+		{"starts_with", OperatorStartsWith, true},
+		{" in ", OperatorIn, true},
+		{"sample_percent", OperatorSamplePercent, true},
+		{"equals", "", false},
+		{"STARTS_WITH", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			op, err := ParseOperator(tt.input)
+			if tt.valid && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidOperator) {
+				t.Errorf("expected ErrInvalidOperator, got %v", err)
+			}
+			if op != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, op)
+			}
+		})
+	}
+}
+
+func TestAllOperators(t *testing.T) {
+	// Collect every Operator constant declared in operator.go
+	file, err := parser.ParseFile(token.NewFileSet(), "operator.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	declared := map[string]bool{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			if ident, ok := value.Type.(*ast.Ident); ok && ident.Name == "Operator" {
+				for _, name := range value.Names {
+					declared[name.Name] = true
+				}
+			}
+		}
+	}
+
+	all := AllOperators()
+	if len(all) != len(declared) {
+		t.Errorf("expected %d operators, got %d", len(declared), len(all))
+	}
+	seen := map[Operator]bool{}
+	for _, op := range all {
+		if seen[op] {
+			t.Errorf("duplicate operator %q", op)
+		}
+		seen[op] = true
+		if !op.IsValid() {
+			t.Errorf("expected %q to be valid", op)
+		}
+		if op.Description() == "" {
+			t.Errorf("expected a description for %q", op)
+		}
+	}
+
+	if desc := Operator("nope").Description(); desc != "" {
+		t.Errorf("expected empty description, got %q", desc)
+	}
+}
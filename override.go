@@ -0,0 +1,91 @@
+package toggo
+
+// Override is a runtime adjustment layered over a flag's base configuration,
+// e.g. from an operator-managed KV store. Unset fields keep the base value
+type Override struct {
+	// Enabled replaces the flag's Enabled field
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+
+	// Rollout replaces the flag's rollout percentage, including any ramp,
+	// cohort rollout or attribute-supplied rollout
+	Rollout *int `json:"rollout,omitempty" yaml:"rollout,omitempty"`
+
+	// Variant forces every evaluation of an enabled flag to this variant,
+	// bypassing conditions and weights
+	Variant string `json:"variant,omitempty" yaml:"variant,omitempty"`
+}
+
+// SetOverride sets the override for a flag. Overrides win over the base flag
+// at evaluation time but never modify it, so GetFlag and exports of the base
+// configuration are unaffected. The flag does not need to exist yet
+func (s *Store) SetOverride(name string, override Override) error {
+	if override.Rollout != nil && (*override.Rollout < 0 || *override.Rollout > 100) {
+		return ErrInvalidRollout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	if s.overrides == nil {
+		s.overrides = make(map[string]Override)
+	}
+	s.overrides[name] = override
+	s.invalidateCache(name)
+	return nil
+}
+
+// ClearOverride removes a flag's override, restoring its base behavior.
+// A sealed read-only store is left unchanged; use TryClearOverride to
+// detect that
+func (s *Store) ClearOverride(name string) {
+	s.TryClearOverride(name)
+}
+
+// TryClearOverride is ClearOverride returning ErrReadOnly on a sealed
+// read-only store
+func (s *Store) TryClearOverride(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	delete(s.overrides, name)
+	s.invalidateCache(name)
+	return nil
+}
+
+// GetOverride returns the override set for a flag, if any
+func (s *Store) GetOverride(name string) (Override, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	override, ok := s.overrides[name]
+	return override, ok
+}
+
+// applyOverride returns the flag as seen through its override, if any.
+// The base flag is copied, never modified
+func (s *Store) applyOverride(flag *Flag) (*Flag, Override, bool) {
+	override, ok := s.GetOverride(flag.Name)
+	if !ok {
+		return flag, override, false
+	}
+
+	overridden := *flag
+	if override.Enabled != nil {
+		overridden.Enabled = *override.Enabled
+	}
+	if override.Rollout != nil {
+		overridden.Rollout = *override.Rollout
+		overridden.Ramp = nil
+		overridden.CohortRollout = nil
+		overridden.RolloutFromAttribute = ""
+	}
+	return &overridden, override, true
+}
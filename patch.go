@@ -0,0 +1,259 @@
+package toggo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// patchOperation is one RFC 6902 JSON Patch operation
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// PatchFlag applies an RFC 6902 JSON Patch (application/json-patch+json) to
+// a flag's JSON form and stores the result as AddFlag would, so a patch
+// producing an invalid flag is rejected and the flag is left unchanged.
+// For example, to change only the rollout:
+//
+//	[{"op": "replace", "path": "/rollout", "value": 50}]
+//
+// The patch applies to the flag as it was added, before inheritance and
+// registered sets were applied, so "@set:" references are kept and a child
+// flag keeps following its parent. Flags copied in by Merge are patched as
+// merged, standalone and with inheritance already applied. The read, patch and write happen under one lock, so concurrent
+// writes are never lost. Patches that fail, change the name (use RenameFlag)
+// or set unknown fields return ErrInvalidPatch. Returns ErrFlagNotFound if
+// the flag does not exist
+func (s *Store) PatchFlag(name string, patch []byte) error {
+	var operations []patchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	var changes []FlagChange
+	defer func() { s.publish(changes) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	config, ok := s.configs[name]
+	if !ok {
+		return ErrFlagNotFound
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	for i, op := range operations {
+		if doc, err = applyPatchOperation(doc, op); err != nil {
+			return fmt.Errorf("%w: operation %d (%s %s): %v", ErrInvalidPatch, i, op.Op, op.Path, err)
+		}
+	}
+
+	if data, err = json.Marshal(doc); err != nil {
+		return err
+	}
+	var patched Flag
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if patched.Name != name {
+		return fmt.Errorf("%w: name cannot be patched, use RenameFlag", ErrInvalidPatch)
+	}
+	if err := patched.Validate(); err != nil {
+		return err
+	}
+
+	changes, err = s.storeFlag(&patched)
+	return err
+}
+
+// applyPatchOperation applies one operation to a decoded JSON document
+func applyPatchOperation(doc interface{}, op patchOperation) (interface{}, error) {
+	path, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("missing value")
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+		if op.Op == "test" {
+			current, err := pointerGet(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("test failed")
+			}
+			return doc, nil
+		}
+		return pointerSet(doc, path, op.Op, value)
+	case "remove":
+		return pointerSet(doc, path, op.Op, nil)
+	case "move", "copy":
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return nil, fmt.Errorf("cannot move a value into itself")
+			}
+			if doc, err = pointerSet(doc, from, "remove", nil); err != nil {
+				return nil, err
+			}
+		} else {
+			value = cloneValue(value)
+		}
+		return pointerSet(doc, path, "add", value)
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// parseJSONPointer splits an RFC 6901 pointer such as "/variants/0/weight"
+// into unescaped reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// pointerGet returns the value at path
+func pointerGet(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			value, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found: %q", token)
+			}
+			node = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("path not found: %q", token)
+		}
+	}
+	return node, nil
+}
+
+// pointerSet applies an add, replace or remove at path and returns the
+// updated document
+func pointerSet(node interface{}, path []string, op string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		if op == "remove" {
+			return nil, fmt.Errorf("cannot remove the whole document")
+		}
+		return value, nil
+	}
+
+	token, last := path[0], len(path) == 1
+	switch n := node.(type) {
+	case map[string]interface{}:
+		current, exists := n[token]
+		if !last {
+			if !exists {
+				return nil, fmt.Errorf("path not found: %q", token)
+			}
+			updated, err := pointerSet(current, path[1:], op, value)
+			if err != nil {
+				return nil, err
+			}
+			n[token] = updated
+			return n, nil
+		}
+		if !exists && op != "add" {
+			return nil, fmt.Errorf("path not found: %q", token)
+		}
+		if op == "remove" {
+			delete(n, token)
+		} else {
+			n[token] = value
+		}
+		return n, nil
+	case []interface{}:
+		if !last {
+			index, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			if n[index], err = pointerSet(n[index], path[1:], op, value); err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+		if op == "add" {
+			index := len(n)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(n)); err != nil {
+					return nil, err
+				}
+			}
+			n = append(n, nil)
+			copy(n[index+1:], n[index:])
+			n[index] = value
+			return n, nil
+		}
+		index, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		if op == "remove" {
+			return append(n[:index], n[index+1:]...), nil
+		}
+		n[index] = value
+		return n, nil
+	}
+	return nil, fmt.Errorf("path not found: %q", token)
+}
+
+// arrayIndex parses an array index token no greater than max
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return index, nil
+}
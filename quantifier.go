@@ -0,0 +1,74 @@
+package toggo
+
+import "strings"
+
+// Quantifier applies a condition's operator to each element of a list attribute
+type Quantifier string
+
+const (
+	// QuantifierAny matches if at least one element satisfies the operator
+	QuantifierAny Quantifier = "any"
+
+	// QuantifierAll matches if every element satisfies the operator
+	QuantifierAll Quantifier = "all"
+)
+
+// IsValid checks if the quantifier is supported. The empty quantifier
+// compares the attribute as a whole
+func (q Quantifier) IsValid() bool {
+	return q == "" || q == QuantifierAny || q == QuantifierAll
+}
+
+// evaluateQuantified applies op to each element of a list context value,
+// reading ElementField from map elements if set. Non-list values, empty lists
+// and, for "all", elements missing the field do not match
+func (e *conditionEvaluator) evaluateQuantified(condition Condition, ctxValue, condValue interface{}) (bool, error) {
+	if !isList(ctxValue) {
+		return false, nil
+	}
+	items := listItems(ctxValue)
+	if len(items) == 0 {
+		return false, nil
+	}
+
+	for _, item := range items {
+		matched := false
+		if value, ok := elementField(item, condition.ElementField); ok {
+			var err error
+			if matched, err = e.evaluateOperator(condition.Operator, value, condValue); err != nil {
+				return false, err
+			}
+		}
+		if matched && condition.Quantifier == QuantifierAny {
+			return true, nil
+		}
+		if !matched && condition.Quantifier == QuantifierAll {
+			return false, nil
+		}
+	}
+	return condition.Quantifier == QuantifierAll, nil
+}
+
+// elementField reads a dotted field path such as "amount" or "item.price"
+// from a list element. An empty path returns the element itself
+func elementField(item interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return item, true
+	}
+	current := item
+	for _, key := range strings.Split(path, ".") {
+		var value interface{}
+		var ok bool
+		switch m := current.(type) {
+		case map[string]interface{}:
+			value, ok = m[key]
+		case Context:
+			value, ok = m.Get(key)
+		}
+		if !ok {
+			return nil, false
+		}
+		current = value
+	}
+	return current, true
+}
@@ -0,0 +1,46 @@
+package toggo
+
+import "time"
+
+// Ramp gradually moves a flag's rollout percentage from From to To
+// between Start and End. Before Start the rollout is From, after End it is To,
+// and in between it is linearly interpolated
+type Ramp struct {
+	// From is the rollout percentage (0-100) at Start
+	From int `json:"from" yaml:"from"`
+
+	// To is the rollout percentage (0-100) at End
+	To int `json:"to" yaml:"to"`
+
+	// Start is when the ramp begins
+	Start time.Time `json:"start" yaml:"start"`
+
+	// End is when the ramp reaches its target
+	End time.Time `json:"end" yaml:"end"`
+}
+
+// Validate checks if the ramp configuration is valid
+func (r *Ramp) Validate() error {
+	if r.From < 0 || r.From > 100 || r.To < 0 || r.To > 100 {
+		return ErrInvalidRollout
+	}
+	if !r.End.After(r.Start) {
+		return ErrInvalidRollout
+	}
+	return nil
+}
+
+// RolloutAt returns the interpolated rollout percentage at time t
+func (r *Ramp) RolloutAt(t time.Time) int {
+	if !t.After(r.Start) {
+		return r.From
+	}
+	if !t.Before(r.End) {
+		return r.To
+	}
+
+	elapsed := t.Sub(r.Start)
+	total := r.End.Sub(r.Start)
+	// Float math keeps multi-year ramps from overflowing
+	return r.From + int(float64(r.To-r.From)*float64(elapsed)/float64(total))
+}
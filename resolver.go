@@ -0,0 +1,38 @@
+package toggo
+
+// AttributeResolver fetches context attributes on demand, e.g. from
+// request-scoped services, instead of assembling a full Context up front
+type AttributeResolver interface {
+	// Resolve returns the attribute value and whether it exists
+	Resolve(key string) (interface{}, bool)
+}
+
+// AttributeResolverFunc adapts a function to the AttributeResolver interface
+type AttributeResolverFunc func(key string) (interface{}, bool)
+
+// Resolve calls f(key)
+func (f AttributeResolverFunc) Resolve(key string) (interface{}, bool) {
+	return f(key)
+}
+
+// IsEnabledResolver is IsEnabled for attributes pulled from a resolver.
+// Only the attributes the flag references are requested
+func (s *Store) IsEnabledResolver(name string, resolver AttributeResolver) bool {
+	flag, err := s.GetFlag(name)
+	if err != nil {
+		return false
+	}
+	return s.IsEnabled(name, resolveContext(flag, resolver))
+}
+
+// resolveContext builds a Context holding the flag's referenced attributes
+func resolveContext(flag *Flag, resolver AttributeResolver) Context {
+	attrs := referencedAttributes(flag)
+	ctx := make(Context, len(attrs))
+	for _, attr := range attrs {
+		if value, ok := resolver.Resolve(attr); ok {
+			ctx[attr] = value
+		}
+	}
+	return ctx
+}
@@ -16,6 +16,15 @@ type RolloutStrategy interface {
 }
 
 // DefaultRolloutStrategy implements standard percentage-based rollout
+//
+// Enrollment (ShouldRollout) and variant assignment (GetVariant) hash
+// different keys, "salt:key" and "salt:variant:key", so they are independent
+// streams: among users enrolled by any rollout percentage the variants keep
+// their weighted balance. Kill switches and dimensions use streams of their
+// own as well. FNV-1a, the default hasher, can leave some residual
+// correlation between streams of similar keys; MurmurHash3 mixes its output
+// more thoroughly. Note that a Store only calls ShouldRollout for flags
+// without variants; variant flags limit exposure through their weights
 type DefaultRolloutStrategy struct {
 	hasher hash.Hasher
 }
@@ -30,7 +39,9 @@ func NewDefaultRolloutStrategy(hasher hash.Hasher) *DefaultRolloutStrategy {
 	}
 }
 
-// ShouldRollout determines if the flag should be enabled based on rollout percentage
+// ShouldRollout determines if the flag should be enabled based on rollout percentage.
+// The rollout key is hashed into a bucket b in [0, 100) and the flag is
+// enabled when b < Rollout, so Rollout=1 enables exactly bucket 0
 func (r *DefaultRolloutStrategy) ShouldRollout(flag *Flag, ctx Context) (bool, error) {
 	// If rollout is 100, always return true
 	if flag.Rollout >= 100 {
@@ -43,8 +54,7 @@ func (r *DefaultRolloutStrategy) ShouldRollout(flag *Flag, ctx Context) (bool, e
 	}
 
 	// Get the rollout key value from context
-	rolloutKey := flag.GetRolloutKey()
-	keyValue, exists := ctx.Get(rolloutKey)
+	keyValue, exists := flag.rolloutKeyValue(ctx)
 	if !exists {
 		// If rollout key is missing, we can't make a consistent decision
 		// Return false to be conservative
@@ -52,39 +62,71 @@ func (r *DefaultRolloutStrategy) ShouldRollout(flag *Flag, ctx Context) (bool, e
 	}
 
 	// Create deterministic hash key
-	hashKey := fmt.Sprintf("%s:%s", flag.Name, fmt.Sprint(keyValue))
-	hashValue := r.hasher.Hash(hashKey)
+	hashValue := r.bucket(rolloutHashKey(flag, keyValue))
 
 	// Check if hash falls within rollout percentage
 	return hashValue < flag.Rollout, nil
 }
 
 // GetVariant determines which variant to return based on weights
+//
+// The rollout key is hashed into a bucket b in [0, 100). Variants own
+// consecutive half-open bucket ranges in configuration order: the i-th variant
+// owns [w0+...+w(i-1), w0+...+wi). A bucket exactly on a boundary therefore
+// belongs to the later variant, a zero-weight variant owns no buckets, and
+// when weights sum to 100 the last variant owns bucket 99. Buckets at or
+// beyond the total weight are not owned by any variant and resolve to
+// DefaultVariant. For example weights B=10 with a "control" default expose B
+// to buckets 0-9 (10% of users) and control to buckets 10-99; raising B to 20
+// keeps every existing B user in B
+//
+// Appending a variant without changing Flag.Version therefore moves nobody
+// already in a variant: with A=45, B=45 and a zero-weight "holdout" default,
+// A owns [0, 45), B owns [45, 90) and buckets [90, 100) fall to holdout;
+// appending C=5 gives C [90, 95) out of the holdout remainder while A and B
+// keep their ranges. Existing users only move if earlier weights change or
+// variants are reordered, or when Version is bumped to re-randomize on purpose
 func (r *DefaultRolloutStrategy) GetVariant(flag *Flag, ctx Context) (string, error) {
 	if !flag.HasVariants() {
 		return flag.DefaultVariant, nil
 	}
 
 	// Get the rollout key value from context
-	rolloutKey := flag.GetRolloutKey()
-	keyValue, exists := ctx.Get(rolloutKey)
+	keyValue, exists := flag.rolloutKeyValue(ctx)
 	if !exists {
 		return flag.DefaultVariant, nil
 	}
 
 	// Create deterministic hash key for variant selection
-	hashKey := fmt.Sprintf("%s:variant:%s", flag.Name, fmt.Sprint(keyValue))
-	hashValue := r.hasher.Hash(hashKey)
+	hashValue := r.bucket(fmt.Sprintf("%s:variant:%s", flag.hashSalt(), fmt.Sprint(keyValue)))
 
-	// Find the variant based on cumulative weights
+	return variantAtBucket(flag, hashValue), nil
+}
+
+// variantAtBucket finds the variant owning bucket by cumulative weights.
+// Buckets beyond the total weight fall through to the default
+func variantAtBucket(flag *Flag, bucket int) string {
 	cumulative := 0
 	for _, variant := range flag.Variants {
 		cumulative += variant.Weight
-		if hashValue < cumulative {
-			return variant.Name, nil
+		if bucket < cumulative {
+			return variant.Name
 		}
 	}
+	return flag.DefaultVariant
+}
 
-	// If no variant matched (shouldn't happen with proper config), return default
-	return flag.DefaultVariant, nil
+// bucket hashes key into [0, 100), folding out-of-range values from custom
+// hashers back into range so every bucket keeps its owner
+func (r *DefaultRolloutStrategy) bucket(key string) int {
+	return (r.hasher.Hash(key)%100 + 100) % 100
+}
+
+// rolloutHashKey builds the hash key used for rollout decisions.
+// Flags with a RolloutScope include it so the segment is bucketed independently
+func rolloutHashKey(flag *Flag, keyValue interface{}) string {
+	if flag.RolloutScope != "" {
+		return fmt.Sprintf("%s:%s:%s", flag.hashSalt(), flag.RolloutScope, fmt.Sprint(keyValue))
+	}
+	return fmt.Sprintf("%s:%s", flag.hashSalt(), fmt.Sprint(keyValue))
 }
@@ -0,0 +1,299 @@
+package toggo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fixedHasher places every key in the same bucket
+type fixedHasher int
+
+func (h fixedHasher) Hash(string) int {
+	return int(h)
+}
+
+func TestDefaultRolloutStrategy_VariantBoundaries(t *testing.T) {
+	full := &Flag{
+		Name:           "full",
+		DefaultVariant: "control",
+		Variants:       []Variant{{Name: "a", Weight: 30}, {Name: "b", Weight: 70}},
+	}
+	partial := &Flag{
+		Name:           "partial",
+		DefaultVariant: "control",
+		Variants:       []Variant{{Name: "a", Weight: 30}, {Name: "b", Weight: 20}},
+	}
+	zeroWeight := &Flag{
+		Name:           "zero_weight",
+		DefaultVariant: "control",
+		Variants:       []Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 0}, {Name: "c", Weight: 50}},
+	}
+
+	tests := []struct {
+		name     string
+		flag     *Flag
+		bucket   int
+		expected string
+	}{
+		{"first bucket", full, 0, "a"},
+		{"last bucket of first variant", full, 29, "a"},
+		{"boundary goes to next variant", full, 30, "b"},
+		{"top bucket reaches last variant", full, 99, "b"},
+		{"last weighted bucket", partial, 49, "b"},
+		{"total weight boundary falls to default", partial, 50, "control"},
+		{"zero weight variant owns no buckets", zeroWeight, 50, "c"},
+		{"out of range hash is folded", full, 130, "b"},
+		{"negative hash is folded", full, -1, "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := NewDefaultRolloutStrategy(fixedHasher(tt.bucket))
+			variant, err := strategy.GetVariant(tt.flag, Context{"user_id": "u"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if variant != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, variant)
+			}
+		})
+	}
+}
+
+func TestStore_VariantForBucket(t *testing.T) {
+	store := NewStore()
+	err := store.AddFlag(&Flag{
+		Name:           "checkout_test",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Variants:       []Variant{{Name: "control", Weight: 50}, {Name: "a", Weight: 25}, {Name: "b", Weight: 25}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		bucket   int
+		expected string
+	}{
+		{0, "control"},
+		{42, "control"},
+		{49, "control"},
+		{50, "a"},
+		{74, "a"},
+		{75, "b"},
+		{99, "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.bucket), func(t *testing.T) {
+			variant, err := store.VariantForBucket("checkout_test", tt.bucket)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if variant != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, variant)
+			}
+		})
+	}
+
+	if _, err := store.VariantForBucket("checkout_test", 100); !errors.Is(err, ErrInvalidRollout) {
+		t.Errorf("expected ErrInvalidRollout, got %v", err)
+	}
+	if _, err := store.VariantForBucket("missing", 0); !errors.Is(err, ErrFlagNotFound) {
+		t.Errorf("expected ErrFlagNotFound, got %v", err)
+	}
+}
+
+func TestDefaultRolloutStrategy_RolloutBoundaries(t *testing.T) {
+	tests := []struct {
+		name     string
+		rollout  int
+		bucket   int
+		expected bool
+	}{
+		{"1% includes bucket 0", 1, 0, true},
+		{"1% excludes bucket 1", 1, 1, false},
+		{"boundary bucket is excluded", 50, 50, false},
+		{"bucket below boundary is included", 50, 49, true},
+		{"99% excludes bucket 99", 99, 99, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := NewDefaultRolloutStrategy(fixedHasher(tt.bucket))
+			flag := &Flag{Name: "rollout", Rollout: tt.rollout}
+			enabled, err := strategy.ShouldRollout(flag, Context{"user_id": "u"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if enabled != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, enabled)
+			}
+		})
+	}
+}
+
+func TestStore_VariantWindows(t *testing.T) {
+	day0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day3, day7 := day0.AddDate(0, 0, 3), day0.AddDate(0, 0, 7)
+	now := day0
+
+	store := NewStore(WithClock(func() time.Time { return now }))
+	err := store.AddFlag(&Flag{
+		Name:    "phased",
+		Enabled: true,
+		Variants: []Variant{
+			{Name: "control", Weight: 50},
+			{Name: "a", Weight: 25, StartsAt: &day3},
+			{Name: "b", Weight: 25, StartsAt: &day7},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want map[string]bool
+	}{
+		{"before a starts", day0.AddDate(0, 0, 1), map[string]bool{"control": true}},
+		{"a started", day0.AddDate(0, 0, 4), map[string]bool{"control": true, "a": true}},
+		{"all started", day0.AddDate(0, 0, 8), map[string]bool{"control": true, "a": true, "b": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = tt.now
+			seen := make(map[string]bool)
+			for i := 0; i < 1000; i++ {
+				variant, enabled := store.GetVariant("phased", Context{"user_id": fmt.Sprintf("user-%d", i)})
+				if !enabled {
+					t.Fatalf("expected enabled for user-%d, got variant %q", i, variant)
+				}
+				seen[variant] = true
+			}
+			if !reflect.DeepEqual(seen, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, seen)
+			}
+		})
+	}
+}
+
+func TestStore_VariantAllocationWindows(t *testing.T) {
+	day0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day3 := day0.AddDate(0, 0, 3)
+	now := day0
+
+	store := NewStore(WithClock(func() time.Time { return now }))
+	store.AddFlag(&Flag{
+		Name:           "phased",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Variants: []Variant{
+			{Name: "control", Weight: 50},
+			{Name: "a", Weight: 50, StartsAt: &day3},
+		},
+	})
+
+	allocation, err := store.VariantAllocation("phased")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]float64{"control": 100}; !reflect.DeepEqual(allocation, want) {
+		t.Errorf("expected %v before a starts, got %v", want, allocation)
+	}
+
+	now = day3
+	allocation, _ = store.VariantAllocation("phased")
+	if want := map[string]float64{"control": 50, "a": 50}; !reflect.DeepEqual(allocation, want) {
+		t.Errorf("expected %v once a starts, got %v", want, allocation)
+	}
+}
+
+func TestWithActiveVariants(t *testing.T) {
+	start := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)
+	flag := &Flag{
+		Name: "phased",
+		Variants: []Variant{
+			{Name: "control", Weight: 40},
+			{Name: "a", Weight: 20},
+			{Name: "b", Weight: 20, StartsAt: &start},
+			{Name: "c", Weight: 10, EndsAt: &end},
+		},
+	}
+
+	effective := withActiveVariants(flag, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC))
+	got := make(map[string]int)
+	for _, variant := range effective.Variants {
+		got[variant.Name] = variant.Weight
+	}
+	want := map[string]int{"control": 60, "a": 30}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if len(flag.Variants) != 4 {
+		t.Errorf("expected original flag unchanged, got %d variants", len(flag.Variants))
+	}
+
+	if all := withActiveVariants(flag, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)); all == flag {
+		t.Error("expected a copy while b is pending")
+	}
+}
+
+func TestFlag_ValidateVariantWindow(t *testing.T) {
+	start := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	end := start.Add(-time.Hour)
+	flag := &Flag{
+		Name:     "phased",
+		Variants: []Variant{{Name: "a", Weight: 100, StartsAt: &start, EndsAt: &end}},
+	}
+	if err := flag.Validate(); !errors.Is(err, ErrInvalidVariant) {
+		t.Errorf("expected %v, got %v", ErrInvalidVariant, err)
+	}
+}
+
+func TestDefaultRolloutStrategy_IndependentStreams(t *testing.T) {
+	flag := &Flag{
+		Name:     "checkout",
+		Rollout:  30,
+		Variants: []Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50}},
+	}
+
+	for _, hasher := range []Hasher{NewFNVHasher(), NewMurmur3Hasher()} {
+		strategy := NewDefaultRolloutStrategy(hasher)
+
+		enrolled, a := 0, 0
+		for i := 0; i < 20000; i++ {
+			ctx := Context{"user_id": fmt.Sprintf("user-%d", i)}
+			ok, err := strategy.ShouldRollout(flag, ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !ok {
+				continue
+			}
+			enrolled++
+			variant, err := strategy.GetVariant(flag, ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if variant == "a" {
+				a++
+			}
+		}
+
+		if enrolled < 5500 || enrolled > 6500 {
+			t.Errorf("%T: expected about 6000 enrolled users, got %d", hasher, enrolled)
+		}
+		// Enrollment must not skew the variant split among enrolled users
+		if share := float64(a) / float64(enrolled); share < 0.48 || share > 0.52 {
+			t.Errorf("%T: expected about 50%% in a among enrolled users, got %.1f%%", hasher, share*100)
+		}
+	}
+}
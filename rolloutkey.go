@@ -0,0 +1,43 @@
+package toggo
+
+// RolloutKeyRule picks the rollout key for contexts matching its conditions,
+// e.g. bucketing B2B users by "account_id" and everyone else by "user_id"
+type RolloutKeyRule struct {
+	// Conditions must all match for the rule to apply
+	Conditions []Condition `json:"conditions" yaml:"conditions"`
+
+	// Key is the context attribute hashed for rollout and variant
+	// assignment when the rule applies
+	Key string `json:"key" yaml:"key"`
+}
+
+// withRolloutKeyRule returns the flag to bucket ctx with: a shallow copy
+// whose RolloutKey is the key of the first matching rule, or the flag itself
+// when no rule matches. The copy has no rules left to apply
+func (s *Store) withRolloutKeyRule(eval *conditionEvaluator, flag *Flag, ctx Context) (*Flag, error) {
+	for _, rule := range flag.RolloutKeyRules {
+		match, err := eval.evaluateAllWithSchema(rule.Conditions, ctx, flag.AttributeSchema)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			keyed := *flag
+			keyed.RolloutKey = rule.Key
+			keyed.RolloutKeyRules = nil
+			return &keyed, nil
+		}
+	}
+	return flag, nil
+}
+
+func cloneRolloutKeyRules(rules []RolloutKeyRule) []RolloutKeyRule {
+	if rules == nil {
+		return nil
+	}
+	clone := make([]RolloutKeyRule, len(rules))
+	for i, rule := range rules {
+		clone[i] = rule
+		clone[i].Conditions = cloneConditions(rule.Conditions)
+	}
+	return clone
+}
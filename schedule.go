@@ -0,0 +1,216 @@
+package toggo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule restricts a flag to recurring active windows such as weekdays
+// 09:00-17:00 or the first week of each month. Outside every window the
+// flag evaluates as disabled
+type Schedule struct {
+	// Timezone is the IANA location windows are expressed in. Defaults to UTC
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+
+	// Windows are the recurring active periods; the flag is active inside any of them
+	Windows []ScheduleWindow `json:"windows" yaml:"windows"`
+
+	// loc caches the resolved Timezone and bounds each window's parsed
+	// start and end. They are only set on copies made by withLocation, so a
+	// Schedule is never written once shared
+	loc    *time.Location
+	bounds []clockRange
+}
+
+// clockRange is a window's start and end in minutes since midnight
+type clockRange struct {
+	start, end int
+}
+
+// ScheduleWindow is a daily time range on selected days.
+// Empty day filters match every day
+type ScheduleWindow struct {
+	// Weekdays limits the window to days such as "mon" or "sat"
+	Weekdays []string `json:"weekdays,omitempty" yaml:"weekdays,omitempty"`
+
+	// DaysOfMonth limits the window to days 1-31 of the month
+	DaysOfMonth []int `json:"days_of_month,omitempty" yaml:"days_of_month,omitempty"`
+
+	// Start is the inclusive "HH:MM" start time. Defaults to "00:00"
+	Start string `json:"start,omitempty" yaml:"start,omitempty"`
+
+	// End is the exclusive "HH:MM" end time. Defaults to "24:00"
+	End string `json:"end,omitempty" yaml:"end,omitempty"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Validate checks if the schedule configuration is valid
+func (s *Schedule) Validate() error {
+	if _, err := s.location(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if len(s.Windows) == 0 {
+		return fmt.Errorf("%w: no windows", ErrInvalidSchedule)
+	}
+	for _, w := range s.Windows {
+		if err := w.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withLocation returns a copy of the schedule with its timezone resolved and
+// window bounds parsed, so ActiveAt does not redo either on every evaluation.
+// The schedule itself is returned if already resolved or invalid
+func (s *Schedule) withLocation() *Schedule {
+	if s.loc != nil {
+		return s
+	}
+	loc, err := s.location()
+	if err != nil {
+		return s
+	}
+	bounds := make([]clockRange, len(s.Windows))
+	for i, w := range s.Windows {
+		if bounds[i].start, bounds[i].end, err = w.minutes(); err != nil {
+			return s
+		}
+	}
+	resolved := *s
+	resolved.loc = loc
+	resolved.bounds = bounds
+	return &resolved
+}
+
+// ActiveAt reports whether t falls inside any of the schedule's windows
+func (s *Schedule) ActiveAt(t time.Time) bool {
+	loc := s.loc
+	if loc == nil {
+		var err error
+		if loc, err = s.location(); err != nil {
+			return false
+		}
+	}
+
+	t = t.In(loc)
+	for i, w := range s.Windows {
+		var bounds clockRange
+		if s.bounds != nil {
+			bounds = s.bounds[i]
+		} else {
+			var err error
+			if bounds.start, bounds.end, err = w.minutes(); err != nil {
+				continue
+			}
+		}
+		if w.activeAt(t, bounds) {
+			return true
+		}
+	}
+	return false
+}
+
+// location resolves the schedule timezone
+func (s *Schedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// validate checks the window's day filters and time range
+func (w ScheduleWindow) validate() error {
+	for _, day := range w.Weekdays {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("%w: unknown weekday %q", ErrInvalidSchedule, day)
+		}
+	}
+	for _, day := range w.DaysOfMonth {
+		if day < 1 || day > 31 {
+			return fmt.Errorf("%w: day of month %d", ErrInvalidSchedule, day)
+		}
+	}
+
+	start, end, err := w.minutes()
+	if err != nil {
+		return err
+	}
+	if start >= end {
+		return fmt.Errorf("%w: window start %q is not before end %q", ErrInvalidSchedule, w.Start, w.End)
+	}
+	return nil
+}
+
+// activeAt reports whether t, already in the schedule's timezone, is inside
+// the window whose parsed bounds are given
+func (w ScheduleWindow) activeAt(t time.Time, bounds clockRange) bool {
+	if len(w.Weekdays) > 0 && !w.matchesWeekday(t.Weekday()) {
+		return false
+	}
+	if len(w.DaysOfMonth) > 0 && !w.matchesDayOfMonth(t.Day()) {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	return minute >= bounds.start && minute < bounds.end
+}
+
+func (w ScheduleWindow) matchesWeekday(day time.Weekday) bool {
+	for _, name := range w.Weekdays {
+		if weekdays[strings.ToLower(name)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (w ScheduleWindow) matchesDayOfMonth(day int) bool {
+	for _, d := range w.DaysOfMonth {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// minutes returns the window bounds as minutes since midnight
+func (w ScheduleWindow) minutes() (int, int, error) {
+	start, err := parseClock(w.Start, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(w.End, 24*60)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight. "24:00" is allowed
+// as an end of day marker; an empty value returns def. Trailing input such
+// as "9:30pm" is rejected
+func parseClock(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid time %q", ErrInvalidSchedule, value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
@@ -0,0 +1,111 @@
+package toggo
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// Attribute types accepted in Flag.AttributeSchema
+const (
+	AttributeTypeNumber = "number"
+	AttributeTypeString = "string"
+	AttributeTypeBool   = "bool"
+)
+
+// AttributeTypeList names list attributes for OperatorIsType
+const AttributeTypeList = "list"
+
+// validateSchema checks that every schema entry names a known attribute type
+func validateSchema(schema map[string]string) error {
+	for _, typ := range schema {
+		switch typ {
+		case AttributeTypeNumber, AttributeTypeString, AttributeTypeBool:
+		default:
+			return ErrInvalidSchema
+		}
+	}
+	return nil
+}
+
+// coerceAttribute converts a context value to the schema type.
+// Numbers accept numeric types and numeric strings, bools accept bools and
+// "true"/"false" strings, and strings accept only strings.
+// Returns false if the value cannot be represented as the type
+func coerceAttribute(value interface{}, typ string) (interface{}, bool) {
+	switch typ {
+	case AttributeTypeNumber:
+		n, err := toFloat64(value)
+		if err != nil {
+			return nil, false
+		}
+		return n, true
+	case AttributeTypeString:
+		str, ok := value.(string)
+		return str, ok
+	case AttributeTypeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, true
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, false
+			}
+			return b, true
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+// parseTypeNames reads an is_type value: a type name or a list of them
+func parseTypeNames(value interface{}) ([]string, bool) {
+	items := []interface{}{value}
+	if isList(value) {
+		items = listItems(value)
+	}
+	if len(items) == 0 {
+		return nil, false
+	}
+
+	types := make([]string, len(items))
+	for i, item := range items {
+		typ, _ := item.(string)
+		switch typ {
+		case AttributeTypeString, AttributeTypeNumber, AttributeTypeBool, AttributeTypeList:
+			types[i] = typ
+		default:
+			return nil, false
+		}
+	}
+	return types, true
+}
+
+// hasType reports whether value is of type typ and non-empty: a non-empty
+// string, a number other than NaN, a bool, or a non-empty list.
+// Numeric strings are not numbers
+func hasType(value interface{}, typ string) bool {
+	switch typ {
+	case AttributeTypeString:
+		s, ok := value.(string)
+		return ok && s != ""
+	case AttributeTypeBool:
+		_, ok := value.(bool)
+		return ok
+	case AttributeTypeList:
+		return isList(value) && reflect.ValueOf(value).Len() > 0
+	case AttributeTypeNumber:
+		if value == nil {
+			return false
+		}
+		switch reflect.TypeOf(value).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		case reflect.Float32, reflect.Float64:
+			return !math.IsNaN(reflect.ValueOf(value).Float())
+		}
+	}
+	return false
+}
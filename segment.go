@@ -0,0 +1,40 @@
+package toggo
+
+// SegmentOverride pins every context matching its conditions to one variant,
+// e.g. all users in DE get "localized" during a regional test. Contexts that
+// match no segment override are assigned by weight as usual
+type SegmentOverride struct {
+	// Conditions must all match for the override to apply
+	Conditions []Condition `json:"conditions" yaml:"conditions"`
+
+	// Variant is the variant assigned to the segment. It bypasses weights,
+	// the variant's own conditions and its StartsAt/EndsAt window
+	Variant string `json:"variant" yaml:"variant"`
+}
+
+// matchSegmentOverride returns the variant of the first segment override
+// whose conditions match ctx
+func (s *Store) matchSegmentOverride(eval *conditionEvaluator, flag *Flag, ctx Context) (string, bool, error) {
+	for _, segment := range flag.SegmentOverrides {
+		match, err := eval.evaluateAllWithSchema(segment.Conditions, ctx, flag.AttributeSchema)
+		if err != nil {
+			return "", false, err
+		}
+		if match {
+			return segment.Variant, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func cloneSegmentOverrides(segments []SegmentOverride) []SegmentOverride {
+	if segments == nil {
+		return nil
+	}
+	clone := make([]SegmentOverride, len(segments))
+	for i, segment := range segments {
+		clone[i] = segment
+		clone[i].Conditions = cloneConditions(segment.Conditions)
+	}
+	return clone
+}
@@ -0,0 +1,203 @@
+package toggo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed major.minor.patch version.
+// Pre-release and build metadata are ignored when comparing
+type semver [3]int
+
+// compare returns -1, 0 or 1 depending on whether v is lower, equal or higher than other
+func (v semver) compare(other semver) int {
+	for i := range v {
+		if v[i] < other[i] {
+			return -1
+		}
+		if v[i] > other[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseSemver parses versions such as "1.2.3", "v1.2" or "1.2.3-beta.1".
+// Missing minor and patch components default to zero
+func parseSemver(s string) (semver, error) {
+	var v semver
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// semverComparator is a single "op version" constraint
+type semverComparator struct {
+	op      string
+	version semver
+}
+
+// matches reports whether v satisfies the comparator
+func (c semverComparator) matches(v semver) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// semverRange is a set of alternatives ("||") each made of comparators that must all hold
+type semverRange [][]semverComparator
+
+// satisfiedBy reports whether v satisfies any alternative of the range
+func (r semverRange) satisfiedBy(v semver) bool {
+	for _, set := range r {
+		matched := true
+		for _, c := range set {
+			if !c.matches(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSemverRange parses npm-style range expressions. Supported forms are
+// caret (^1.2.3), tilde (~1.2.3), comparisons (>=, >, <=, <, =), x wildcards
+// (1.x, 1.2.x, *), whitespace-separated AND and "||" OR. An operator may be
+// separated from its version by spaces, as in ">= 1.0.0". Empty ranges and
+// empty alternatives are rejected; use "*" to match every version
+func parseSemverRange(expr string) (semverRange, error) {
+	var r semverRange
+	for _, alternative := range strings.Split(expr, "||") {
+		tokens := strings.Fields(alternative)
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("empty range in %q", expr)
+		}
+		var set []semverComparator
+		for i := 0; i < len(tokens); i++ {
+			token := tokens[i]
+			if isSemverOperator(token) {
+				if i+1 == len(tokens) {
+					return nil, fmt.Errorf("missing version after %q", token)
+				}
+				i++
+				token += tokens[i]
+			}
+			comparators, err := parseSemverToken(token)
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, comparators...)
+		}
+		r = append(r, set)
+	}
+	return r, nil
+}
+
+// isSemverOperator reports whether token is a bare range operator
+func isSemverOperator(token string) bool {
+	switch token {
+	case "^", "~", ">=", "<=", ">", "<", "=":
+		return true
+	}
+	return false
+}
+
+// parseSemverToken expands a single range token into comparators
+func parseSemverToken(token string) ([]semverComparator, error) {
+	switch {
+	case strings.HasPrefix(token, "^"):
+		v, err := parseSemver(token[1:])
+		if err != nil {
+			return nil, err
+		}
+		upper := semver{v[0] + 1, 0, 0}
+		if v[0] == 0 && v[1] > 0 {
+			upper = semver{0, v[1] + 1, 0}
+		} else if v[0] == 0 {
+			upper = semver{0, 0, v[2] + 1}
+		}
+		return []semverComparator{{">=", v}, {"<", upper}}, nil
+	case strings.HasPrefix(token, "~"):
+		v, err := parseSemver(token[1:])
+		if err != nil {
+			return nil, err
+		}
+		upper := semver{v[0], v[1] + 1, 0}
+		if !strings.Contains(token, ".") {
+			upper = semver{v[0] + 1, 0, 0}
+		}
+		return []semverComparator{{">=", v}, {"<", upper}}, nil
+	}
+
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(token, op) {
+			v, err := parseSemver(token[len(op):])
+			if err != nil {
+				return nil, err
+			}
+			return []semverComparator{{op, v}}, nil
+		}
+	}
+
+	return parseSemverWildcard(token)
+}
+
+// parseSemverWildcard expands "*", "1", "1.x" and "1.2.x" into bounds.
+// A fully specified version is an exact match
+func parseSemverWildcard(token string) ([]semverComparator, error) {
+	var fixed []int
+	for _, part := range strings.Split(strings.TrimPrefix(token, "v"), ".") {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version range %q", token)
+		}
+		fixed = append(fixed, n)
+	}
+	if len(fixed) > 3 {
+		return nil, fmt.Errorf("invalid version range %q", token)
+	}
+
+	switch len(fixed) {
+	case 0:
+		return nil, nil
+	case 1:
+		return []semverComparator{{">=", semver{fixed[0], 0, 0}}, {"<", semver{fixed[0] + 1, 0, 0}}}, nil
+	case 2:
+		return []semverComparator{{">=", semver{fixed[0], fixed[1], 0}}, {"<", semver{fixed[0], fixed[1] + 1, 0}}}, nil
+	default:
+		return []semverComparator{{"=", semver{fixed[0], fixed[1], fixed[2]}}}, nil
+	}
+}
@@ -0,0 +1,136 @@
+package toggo
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetRefPrefix marks an in/not_in condition value that names a set
+// registered with Store.RegisterSet, e.g. "@set:employees"
+const SetRefPrefix = "@set:"
+
+// StringSet is a set of canonical strings used as an in/not_in condition
+// value for O(1) membership checks on large lists
+type StringSet map[string]struct{}
+
+// NewStringSet builds a set from values
+func NewStringSet(values []string) StringSet {
+	set := make(StringSet, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// Has reports whether value is in the set
+func (s StringSet) Has(value string) bool {
+	_, ok := s[value]
+	return ok
+}
+
+// MarshalJSON encodes the set as a sorted list so flags keep their
+// configuration file shape when serialized
+func (s StringSet) MarshalJSON() ([]byte, error) {
+	values := make([]string, 0, len(s))
+	for v := range s {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return json.Marshal(values)
+}
+
+// setRef returns the set name if value is a set reference
+func setRef(value interface{}) (string, bool) {
+	str, ok := value.(string)
+	if !ok || !strings.HasPrefix(str, SetRefPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(str, SetRefPrefix), true
+}
+
+// RegisterSet registers a named set of values that in/not_in conditions can
+// reference as "@set:<name>". References are resolved when a flag is added,
+// so sets must be registered before the flags that use them; re-registering
+// a set does not affect flags already in the store. A sealed read-only store
+// is left unchanged; use TryRegisterSet to detect that
+func (s *Store) RegisterSet(name string, values []string) {
+	s.TryRegisterSet(name, values)
+}
+
+// TryRegisterSet is RegisterSet returning ErrReadOnly on a sealed read-only store
+func (s *Store) TryRegisterSet(name string, values []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	if s.sets == nil {
+		s.sets = make(map[string]StringSet)
+	}
+	s.sets[name] = NewStringSet(values)
+	return nil
+}
+
+// prepareSets resolves set references in a flag's conditions to the
+// registered sets and indexes inline lists into sets for O(1) membership.
+// The flag is copied if any list condition is found.
+// Must be called with the store lock held
+func prepareSets(flag *Flag, sets map[string]StringSet) (*Flag, error) {
+	if !hasListConditions(flag) {
+		return flag, nil
+	}
+
+	return flag.mapConditionLists(func(conditions []Condition) ([]Condition, error) {
+		return prepareConditionSets(conditions, sets)
+	})
+}
+
+// prepareConditionSets returns a copy of conditions with set references
+// resolved and list values indexed. Value keeps its original list so the
+// condition serializes unchanged
+func prepareConditionSets(conditions []Condition, sets map[string]StringSet) ([]Condition, error) {
+	if conditions == nil {
+		return nil, nil
+	}
+
+	prepared := make([]Condition, len(conditions))
+	for i, cond := range conditions {
+		prepared[i] = cond
+		if !cond.Operator.IsListOperator() {
+			continue
+		}
+		if name, ok := setRef(cond.Value); ok {
+			set, ok := sets[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrUnknownSet, name)
+			}
+			prepared[i].Value = set
+			continue
+		}
+		if isList(cond.Value) {
+			items := listItems(cond.Value)
+			values := make([]string, len(items))
+			for j, item := range items {
+				values[j] = canonicalString(item)
+			}
+			prepared[i].set = NewStringSet(values)
+		}
+	}
+	return prepared, nil
+}
+
+// hasListConditions reports whether any of the flag's conditions use a list operator
+func hasListConditions(flag *Flag) bool {
+	for _, list := range flag.conditionLists() {
+		for _, cond := range *list {
+			if cond.Operator.IsListOperator() {
+				return true
+			}
+		}
+	}
+	return false
+}
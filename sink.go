@@ -0,0 +1,78 @@
+package toggo
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// evaluationSink buffers evaluation records and hands them to a callback in
+// batches from a background goroutine, so slow logging never blocks evaluation
+type evaluationSink struct {
+	records chan EvaluationRecord
+	flush   func([]EvaluationRecord)
+	dropped atomic.Uint64
+}
+
+// WithAsyncEvaluationSink delivers evaluation records to fn in batches from a
+// background goroutine instead of on the request path. Up to bufferSize
+// records are queued; when the queue is full new records are dropped and
+// counted (see DroppedEvaluations) rather than blocking evaluation. Each batch
+// holds the records queued since the previous call to fn. Close flushes the
+// queue and stops the goroutine. Records reference the caller's Context, so
+// contexts must not be mutated after evaluating them
+func WithAsyncEvaluationSink(bufferSize int, fn func([]EvaluationRecord)) StoreOption {
+	return func(s *Store) {
+		if bufferSize < 1 {
+			bufferSize = 1
+		}
+		s.sink = &evaluationSink{
+			records: make(chan EvaluationRecord, bufferSize),
+			flush:   fn,
+		}
+	}
+}
+
+// send queues a record, dropping it if the buffer is full
+func (sink *evaluationSink) send(record EvaluationRecord) {
+	select {
+	case sink.records <- record:
+	default:
+		sink.dropped.Add(1)
+	}
+}
+
+// run delivers batches until ctx is cancelled, then flushes what is left
+func (sink *evaluationSink) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			if batch := sink.drain(nil); len(batch) > 0 {
+				sink.flush(batch)
+			}
+			return
+		case record := <-sink.records:
+			sink.flush(sink.drain([]EvaluationRecord{record}))
+		}
+	}
+}
+
+// drain appends the records currently queued to batch without waiting for more
+func (sink *evaluationSink) drain(batch []EvaluationRecord) []EvaluationRecord {
+	for {
+		select {
+		case record := <-sink.records:
+			batch = append(batch, record)
+		default:
+			return batch
+		}
+	}
+}
+
+// DroppedEvaluations returns how many records the async evaluation sink has
+// dropped because its buffer was full. It is always 0 without a sink
+func (s *Store) DroppedEvaluations() uint64 {
+	if s.sink == nil {
+		return 0
+	}
+	return s.sink.dropped.Load()
+}
@@ -0,0 +1,103 @@
+package toggo
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingSink records batches, holding the first delivery until released
+type blockingSink struct {
+	mu       sync.Mutex
+	batches  [][]EvaluationRecord
+	started  chan struct{}
+	release  chan struct{}
+	onceWait sync.Once
+}
+
+func newBlockingSink() *blockingSink {
+	return &blockingSink{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (b *blockingSink) flush(batch []EvaluationRecord) {
+	b.onceWait.Do(func() {
+		close(b.started)
+		<-b.release
+	})
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batches = append(b.batches, batch)
+}
+
+func (b *blockingSink) sizes() []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sizes := make([]int, len(b.batches))
+	for i, batch := range b.batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func TestStore_AsyncEvaluationSink_Batches(t *testing.T) {
+	sink := newBlockingSink()
+	store := NewStore(WithAsyncEvaluationSink(100, sink.flush))
+	store.AddFlag(&Flag{Name: "feature", Enabled: true, Rollout: 100})
+
+	store.IsEnabled("feature", Context{"user_id": "u0"})
+	<-sink.started
+	for i := 1; i <= 5; i++ {
+		store.IsEnabled("feature", Context{"user_id": i})
+	}
+	close(sink.release)
+	store.Close()
+
+	sizes := sink.sizes()
+	if len(sizes) != 2 || sizes[0] != 1 || sizes[1] != 5 {
+		t.Errorf("expected batches [1 5], got %v", sizes)
+	}
+	if dropped := store.DroppedEvaluations(); dropped != 0 {
+		t.Errorf("expected 0, got %d", dropped)
+	}
+	if got := sink.batches[1][4].Context["user_id"]; got != 5 {
+		t.Errorf("expected %v, got %v", 5, got)
+	}
+}
+
+func TestStore_AsyncEvaluationSink_Overflow(t *testing.T) {
+	sink := newBlockingSink()
+	store := NewStore(WithAsyncEvaluationSink(2, sink.flush))
+	store.AddFlag(&Flag{Name: "feature", Enabled: true, Rollout: 100})
+
+	store.IsEnabled("feature", Context{"user_id": "u0"})
+	<-sink.started
+
+	done := make(chan struct{})
+	go func() {
+		for i := 1; i <= 5; i++ {
+			store.IsEnabled("feature", Context{"user_id": i})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected evaluation not to block on a full sink")
+	}
+
+	if dropped := store.DroppedEvaluations(); dropped != 3 {
+		t.Errorf("expected 3, got %d", dropped)
+	}
+
+	close(sink.release)
+	store.Close()
+	if sizes := sink.sizes(); len(sizes) != 2 || sizes[1] != 2 {
+		t.Errorf("expected batches [1 2], got %v", sizes)
+	}
+}
+
+func TestStore_DroppedEvaluations_WithoutSink(t *testing.T) {
+	if dropped := NewStore().DroppedEvaluations(); dropped != 0 {
+		t.Errorf("expected 0, got %d", dropped)
+	}
+}
@@ -0,0 +1,169 @@
+package toggo
+
+import (
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Snapshot is a point-in-time, read-only view of a store's flags.
+// Flags are immutable once added, so a snapshot is cheap to take and stays
+// consistent while the store keeps changing
+type Snapshot struct {
+	flags   map[string]*Flag
+	takenAt time.Time
+}
+
+// Snapshot captures the store's current base flags. Runtime overrides are not included
+func (s *Store) Snapshot() *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flags := make(map[string]*Flag, len(s.flags))
+	for name, flag := range s.flags {
+		flags[name] = flag
+	}
+	return &Snapshot{flags: flags, takenAt: s.clock()}
+}
+
+// Flag returns the named flag as it was when the snapshot was taken
+func (s *Snapshot) Flag(name string) (*Flag, bool) {
+	flag, ok := s.flags[name]
+	return flag, ok
+}
+
+// Names returns the flag names in the snapshot, sorted
+func (s *Snapshot) Names() []string {
+	names := make([]string, 0, len(s.flags))
+	for name := range s.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Len returns the number of flags in the snapshot
+func (s *Snapshot) Len() int {
+	return len(s.flags)
+}
+
+// TakenAt returns the store clock time when the snapshot was taken
+func (s *Snapshot) TakenAt() time.Time {
+	return s.takenAt
+}
+
+// ChangeKind describes what changed about a flag between two snapshots
+type ChangeKind string
+
+const (
+	// ChangeAdded means the flag only exists in the new snapshot
+	ChangeAdded ChangeKind = "added"
+
+	// ChangeRemoved means the flag only exists in the old snapshot
+	ChangeRemoved ChangeKind = "removed"
+
+	// ChangeUpdated means the flag was replaced by a different version.
+	// Subscribers get it for every update; Diff reports it for changes to
+	// fields not covered by the specific kinds below, e.g. variants or schedule
+	ChangeUpdated ChangeKind = "updated"
+
+	// ChangeEnabledToggled means the flag's Enabled field flipped
+	ChangeEnabledToggled ChangeKind = "enabled_toggled"
+
+	// ChangeRolloutChanged means the rollout percentage or ramp changed
+	ChangeRolloutChanged ChangeKind = "rollout_changed"
+
+	// ChangeConditionsChanged means the flag-level conditions changed
+	ChangeConditionsChanged ChangeKind = "conditions_changed"
+)
+
+// FlagChange is one difference between two snapshots
+type FlagChange struct {
+	// Flag is the name of the changed flag
+	Flag string `json:"flag"`
+
+	// Kind is what changed
+	Kind ChangeKind `json:"kind"`
+
+	// Old and New hold the changed value before and after: the flag itself
+	// for added, removed and updated, Enabled, Rollout or Conditions otherwise
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// Diff lists the changes from old to new, ordered by flag name.
+// A flag may produce several changes; nil snapshots are treated as empty
+func Diff(old, new *Snapshot) []FlagChange {
+	if old == nil {
+		old = &Snapshot{}
+	}
+	if new == nil {
+		new = &Snapshot{}
+	}
+
+	names := make(map[string]struct{}, len(old.flags)+len(new.flags))
+	for name := range old.flags {
+		names[name] = struct{}{}
+	}
+	for name := range new.flags {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var changes []FlagChange
+	for _, name := range sorted {
+		before, inOld := old.flags[name]
+		after, inNew := new.flags[name]
+
+		switch {
+		case !inOld:
+			changes = append(changes, FlagChange{Flag: name, Kind: ChangeAdded, New: after})
+		case !inNew:
+			changes = append(changes, FlagChange{Flag: name, Kind: ChangeRemoved, Old: before})
+		default:
+			changes = append(changes, diffFlag(before, after)...)
+		}
+	}
+	return changes
+}
+
+// diffFlag compares two versions of the same flag field by field. Enabled,
+// Rollout and Conditions get their own kinds; a change to any other field
+// is reported once as ChangeUpdated with both flags
+func diffFlag(before, after *Flag) []FlagChange {
+	var changes []FlagChange
+
+	if before.Enabled != after.Enabled {
+		changes = append(changes, FlagChange{Flag: after.Name, Kind: ChangeEnabledToggled, Old: before.Enabled, New: after.Enabled})
+	}
+	if before.Rollout != after.Rollout || !reflect.DeepEqual(before.Ramp, after.Ramp) {
+		changes = append(changes, FlagChange{Flag: after.Name, Kind: ChangeRolloutChanged, Old: before.Rollout, New: after.Rollout})
+	}
+	if !conditionsEqual(before.Conditions, after.Conditions) {
+		changes = append(changes, FlagChange{Flag: after.Name, Kind: ChangeConditionsChanged, Old: before.Conditions, New: after.Conditions})
+	}
+	if !reflect.DeepEqual(withoutDiffedFields(before), withoutDiffedFields(after)) {
+		changes = append(changes, FlagChange{Flag: after.Name, Kind: ChangeUpdated, Old: before, New: after})
+	}
+	return changes
+}
+
+// withoutDiffedFields returns a copy of the flag with the fields that have
+// their own change kinds cleared, for comparing everything else
+func withoutDiffedFields(flag *Flag) Flag {
+	rest := *flag
+	rest.Enabled, rest.Rollout, rest.Ramp, rest.Conditions = false, 0, nil, nil
+	return rest
+}
+
+// conditionsEqual compares condition lists, treating nil and empty as equal
+func conditionsEqual(a, b []Condition) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
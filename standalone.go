@@ -0,0 +1,48 @@
+package toggo
+
+import "time"
+
+// EvalOption configures a standalone Evaluate
+type EvalOption func(*evalConfig)
+
+type evalConfig struct {
+	storeOpts []StoreOption
+}
+
+// WithEvalClock sets the clock used for schedules, ramps and time
+// conditions. Defaults to time.Now
+func WithEvalClock(clock func() time.Time) EvalOption {
+	return func(c *evalConfig) {
+		c.storeOpts = append(c.storeOpts, WithClock(clock))
+	}
+}
+
+// WithEvalHasher sets the hasher used for rollout, variant and condition
+// bucketing, as NewStore(WithHasher(hasher)) would. Defaults to FNV-1a
+func WithEvalHasher(hasher Hasher) EvalOption {
+	return func(c *evalConfig) {
+		c.storeOpts = append(c.storeOpts, WithHasher(hasher))
+	}
+}
+
+// Evaluate evaluates a flag against ctx without adding it to a store, e.g. to
+// preview a candidate configuration. The flag is validated and prepared
+// exactly as AddFlag would, so the result matches a default store's
+// Store.Evaluate for the same flag and context. A flag that Extends another
+// cannot be resolved and returns ErrFlagNotFound
+func Evaluate(flag *Flag, ctx Context, opts ...EvalOption) (EvaluationResult, error) {
+	if flag == nil {
+		return EvaluationResult{}, ErrFlagNotFound
+	}
+
+	var config evalConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	store := NewStore(config.storeOpts...)
+	if err := store.AddFlag(flag); err != nil {
+		return EvaluationResult{}, err
+	}
+	return store.Evaluate(flag.Name, ctx)
+}
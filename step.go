@@ -0,0 +1,166 @@
+package toggo
+
+import (
+	"sync"
+	"time"
+)
+
+// Step is one stage of a stepped canary rollout
+type Step struct {
+	// Percent is the rollout percentage (0-100) once the step is reached
+	Percent int
+
+	// After is how long after the start the step is reached
+	After time.Duration
+}
+
+// StepRolloutStrategy raises the rollout percentage through fixed steps,
+// e.g. 10% after 1h, 25% after 2h, 50% after 4h and 100% after 8h. The
+// current percentage replaces flag.Rollout and users are bucketed with the
+// same deterministic hashing as DefaultRolloutStrategy, so users already
+// rolled out stay rolled out as the percentage grows.
+//
+// Pause freezes progression, e.g. from a WithSafeMode error callback, and
+// Resume continues from where it was paused
+type StepRolloutStrategy struct {
+	baseStrategy *DefaultRolloutStrategy
+	hasher       Hasher
+	clock        func() time.Time
+	steps        []Step
+	startTime    time.Time
+	timeProvider func() time.Time
+
+	mu       sync.Mutex
+	pausedAt time.Time
+	paused   bool
+	frozen   time.Duration // total time spent paused before the current pause
+}
+
+// StepOption configures a step rollout strategy
+type StepOption func(*StepRolloutStrategy)
+
+// WithStepClock sets the time source used to compute elapsed time. By
+// default a Store's clock (see WithClock) is used, or time.Now outside a Store
+func WithStepClock(clock func() time.Time) StepOption {
+	return func(s *StepRolloutStrategy) {
+		s.clock = clock
+	}
+}
+
+// WithStepHasher sets the hasher used for bucketing. By default a Store's
+// hasher (see WithHasher) is used, or FNV-1a outside a Store
+func WithStepHasher(hasher Hasher) StepOption {
+	return func(s *StepRolloutStrategy) {
+		s.hasher = hasher
+	}
+}
+
+// NewStepRolloutStrategy creates a step rollout strategy starting at start.
+// Steps are expected in ascending order of After
+func NewStepRolloutStrategy(start time.Time, steps []Step, opts ...StepOption) *StepRolloutStrategy {
+	s := &StepRolloutStrategy{
+		steps:        steps,
+		startTime:    start,
+		timeProvider: time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.clock != nil {
+		s.timeProvider = s.clock
+	}
+	s.baseStrategy = NewDefaultRolloutStrategy(s.hasher)
+
+	return s
+}
+
+// useStoreHasher buckets with the store's hasher unless WithStepHasher was given
+func (s *StepRolloutStrategy) useStoreHasher(hasher Hasher) {
+	if s.hasher == nil {
+		s.baseStrategy = NewDefaultRolloutStrategy(hasher)
+	}
+}
+
+// useStoreClock measures elapsed time with the store's clock unless
+// WithStepClock was given
+func (s *StepRolloutStrategy) useStoreClock(clock func() time.Time) {
+	if s.clock == nil {
+		s.timeProvider = clock
+	}
+}
+
+// CurrentPercent returns the rollout percentage of the latest step reached.
+// Before the first step it is 0
+func (s *StepRolloutStrategy) CurrentPercent() int {
+	elapsed := s.elapsed()
+
+	percent := 0
+	for _, step := range s.steps {
+		if elapsed < step.After {
+			break
+		}
+		percent = step.Percent
+	}
+	return percent
+}
+
+// Pause freezes progression at the current step
+func (s *StepRolloutStrategy) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		s.paused = true
+		s.pausedAt = s.timeProvider()
+	}
+}
+
+// Resume continues progression. Time spent paused does not count towards steps
+func (s *StepRolloutStrategy) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused {
+		s.paused = false
+		s.frozen += s.timeProvider().Sub(s.pausedAt)
+	}
+}
+
+// Paused reports whether progression is paused
+func (s *StepRolloutStrategy) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// elapsed returns the running time since start, excluding paused time
+func (s *StepRolloutStrategy) elapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timeProvider()
+	if s.paused {
+		now = s.pausedAt
+	}
+	return now.Sub(s.startTime) - s.frozen
+}
+
+// ShouldRollout applies the current step percentage with deterministic hashing
+func (s *StepRolloutStrategy) ShouldRollout(flag *Flag, ctx Context) (bool, error) {
+	stepped := *flag
+	stepped.Rollout = s.CurrentPercent()
+	return s.baseStrategy.ShouldRollout(&stepped, ctx)
+}
+
+// GetVariant uses standard weighted variant selection
+func (s *StepRolloutStrategy) GetVariant(flag *Flag, ctx Context) (string, error) {
+	return s.baseStrategy.GetVariant(flag, ctx)
+}
+
+// WithStepRollout is a StoreOption that configures a stepped canary rollout
+func WithStepRollout(start time.Time, steps []Step, opts ...StepOption) StoreOption {
+	return func(store *Store) {
+		store.rolloutStrategy = NewStepRolloutStrategy(start, steps, opts...)
+	}
+}
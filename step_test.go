@@ -0,0 +1,193 @@
+package toggo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStepRolloutStrategy_CurrentPercent(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	strategy := NewStepRolloutStrategy(start, []Step{
+		{Percent: 10, After: time.Hour},
+		{Percent: 25, After: 2 * time.Hour},
+		{Percent: 50, After: 4 * time.Hour},
+		{Percent: 100, After: 8 * time.Hour},
+	}, WithStepClock(func() time.Time { return now }))
+
+	tests := []struct {
+		name     string
+		elapsed  time.Duration
+		expected int
+	}{
+		{"at launch", 0, 0},
+		{"before first step", 59 * time.Minute, 0},
+		{"first step", time.Hour, 10},
+		{"second step", 3 * time.Hour, 25},
+		{"third step", 4 * time.Hour, 50},
+		{"final step", 8 * time.Hour, 100},
+		{"after final step", 48 * time.Hour, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = start.Add(tt.elapsed)
+			if percent := strategy.CurrentPercent(); percent != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, percent)
+			}
+		})
+	}
+}
+
+func TestStepRolloutStrategy_PauseResume(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(90 * time.Minute)
+	strategy := NewStepRolloutStrategy(start, []Step{
+		{Percent: 10, After: time.Hour},
+		{Percent: 25, After: 2 * time.Hour},
+	}, WithStepClock(func() time.Time { return now }))
+
+	strategy.Pause()
+	if !strategy.Paused() {
+		t.Error("expected strategy to be paused")
+	}
+
+	now = start.Add(5 * time.Hour)
+	if percent := strategy.CurrentPercent(); percent != 10 {
+		t.Errorf("expected paused progression to stay at 10, got %d", percent)
+	}
+
+	// 3.5h were spent paused, so the run clock resumes at 1.5h
+	strategy.Resume()
+	if percent := strategy.CurrentPercent(); percent != 10 {
+		t.Errorf("expected 10 right after resume, got %d", percent)
+	}
+
+	now = now.Add(30 * time.Minute)
+	if percent := strategy.CurrentPercent(); percent != 25 {
+		t.Errorf("expected 25 after another 30 minutes, got %d", percent)
+	}
+}
+
+func TestStepRolloutStrategy_Deterministic(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	store := NewStore(WithStepRollout(start, []Step{
+		{Percent: 10, After: time.Hour},
+		{Percent: 50, After: 2 * time.Hour},
+	}, WithStepClock(func() time.Time { return now })))
+	store.AddFlag(&Flag{Name: "canary", Enabled: true})
+
+	enabledAt := func() map[int]bool {
+		enabled := make(map[int]bool)
+		for i := 0; i < 1000; i++ {
+			if store.IsEnabled("canary", Context{"user_id": i}) {
+				enabled[i] = true
+			}
+		}
+		return enabled
+	}
+
+	if n := len(enabledAt()); n != 0 {
+		t.Errorf("expected no users before the first step, got %d", n)
+	}
+
+	now = start.Add(time.Hour)
+	first := enabledAt()
+	if len(first) < 50 || len(first) > 150 {
+		t.Errorf("expected ~100 users at 10%%, got %d", len(first))
+	}
+
+	now = start.Add(2 * time.Hour)
+	second := enabledAt()
+	if len(second) < 400 || len(second) > 600 {
+		t.Errorf("expected ~500 users at 50%%, got %d", len(second))
+	}
+	for user := range first {
+		if !second[user] {
+			t.Errorf("user %d dropped out when the rollout increased", user)
+		}
+	}
+}
+
+func TestStepRolloutStrategy_StoreHasher(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := WithStepClock(func() time.Time { return start.Add(time.Hour) })
+	steps := []Step{{Percent: 50, After: time.Hour}}
+
+	stepped := NewStore(WithHasher(NewMurmur3Hasher()), WithStepRollout(start, steps, clock))
+	reordered := NewStore(WithStepRollout(start, steps, clock), WithHasher(NewMurmur3Hasher()))
+	target := NewStore(WithHasher(NewMurmur3Hasher()))
+	fnv := NewStore(WithStepRollout(start, steps, clock, WithStepHasher(NewFNVHasher())), WithHasher(NewMurmur3Hasher()))
+	flag := &Flag{Name: "canary", Enabled: true, Rollout: 50}
+	for _, store := range []*Store{stepped, reordered, target, fnv} {
+		store.AddFlag(flag)
+	}
+
+	differs := false
+	for i := 0; i < 1000; i++ {
+		ctx := Context{"user_id": i}
+		expected := target.IsEnabled("canary", ctx)
+		if got := stepped.IsEnabled("canary", ctx); got != expected {
+			t.Fatalf("user %d: expected %v with the store hasher, got %v", i, expected, got)
+		}
+		if got := reordered.IsEnabled("canary", ctx); got != expected {
+			t.Fatalf("user %d: expected %v regardless of option order, got %v", i, expected, got)
+		}
+		if fnv.IsEnabled("canary", ctx) != expected {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Error("expected WithStepHasher to override the store hasher")
+	}
+}
+
+func TestStepRolloutStrategy_StoreClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	steps := []Step{
+		{Percent: 10, After: time.Hour},
+		{Percent: 100, After: 2 * time.Hour},
+	}
+	strategy := NewStepRolloutStrategy(start, steps)
+	store := NewStore(WithClock(func() time.Time { return now }), WithRolloutStrategy(strategy))
+	store.AddFlag(&Flag{Name: "canary", Enabled: true})
+
+	enabled := func() int {
+		count := 0
+		for i := 0; i < 1000; i++ {
+			if store.IsEnabled("canary", Context{"user_id": i}) {
+				count++
+			}
+		}
+		return count
+	}
+
+	if percent := strategy.CurrentPercent(); percent != 0 {
+		t.Errorf("expected 0 at launch, got %d", percent)
+	}
+	if n := enabled(); n != 0 {
+		t.Errorf("expected no users at launch, got %d", n)
+	}
+
+	now = start.Add(time.Hour)
+	if percent := strategy.CurrentPercent(); percent != 10 {
+		t.Errorf("expected 10 after advancing the store clock, got %d", percent)
+	}
+	if n := enabled(); n < 50 || n > 150 {
+		t.Errorf("expected ~100 users at 10%%, got %d", n)
+	}
+
+	now = start.Add(2 * time.Hour)
+	if n := enabled(); n != 1000 {
+		t.Errorf("expected every user at 100%%, got %d", n)
+	}
+
+	// An explicit step clock wins over the store clock
+	pinned := NewStepRolloutStrategy(start, steps, WithStepClock(func() time.Time { return start }))
+	NewStore(WithClock(func() time.Time { return now }), WithRolloutStrategy(pinned))
+	if percent := pinned.CurrentPercent(); percent != 0 {
+		t.Errorf("expected WithStepClock to override the store clock, got %d", percent)
+	}
+}
@@ -1,31 +1,234 @@
 package toggo
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Store manages feature flags and provides thread-safe evaluation
 type Store struct {
 	mu              sync.RWMutex
 	flags           map[string]*Flag
+	configs         map[string]*Flag
 	evaluator       *conditionEvaluator
 	rolloutStrategy RolloutStrategy
+	clock           func() time.Time
+	onError         func(flag string, err error)
+	hook            func(EvaluationRecord)
+	sink            *evaluationSink
+	cache           *evaluationCache
+	staticAttrs     Context
+	sets            map[string]StringSet
+	overrides       map[string]Override
+	readOnly        bool
+	timeout         time.Duration
+	randIntn        func(n int) int
+	sealed          bool
+	rolloutKey      string
+	foldCase        bool
+	metrics         Metrics
+	usage           *usageTracker
+	subs            subscriptions
+	contextHash     bool
+
+	// Background goroutine lifecycle, see Close
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
 }
 
 // StoreOption is a functional option for configuring the Store
 type StoreOption func(*Store)
 
+// WithClock sets the time source used for time-dependent evaluation such as ramps
+func WithClock(clock func() time.Time) StoreOption {
+	return func(s *Store) {
+		s.clock = clock
+	}
+}
+
+// WithHasher sets the hasher used for all bucketing: the default rollout
+// strategy, step rollouts, bucket and sample conditions, kill switches,
+// dimensions and ramp jitter. Defaults to FNV-1a
+func WithHasher(hasher Hasher) StoreOption {
+	return func(s *Store) {
+		if hasher != nil {
+			s.evaluator.hasher = hasher
+		}
+	}
+}
+
+// WithRolloutStrategy replaces the default percentage rollout strategy
+func WithRolloutStrategy(strategy RolloutStrategy) StoreOption {
+	return func(s *Store) {
+		s.rolloutStrategy = strategy
+	}
+}
+
+// WithSafeMode makes IsEnabled and GetVariant report evaluation errors to onError.
+// The caller still receives the safe default (disabled / DefaultVariant), so a
+// single malformed flag fails closed without breaking requests
+func WithSafeMode(onError func(flag string, err error)) StoreOption {
+	return func(s *Store) {
+		s.onError = onError
+	}
+}
+
+// WithEvaluationHook sets a callback invoked synchronously after every evaluation
+func WithEvaluationHook(hook func(EvaluationRecord)) StoreOption {
+	return func(s *Store) {
+		s.hook = hook
+	}
+}
+
+// WithEvaluationCache caches up to size evaluation results for ttl.
+// Results are keyed by flag name and the context attributes the flag
+// references, and are invalidated when the flag changes. Flags that read
+// the clock (schedules, ramps, TTL expiry, variant windows, time conditions)
+// are not cached, and neither is anything evaluated by a time-based rollout
+// strategy (switchback, step or dual-hash migration)
+func WithEvaluationCache(size int, ttl time.Duration) StoreOption {
+	return func(s *Store) {
+		s.cache = newEvaluationCache(size, ttl)
+	}
+}
+
+// WithStaticAttributes sets attributes such as deploy environment or build
+// version that are available to every evaluation. Per-call context values
+// take precedence over static attributes with the same key. The attributes
+// are copied, so later changes to attrs do not affect the store
+func WithStaticAttributes(attrs Context) StoreOption {
+	return func(s *Store) {
+		s.staticAttrs = make(Context, len(attrs))
+		for k, v := range attrs {
+			s.staticAttrs[k] = v
+		}
+	}
+}
+
+// WithStrictMode makes evaluation return errors for bad context data, such as
+// values that do not match a flag's attribute schema, instead of quietly
+// failing the condition
+func WithStrictMode() StoreOption {
+	return func(s *Store) {
+		s.evaluator.strict = true
+	}
+}
+
+// WithConditionReordering evaluates cheap conditions (equality, membership)
+// before expensive ones (regex, JSON path) so a failing cheap condition
+// short-circuits early. Since conditions are ANDed the match result is the
+// same in any order; an erroring condition may however be skipped when a
+// cheaper condition fails first
+func WithConditionReordering() StoreOption {
+	return func(s *Store) {
+		s.evaluator.reorder = true
+	}
+}
+
+// WithListAnyMatch makes equality and comparison operators (==, !=, >, >=,
+// <, <=) treat a list context value as multi-valued: the condition matches if
+// any element matches, e.g. roles ["admin", "editor"] == "editor". != matches
+// when no element equals the value. Without it a list is compared as a whole
+func WithListAnyMatch() StoreOption {
+	return func(s *Store) {
+		s.evaluator.anyMatch = true
+	}
+}
+
+// WithEvaluationTimeout bounds how long a single flag decision may take.
+// The deadline is checked before each condition, in the calling goroutine:
+// once it has passed no further conditions run, evaluation returns
+// ErrEvaluationTimeout and callers of IsEnabled and GetVariant get the safe
+// default. A condition already running is not interrupted
+func WithEvaluationTimeout(timeout time.Duration) StoreOption {
+	return func(s *Store) {
+		s.timeout = timeout
+	}
+}
+
+// WithRandom sets the randomness source for non-sticky flags, e.g. a seeded
+// rand.Rand in tests. Access to r is serialized by the store
+func WithRandom(r *rand.Rand) StoreOption {
+	return func(s *Store) {
+		var mu sync.Mutex
+		s.randIntn = func(n int) int {
+			mu.Lock()
+			defer mu.Unlock()
+			return r.Intn(n)
+		}
+	}
+}
+
+// WithReadOnly guards config-driven stores against runtime mutation. The store
+// accepts writes for its initial load until Seal is called; after that
+// AddFlag, AddFlags, ReplaceAll, Merge, RenameFlag, PatchFlag, SetOverride
+// and the Try variants TryRemoveFlag, TryClear, TryClearOverride and
+// TryRegisterSet return ErrReadOnly, while RemoveFlag, Clear, ClearOverride
+// and RegisterSet silently leave the store unchanged. Evaluation is unaffected
+func WithReadOnly() StoreOption {
+	return func(s *Store) {
+		s.readOnly = true
+	}
+}
+
+// WithDefaultRolloutKey sets the rollout key for flags that do not set
+// RolloutKey, e.g. "account_id" instead of the built-in "user_id". The
+// default is applied when a flag is added, so it is visible on GetFlag
+func WithDefaultRolloutKey(key string) StoreOption {
+	return func(s *Store) {
+		s.rolloutKey = key
+	}
+}
+
+// WithCaseInsensitiveAttributes matches context attribute keys regardless of
+// case, so a condition on "country" matches a context providing "Country".
+// Context keys and the attributes flags read are lowercased; flags are
+// stored with lowercased attributes, as visible on GetFlag
+func WithCaseInsensitiveAttributes() StoreOption {
+	return func(s *Store) {
+		s.foldCase = true
+	}
+}
+
 // NewStore creates a new feature flag store
 func NewStore(opts ...StoreOption) *Store {
+	ctx, cancel := context.WithCancel(context.Background())
 	store := &Store{
-		flags:           make(map[string]*Flag),
-		evaluator:       newConditionEvaluator(),
-		rolloutStrategy: NewDefaultRolloutStrategy(nil),
+		flags:     make(map[string]*Flag),
+		configs:   make(map[string]*Flag),
+		evaluator: newConditionEvaluator(),
+		clock:     time.Now,
+		randIntn:  rand.Intn,
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 
 	for _, opt := range opts {
 		opt(store)
 	}
+	store.evaluator.clock = store.clock
+	if store.rolloutStrategy == nil {
+		store.rolloutStrategy = NewDefaultRolloutStrategy(store.evaluator.hasher)
+	}
+	if strategy, ok := store.rolloutStrategy.(interface{ useStoreHasher(Hasher) }); ok {
+		strategy.useStoreHasher(store.evaluator.hasher)
+	}
+	if strategy, ok := store.rolloutStrategy.(interface{ useStoreClock(func() time.Time) }); ok {
+		strategy.useStoreClock(store.clock)
+	}
+	if store.sink != nil {
+		store.goBackground(store.sink.run)
+	}
 
 	return store
 }
@@ -36,16 +239,86 @@ func (s *Store) AddFlag(flag *Flag) error {
 		return err
 	}
 
+	var changes []FlagChange
+	defer func() { s.publish(changes) }()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.flags[flag.Name] = flag
-	return nil
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	var err error
+	changes, err = s.storeFlag(flag)
+	return err
+}
+
+// storeFlag resolves a validated flag against the store and stores it,
+// keeping a copy of the flag as given for PatchFlag, and returns the change.
+// Must be called with the store write lock held
+func (s *Store) storeFlag(flag *Flag) ([]FlagChange, error) {
+	resolved, err := s.resolveFlag(flag, s.flags)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := appendChange(nil, flag.Name, s.flags[flag.Name], resolved)
+	config := *flag
+	s.configs[flag.Name] = &config
+	s.flags[flag.Name] = resolved
+	s.invalidateCache(flag.Name)
+	return changes, nil
+}
+
+// resolveFlag applies inheritance from parents, registered sets and store
+// defaults to a validated flag, re-validating the result if it changed
+func (s *Store) resolveFlag(flag *Flag, parents map[string]*Flag) (*Flag, error) {
+	resolved, err := resolveInheritance(flag, parents)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err = prepareSets(resolved, s.sets); err != nil {
+		return nil, err
+	}
+	resolved = s.applyDefaults(resolved)
+	if resolved != flag {
+		if err := resolved.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// applyDefaults applies store-wide settings, the default rollout key and
+// case-insensitive attributes, to a flag being added, and resolves its
+// schedule timezone. The flag is copied if changed
+func (s *Store) applyDefaults(flag *Flag) *Flag {
+	if flag.Schedule != nil && flag.Schedule.loc == nil {
+		located := *flag
+		located.Schedule = flag.Schedule.withLocation()
+		flag = &located
+	}
+	if flag.RolloutKey == "" && s.rolloutKey != "" {
+		keyed := *flag
+		keyed.RolloutKey = s.rolloutKey
+		flag = &keyed
+	}
+	if s.foldCase {
+		flag = foldFlagCase(flag)
+	}
+	return flag
 }
 
 // AddFlags adds multiple flags to the store
+// Flags are added parents first so children may extend flags in the same batch
 func (s *Store) AddFlags(flags []*Flag) error {
-	for _, flag := range flags {
+	ordered, err := orderByInheritance(flags)
+	if err != nil {
+		return err
+	}
+
+	for _, flag := range ordered {
 		if err := s.AddFlag(flag); err != nil {
 			return err
 		}
@@ -53,12 +326,121 @@ func (s *Store) AddFlags(flags []*Flag) error {
 	return nil
 }
 
-// RemoveFlag removes a flag from the store
+// RemoveFlag removes a flag from the store. A sealed read-only store is left
+// unchanged; use TryRemoveFlag to detect that
 func (s *Store) RemoveFlag(name string) {
+	s.TryRemoveFlag(name)
+}
+
+// TryRemoveFlag is RemoveFlag returning ErrReadOnly on a sealed read-only store
+func (s *Store) TryRemoveFlag(name string) error {
+	var changes []FlagChange
+	defer func() { s.publish(changes) }()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	changes = appendChange(changes, name, s.flags[name], nil)
 	delete(s.flags, name)
+	delete(s.configs, name)
+	s.invalidateCache(name)
+	return nil
+}
+
+// RenameFlag atomically moves a flag to newName. Unless the flag already has
+// a Salt, its old name becomes the salt so every user keeps their rollout and
+// variant buckets. Flags extending it and its runtime override follow the rename.
+// Returns ErrFlagNotFound if oldName does not exist and ErrFlagExists if
+// newName does
+func (s *Store) RenameFlag(oldName, newName string) error {
+	var changes []FlagChange
+	defer func() { s.publish(changes) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	flag, ok := s.flags[oldName]
+	if !ok {
+		return ErrFlagNotFound
+	}
+	if oldName == newName {
+		return nil
+	}
+	if _, exists := s.flags[newName]; exists {
+		return fmt.Errorf("%w: %s", ErrFlagExists, newName)
+	}
+
+	renamed := *flag
+	renamed.Name = newName
+	if renamed.Salt == "" {
+		renamed.Salt = oldName
+	}
+	if err := renamed.Validate(); err != nil {
+		return err
+	}
+
+	config := *s.configs[oldName]
+	config.Name, config.Salt = renamed.Name, renamed.Salt
+
+	changes = appendChange(changes, oldName, flag, nil)
+	changes = appendChange(changes, newName, nil, &renamed)
+	delete(s.flags, oldName)
+	delete(s.configs, oldName)
+	s.flags[newName] = &renamed
+	s.configs[newName] = &config
+	for _, name := range sortedNames(s.flags) {
+		if child := s.flags[name]; child.Extends == oldName {
+			updated := *child
+			updated.Extends = newName
+			changes = appendChange(changes, name, child, &updated)
+			s.flags[name] = &updated
+
+			childConfig := *s.configs[name]
+			childConfig.Extends = newName
+			s.configs[name] = &childConfig
+		}
+	}
+	if override, ok := s.overrides[oldName]; ok {
+		delete(s.overrides, oldName)
+		s.overrides[newName] = override
+	}
+	s.invalidateCache(oldName)
+	s.invalidateCache(newName)
+	return nil
+}
+
+// Validate re-checks every flag in the store: each must pass Flag.Validate,
+// the parent named by Extends must still exist (RemoveFlag does not check for
+// children) and inheritance must not form a cycle. Flags are checked in name
+// order and the first problem is returned. Useful as a startup health check
+func (s *Store) Validate() error {
+	snapshot := s.Snapshot()
+	names := snapshot.Names()
+
+	flags := make([]*Flag, 0, len(names))
+	for _, name := range names {
+		flag, _ := snapshot.Flag(name)
+		if err := flag.Validate(); err != nil {
+			return err
+		}
+		if flag.Extends != "" {
+			if _, ok := snapshot.Flag(flag.Extends); !ok {
+				return fmt.Errorf("%w: parent %q of %q", ErrFlagNotFound, flag.Extends, flag.Name)
+			}
+		}
+		flags = append(flags, flag)
+	}
+
+	_, err := orderByInheritance(flags)
+	return err
 }
 
 // GetFlag retrieves a flag by name
@@ -74,6 +456,28 @@ func (s *Store) GetFlag(name string) (*Flag, error) {
 	return flag, nil
 }
 
+// GetFlagJSON returns a flag marshaled exactly as it serializes in JSON
+// configuration, or ErrFlagNotFound. The flag is serialized as it was added,
+// before inheritance, registered sets and store defaults were applied, so
+// the output can be loaded again as-is. Operators such as ">=" are not
+// HTML-escaped. Runtime overrides are not included
+func (s *Store) GetFlagJSON(name string) ([]byte, error) {
+	s.mu.RLock()
+	flag, ok := s.configs[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrFlagNotFound
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(flag); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
 // ListFlags returns all flag names
 func (s *Store) ListFlags() []string {
 	s.mu.RLock()
@@ -87,13 +491,109 @@ func (s *Store) ListFlags() []string {
 	return names
 }
 
+// ListFlagsWithPrefix returns the sorted names of flags starting with
+// prefix, e.g. "checkout." for a namespace
+func (s *Store) ListFlagsWithPrefix(prefix string) []string {
+	flags := s.flagsWithPrefix(prefix)
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExpiredFlags returns the sorted names of flags whose TTL has elapsed by
+// the store clock, for cleaning up stale temporary flags
+func (s *Store) ExpiredFlags() []string {
+	now := s.clock()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var names []string
+	for name, flag := range s.flags {
+		if flag.ExpiredAt(now) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// flagsWithPrefix collects the flags whose name starts with prefix under a single read lock
+func (s *Store) flagsWithPrefix(prefix string) map[string]*Flag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flags := make(map[string]*Flag)
+	for name, flag := range s.flags {
+		if strings.HasPrefix(name, prefix) {
+			flags[name] = flag
+		}
+	}
+	return flags
+}
+
+// FlagsUsingAttribute returns the sorted names of flags that read attr from
+// the context: in conditions (including variant conditions and templates), as
+// the rollout or seed key, or as a required, cohort or rollout attribute.
+// Useful to gauge the impact of changing how an attribute is populated
+func (s *Store) FlagsUsingAttribute(attr string) []string {
+	if s.foldCase {
+		attr = strings.ToLower(attr)
+	}
+
+	snapshot := s.Snapshot()
+	var names []string
+	for _, name := range snapshot.Names() {
+		flag, _ := snapshot.Flag(name)
+		attrs := referencedAttributes(flag)
+		if i := sort.SearchStrings(attrs, attr); i < len(attrs) && attrs[i] == attr {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 // IsEnabled checks if a feature flag is enabled for the given context
 // This is the primary method for simple on/off feature flags
 func (s *Store) IsEnabled(name string, ctx Context) bool {
-	result, _ := s.IsEnabledWithError(name, ctx)
+	result, err := s.IsEnabledWithError(name, ctx)
+	if err != nil {
+		s.reportError(name, err)
+		return false
+	}
 	return result
 }
 
+// IsEnabledAny reports whether the flag is enabled for at least one of the
+// contexts, e.g. any profile on a shared device. Contexts are evaluated in
+// order until one is enabled; no contexts means not enabled
+func (s *Store) IsEnabledAny(name string, ctxs ...Context) bool {
+	for _, ctx := range ctxs {
+		if s.IsEnabled(name, ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEnabledAll reports whether the flag is enabled for every one of the
+// contexts. Evaluation stops at the first disabled context; no contexts
+// means not enabled
+func (s *Store) IsEnabledAll(name string, ctxs ...Context) bool {
+	if len(ctxs) == 0 {
+		return false
+	}
+	for _, ctx := range ctxs {
+		if !s.IsEnabled(name, ctx) {
+			return false
+		}
+	}
+	return true
+}
+
 // IsEnabledWithError checks if a feature flag is enabled and returns any error
 func (s *Store) IsEnabledWithError(name string, ctx Context) (bool, error) {
 	flag, err := s.GetFlag(name)
@@ -101,42 +601,69 @@ func (s *Store) IsEnabledWithError(name string, ctx Context) (bool, error) {
 		return false, err
 	}
 
-	// If flag is disabled, return false immediately
-	if !flag.Enabled {
-		return false, nil
-	}
-
 	// If flag has variants, IsEnabled should return false
 	// User should use GetVariant instead
-	if flag.HasVariants() {
+	if flag.Enabled && flag.HasVariants() {
 		return false, nil
 	}
 
-	// Evaluate all conditions
-	match, err := s.evaluator.evaluateAll(flag.Conditions, ctx)
+	result, err := s.evaluateFlag(flag, ctx)
 	if err != nil {
 		return false, err
 	}
 
-	// If conditions don't match, return false
-	if !match {
-		return false, nil
+	return result.Enabled, nil
+}
+
+// GetVariant returns the variant for A/B testing
+// Returns the variant name and whether the flag is enabled
+func (s *Store) GetVariant(name string, ctx Context) (string, bool) {
+	variant, enabled, err := s.GetVariantWithError(name, ctx)
+	if err != nil && s.reportError(name, err) {
+		if flag, err := s.GetFlag(name); err == nil {
+			return flag.DefaultVariant, false
+		}
 	}
+	return variant, enabled
+}
 
-	// Apply rollout strategy
-	shouldRollout, err := s.rolloutStrategy.ShouldRollout(flag, ctx)
+// GetVariantAndPayload returns the variant, its JSON-encoded payload and whether
+// the flag is enabled. Both come from a single evaluation, so the payload always
+// belongs to the returned variant. The payload is nil when the variant has none
+func (s *Store) GetVariantAndPayload(name string, ctx Context) (string, json.RawMessage, bool) {
+	flag, err := s.GetFlag(name)
 	if err != nil {
-		return false, err
+		return "", nil, false
+	}
+
+	result, err := s.evaluateFlag(flag, ctx)
+	if err != nil {
+		s.reportError(name, err)
+		return flag.DefaultVariant, nil, false
+	}
+
+	variant, ok := flag.GetVariantByName(result.Variant)
+	if !ok || variant.Payload == nil {
+		return result.Variant, nil, result.Enabled
 	}
 
-	return shouldRollout, nil
+	payload, err := json.Marshal(variant.Payload)
+	if err != nil {
+		s.reportError(name, err)
+		return result.Variant, nil, result.Enabled
+	}
+	return result.Variant, payload, result.Enabled
 }
 
-// GetVariant returns the variant for A/B testing
-// Returns the variant name and whether the flag is enabled
-func (s *Store) GetVariant(name string, ctx Context) (string, bool) {
-	variant, enabled, _ := s.GetVariantWithError(name, ctx)
-	return variant, enabled
+// reportError passes an evaluation error to the safe mode callback, if any.
+// Unknown flags are not evaluation errors and are not reported.
+// Returns true if the error was reported
+func (s *Store) reportError(name string, err error) bool {
+	if s.onError == nil || err == ErrFlagNotFound {
+		return false
+	}
+	s.onError(name, err)
+	return true
 }
 
 // GetVariantWithError returns the variant with detailed error information
@@ -146,67 +673,217 @@ func (s *Store) GetVariantWithError(name string, ctx Context) (string, bool, err
 		return "", false, err
 	}
 
-	// If flag is disabled, return default variant
-	if !flag.Enabled {
-		return flag.DefaultVariant, false, nil
+	result, err := s.evaluateFlag(flag, ctx)
+	if err != nil {
+		return "", false, err
 	}
 
-	// Evaluate global flag conditions
-	match, err := s.evaluator.evaluateAll(flag.Conditions, ctx)
+	return result.Variant, result.Enabled, nil
+}
+
+// EffectiveRollout returns the rollout percentage currently applied to a flag,
+// taking its override into account. For a plain flag this is flag.Rollout;
+// for a ramping flag it is the interpolated value at the store clock's
+// current time, and under WithStepRollout it is the current step's
+// percentage. A flag that is disabled, expired or outside its schedule
+// reports 0
+func (s *Store) EffectiveRollout(name string) (int, error) {
+	flag, err := s.GetFlag(name)
 	if err != nil {
-		return "", false, err
+		return 0, err
+	}
+	flag, _, _ = s.applyOverride(flag)
+
+	now := s.clock()
+	if !flag.Enabled || flag.ExpiredAt(now) || (flag.Schedule != nil && !flag.Schedule.ActiveAt(now)) {
+		return 0, nil
+	}
+	if strategy, ok := s.rolloutStrategy.(*StepRolloutStrategy); ok {
+		return strategy.CurrentPercent(), nil
 	}
+	return s.effectiveRollout(flag, nil), nil
+}
 
-	// If global conditions don't match, return default variant
-	if !match {
-		return flag.DefaultVariant, false, nil
+// VariantAllocation returns the percentage of traffic each variant receives
+// under weighted selection. Weights are absolute, so the remainder when they
+// sum to less than 100 is reported under DefaultVariant (added to it when it
+// is itself a variant, under "" when the flag has none). Simple flags report
+// their effective rollout as "on" and the rest as "off". Overrides apply as
+// in evaluation: a disabled flag sends all traffic to DefaultVariant (or
+// "off") and a forced variant receives all of it. Variants outside their
+// window at the store clock's current time are excluded as in evaluation.
+// Flag-level and variant conditions are not taken into account
+func (s *Store) VariantAllocation(name string) (map[string]float64, error) {
+	flag, err := s.GetFlag(name)
+	if err != nil {
+		return nil, err
 	}
+	flag, override, overridden := s.applyOverride(flag)
 
-	// If no variants configured, this is a simple on/off flag
 	if !flag.HasVariants() {
-		// Apply rollout
-		shouldRollout, err := s.rolloutStrategy.ShouldRollout(flag, ctx)
-		if err != nil {
-			return "", false, err
-		}
-		if shouldRollout {
-			return "on", true, nil
+		rollout := 0.0
+		if flag.Enabled {
+			rollout = float64(s.effectiveRollout(flag, nil))
 		}
-		return "off", false, nil
+		return map[string]float64{"on": rollout, "off": 100 - rollout}, nil
 	}
+	if !flag.Enabled {
+		return map[string]float64{flag.DefaultVariant: 100}, nil
+	}
+	if overridden && override.Variant != "" {
+		return map[string]float64{override.Variant: 100}, nil
+	}
+	flag = withActiveVariants(flag, s.clock())
+
+	allocation := make(map[string]float64, len(flag.Variants)+1)
+	total := 0
+	for _, variant := range flag.Variants {
+		allocation[variant.Name] += float64(variant.Weight)
+		total += variant.Weight
+	}
+	if total < 100 {
+		allocation[flag.DefaultVariant] += float64(100 - total)
+	}
+	return allocation, nil
+}
 
-	// Get variant based on rollout strategy
-	variantName, err := s.rolloutStrategy.GetVariant(flag, ctx)
+// VariantForBucket returns the variant that weighted assignment gives to a
+// 0-99 bucket under the flag's current configuration, independent of any
+// user, e.g. to preview the allocation map. Variants outside their window
+// are excluded as in evaluation, and buckets beyond the total weight return
+// DefaultVariant. Conditions and custom rollout strategies are not applied
+func (s *Store) VariantForBucket(name string, bucket int) (string, error) {
+	if !validBucket(bucket) {
+		return "", fmt.Errorf("%w: bucket %d is not between 0 and 99", ErrInvalidRollout, bucket)
+	}
+	flag, err := s.GetFlag(name)
 	if err != nil {
-		return "", false, err
+		return "", err
+	}
+	if !flag.HasVariants() {
+		return flag.DefaultVariant, nil
 	}
+	return variantAtBucket(withActiveVariants(flag, s.clock()), bucket), nil
+}
 
-	// Find the variant and check its conditions
-	for _, variant := range flag.Variants {
-		if variant.Name == variantName {
-			// Evaluate variant-specific conditions if any
-			if len(variant.Conditions) > 0 {
-				match, err := s.evaluator.evaluateAll(variant.Conditions, ctx)
-				if err != nil {
-					return "", false, err
-				}
-				if !match {
-					return flag.DefaultVariant, false, nil
-				}
-			}
-			return variant.Name, true, nil
+// effectiveRollout computes the rollout percentage in effect for a flag.
+// Cohort and attribute rollouts only apply when a context is given
+func (s *Store) effectiveRollout(flag *Flag, ctx Context) int {
+	if flag.CohortRollout != nil && ctx != nil {
+		if rollout, ok := flag.CohortRollout.RolloutFor(ctx); ok {
+			return rollout
 		}
 	}
+	if rollout, ok := attributeRollout(flag, ctx); ok {
+		return rollout
+	}
+	if flag.Ramp != nil {
+		return flag.Ramp.RolloutAt(s.clock().Add(-s.rampJitter(flag, ctx)))
+	}
+	return flag.Rollout
+}
+
+// attributeRollout reads the rollout percentage from the flag's
+// RolloutFromAttribute, reporting false when it is unset, missing or invalid
+func attributeRollout(flag *Flag, ctx Context) (int, bool) {
+	if flag.RolloutFromAttribute == "" || ctx == nil {
+		return 0, false
+	}
+	value, exists := ctx.Get(flag.RolloutFromAttribute)
+	if !exists {
+		return 0, false
+	}
+	rollout, err := toFloat64(value)
+	if err != nil || rollout < 0 || rollout > 100 {
+		return 0, false
+	}
+	return int(rollout), true
+}
+
+// rampJitter returns how far behind the ramp this context runs: a
+// deterministic fraction of the flag's RampJitter based on the rollout key
+func (s *Store) rampJitter(flag *Flag, ctx Context) time.Duration {
+	if flag.RampJitter <= 0 || ctx == nil {
+		return 0
+	}
+	keyValue, exists := flag.rolloutKeyValue(ctx)
+	if !exists {
+		return 0
+	}
+	bucket := s.evaluator.hasher.Hash(fmt.Sprintf("%s:jitter:%s", flag.hashSalt(), fmt.Sprint(keyValue)))
+	return time.Duration(flag.RampJitter) * time.Duration(bucket) / 100
+}
 
-	return flag.DefaultVariant, false, nil
+// withEffectiveRollout returns the flag to hand to the rollout strategy.
+// Flags whose rollout is computed are shallow-copied with Rollout set to the
+// effective value so strategies keep reading flag.Rollout
+func (s *Store) withEffectiveRollout(flag *Flag, ctx Context) *Flag {
+	rollout := s.effectiveRollout(flag, ctx)
+	if rollout == flag.Rollout {
+		return flag
+	}
+	effective := *flag
+	effective.Rollout = rollout
+	return &effective
 }
 
-// Clear removes all flags from the store
+// Clear removes all flags from the store. A sealed read-only store is left
+// unchanged; use TryClear to detect that
 func (s *Store) Clear() {
+	s.TryClear()
+}
+
+// TryClear is Clear returning ErrReadOnly on a sealed read-only store
+func (s *Store) TryClear() error {
+	var changes []FlagChange
+	defer func() { s.publish(changes) }()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	for _, name := range sortedNames(s.flags) {
+		changes = appendChange(changes, name, s.flags[name], nil)
+	}
+
 	s.flags = make(map[string]*Flag)
+	s.configs = make(map[string]*Flag)
+	if s.cache != nil {
+		s.cache.clear()
+	}
+	return nil
+}
+
+// Seal ends the initial load of a store created with WithReadOnly, e.g. after
+// a loader's LoadIntoStore. Later mutations leave the store unchanged: those
+// with an error result return ErrReadOnly, while RemoveFlag, Clear,
+// ClearOverride and RegisterSet do nothing silently (their Try variants
+// return ErrReadOnly). Sealing cannot be undone, has no effect on other
+// stores and is a no-op for stores created without WithReadOnly
+func (s *Store) Seal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sealed = true
+}
+
+// checkWritable returns ErrReadOnly once a read-only store has been sealed.
+// Must be called with the store lock held
+func (s *Store) checkWritable() error {
+	if s.readOnly && s.sealed {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// invalidateCache drops cached results for a flag, if caching is enabled
+func (s *Store) invalidateCache(name string) {
+	if s.cache != nil {
+		s.cache.invalidate(name)
+	}
 }
 
 // Size returns the number of flags in the store
@@ -222,3 +899,28 @@ func (s *Store) Size() int {
 func (s *Store) GetRolloutStrategy() RolloutStrategy {
 	return s.rolloutStrategy
 }
+
+// goBackground runs fn in a goroutine tied to the store lifecycle.
+// The context passed to fn is cancelled by Close, which then waits for fn to return
+func (s *Store) goBackground(fn func(ctx context.Context)) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		fn(s.ctx)
+	}()
+}
+
+// Close stops any background goroutines started by the store and waits for
+// them to exit. If the rollout strategy implements io.Closer it is closed too.
+// Evaluation keeps working after Close; it is safe to call Close more than once
+func (s *Store) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.cancel()
+		s.wg.Wait()
+		if closer, ok := s.rolloutStrategy.(io.Closer); ok {
+			err = closer.Close()
+		}
+	})
+	return err
+}
@@ -1,7 +1,16 @@
 package toggo
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestStore_AddFlag(t *testing.T) {
@@ -321,3 +330,3716 @@ func TestStore_ThreadSafety(t *testing.T) {
 		<-done
 	}
 }
+
+func TestStore_GetVariant_GatedDefaultVariant(t *testing.T) {
+	store := NewStore()
+
+	flag := &Flag{
+		Name:           "gated_default",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Variants: []Variant{
+			{
+				Name:   "treatment",
+				Weight: 100,
+				Conditions: []Condition{
+					{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+				},
+			},
+			{
+				Name:   "control",
+				Weight: 0,
+				Conditions: []Condition{
+					{Attribute: "plan", Operator: OperatorEqual, Value: "premium"},
+				},
+			},
+		},
+	}
+
+	if err := store.AddFlag(flag); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		ctx             Context
+		expectedVariant string
+		expectedEnabled bool
+	}{
+		{
+			name:            "selected variant eligible",
+			ctx:             Context{"user_id": "1", "country": "US", "plan": "basic"},
+			expectedVariant: "treatment",
+			expectedEnabled: true,
+		},
+		{
+			name:            "default variant granted",
+			ctx:             Context{"user_id": "1", "country": "DE", "plan": "premium"},
+			expectedVariant: "control",
+			expectedEnabled: true,
+		},
+		{
+			name:            "default variant denied",
+			ctx:             Context{"user_id": "1", "country": "DE", "plan": "basic"},
+			expectedVariant: "",
+			expectedEnabled: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, enabled := store.GetVariant("gated_default", tt.ctx)
+			if variant != tt.expectedVariant {
+				t.Errorf("expected variant %q, got %q", tt.expectedVariant, variant)
+			}
+			if enabled != tt.expectedEnabled {
+				t.Errorf("expected enabled %v, got %v", tt.expectedEnabled, enabled)
+			}
+		})
+	}
+}
+
+func TestStore_GetVariant_PlainDefaultVariant(t *testing.T) {
+	store := NewStore()
+
+	flag := &Flag{
+		Name:    "plain_default",
+		Enabled: true,
+		Variants: []Variant{
+			{
+				Name:   "treatment",
+				Weight: 100,
+				Conditions: []Condition{
+					{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+				},
+			},
+		},
+	}
+
+	// A default naming no variant is rejected rather than used as a plain fallback
+	dangling := *flag
+	dangling.DefaultVariant = "off"
+	if err := store.AddFlag(&dangling); !errors.Is(err, ErrInvalidVariant) {
+		t.Fatalf("expected ErrInvalidVariant, got %v", err)
+	}
+
+	if err := store.AddFlag(flag); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	variant, enabled := store.GetVariant("plain_default", Context{"user_id": "1", "country": "DE"})
+	if variant != "" || enabled {
+		t.Errorf("expected (\"\", false), got (%s, %v)", variant, enabled)
+	}
+	result, _ := store.Evaluate("plain_default", Context{"user_id": "1", "country": "DE"})
+	if result.Reason != ReasonDefaultVariant {
+		t.Errorf("expected reason %s, got %s", ReasonDefaultVariant, result.Reason)
+	}
+}
+
+func TestFlag_Validate_Variants(t *testing.T) {
+	tests := []struct {
+		name  string
+		flag  *Flag
+		field string
+	}{
+		{
+			name: "duplicate variant names",
+			flag: &Flag{
+				Name:     "duplicate",
+				Enabled:  true,
+				Variants: []Variant{{Name: "control", Weight: 50}, {Name: "control", Weight: 50}},
+			},
+			field: "variants[1].name",
+		},
+		{
+			name: "dangling default variant",
+			flag: &Flag{
+				Name:           "dangling",
+				Enabled:        true,
+				DefaultVariant: "off",
+				Variants:       []Variant{{Name: "treatment", Weight: 100}},
+			},
+			field: "default_variant",
+		},
+		{
+			name: "empty variant name",
+			flag: &Flag{
+				Name:     "unnamed",
+				Enabled:  true,
+				Variants: []Variant{{Weight: 100}},
+			},
+			field: "variants[0].name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.flag.Validate()
+			if !errors.Is(err, ErrInvalidVariant) {
+				t.Fatalf("expected ErrInvalidVariant, got %v", err)
+			}
+			var validationErr *FlagValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != tt.field {
+				t.Errorf("expected field %s, got %v", tt.field, err)
+			}
+		})
+	}
+
+	valid := []*Flag{
+		{Name: "no_default", Enabled: true, Variants: []Variant{{Name: "a", Weight: 100}}},
+		{Name: "simple_default", Enabled: true, Rollout: 100, DefaultVariant: "off"},
+	}
+	for _, flag := range valid {
+		if err := flag.Validate(); err != nil {
+			t.Errorf("expected %s to be valid, got %v", flag.Name, err)
+		}
+	}
+}
+
+// closableStrategy is a rollout strategy that records whether it was closed
+type closableStrategy struct {
+	*DefaultRolloutStrategy
+	closed bool
+}
+
+func (c *closableStrategy) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestStore_Close(t *testing.T) {
+	strategy := &closableStrategy{DefaultRolloutStrategy: NewDefaultRolloutStrategy(nil)}
+	store := NewStore(func(s *Store) { s.rolloutStrategy = strategy })
+
+	exited := make(chan struct{})
+	store.goBackground(func(ctx context.Context) {
+		<-ctx.Done()
+		close(exited)
+	})
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-exited:
+	default:
+		t.Error("expected background goroutine to exit before Close returned")
+	}
+
+	if !strategy.closed {
+		t.Error("expected rollout strategy to be closed")
+	}
+
+	// Closing twice is safe
+	if err := store.Close(); err != nil {
+		t.Fatalf("unexpected error on second Close: %v", err)
+	}
+}
+
+func TestStore_EffectiveRollout(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(6 * time.Hour)
+	store := NewStore(WithClock(func() time.Time { return now }))
+
+	store.AddFlag(&Flag{Name: "static", Enabled: true, Rollout: 40})
+	store.AddFlag(&Flag{
+		Name:    "ramping",
+		Enabled: true,
+		Ramp: &Ramp{
+			From:  0,
+			To:    80,
+			Start: start,
+			End:   start.Add(12 * time.Hour),
+		},
+	})
+
+	rollout, err := store.EffectiveRollout("static")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rollout != 40 {
+		t.Errorf("expected static rollout 40, got %d", rollout)
+	}
+
+	rollout, err = store.EffectiveRollout("ramping")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rollout != 40 {
+		t.Errorf("expected mid-ramp rollout 40, got %d", rollout)
+	}
+
+	now = start.Add(24 * time.Hour)
+	if rollout, _ := store.EffectiveRollout("ramping"); rollout != 80 {
+		t.Errorf("expected completed ramp rollout 80, got %d", rollout)
+	}
+
+	// Overrides replace the ramp, and a disabled flag rolls out to no one
+	override := 15
+	store.SetOverride("ramping", Override{Rollout: &override})
+	if rollout, _ := store.EffectiveRollout("ramping"); rollout != 15 {
+		t.Errorf("expected overridden rollout 15, got %d", rollout)
+	}
+	disabled := false
+	store.SetOverride("static", Override{Enabled: &disabled})
+	if rollout, _ := store.EffectiveRollout("static"); rollout != 0 {
+		t.Errorf("expected disabled flag rollout 0, got %d", rollout)
+	}
+
+	if _, err := store.EffectiveRollout("missing"); err != ErrFlagNotFound {
+		t.Errorf("expected ErrFlagNotFound, got %v", err)
+	}
+}
+
+func TestStore_EffectiveRolloutInactive(t *testing.T) {
+	// Monday 2024-01-01 10:00 UTC
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	created := now.Add(-48 * time.Hour)
+	store := NewStore(WithClock(func() time.Time { return now }))
+
+	store.AddFlags([]*Flag{
+		{Name: "business_hours", Enabled: true, Rollout: 40, Schedule: &Schedule{
+			Windows: []ScheduleWindow{{Start: "09:00", End: "17:00"}},
+		}},
+		{Name: "holiday_banner", Enabled: true, Rollout: 40, TTL: Duration(24 * time.Hour), CreatedAt: &created},
+	})
+
+	if rollout, _ := store.EffectiveRollout("business_hours"); rollout != 40 {
+		t.Errorf("expected 40 inside the schedule, got %d", rollout)
+	}
+	now = now.Add(8 * time.Hour)
+	if rollout, _ := store.EffectiveRollout("business_hours"); rollout != 0 {
+		t.Errorf("expected 0 outside the schedule, got %d", rollout)
+	}
+	if rollout, _ := store.EffectiveRollout("holiday_banner"); rollout != 0 {
+		t.Errorf("expected 0 once the TTL has elapsed, got %d", rollout)
+	}
+}
+
+func TestStore_EffectiveRolloutStep(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(90 * time.Minute)
+	store := NewStore(
+		WithClock(func() time.Time { return now }),
+		WithStepRollout(start, []Step{{Percent: 10, After: time.Hour}, {Percent: 50, After: 2 * time.Hour}}),
+	)
+	store.AddFlag(&Flag{Name: "canary", Enabled: true, Rollout: 100})
+
+	if rollout, _ := store.EffectiveRollout("canary"); rollout != 10 {
+		t.Errorf("expected the current step's 10, got %d", rollout)
+	}
+	now = start.Add(2 * time.Hour)
+	if rollout, _ := store.EffectiveRollout("canary"); rollout != 50 {
+		t.Errorf("expected the current step's 50, got %d", rollout)
+	}
+}
+
+func TestRamp_RolloutAtLongRamp(t *testing.T) {
+	ramp := &Ramp{
+		From:  0,
+		To:    100,
+		Start: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		at       time.Time
+		expected int
+	}{
+		{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 50},
+		{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 60},
+		{time.Date(2029, 12, 31, 0, 0, 0, 0, time.UTC), 99},
+	}
+	for _, tt := range tests {
+		if rollout := ramp.RolloutAt(tt.at); rollout != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.at.Format("2006-01-02"), tt.expected, rollout)
+		}
+	}
+}
+
+func TestStore_IsEnabled_Ramp(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	store := NewStore(WithClock(func() time.Time { return now }))
+
+	store.AddFlag(&Flag{
+		Name:    "ramping",
+		Enabled: true,
+		Ramp:    &Ramp{From: 0, To: 100, Start: start, End: start.Add(time.Hour)},
+	})
+
+	countEnabled := func() int {
+		count := 0
+		for i := 0; i < 1000; i++ {
+			if store.IsEnabled("ramping", Context{"user_id": i}) {
+				count++
+			}
+		}
+		return count
+	}
+
+	if count := countEnabled(); count != 0 {
+		t.Errorf("expected no users enabled at ramp start, got %d", count)
+	}
+
+	now = start.Add(30 * time.Minute)
+	if count := countEnabled(); count < 400 || count > 600 {
+		t.Errorf("expected roughly half of users enabled mid-ramp, got %d/1000", count)
+	}
+
+	now = start.Add(time.Hour)
+	if count := countEnabled(); count != 1000 {
+		t.Errorf("expected all users enabled after ramp, got %d", count)
+	}
+}
+
+func TestStore_SafeMode(t *testing.T) {
+	var reported []string
+	store := NewStore(WithSafeMode(func(flag string, err error) {
+		reported = append(reported, flag)
+	}))
+
+	badRegex := []Condition{{Attribute: "email", Operator: OperatorRegex, Value: "("}}
+	store.AddFlag(&Flag{Name: "bad_flag", Enabled: true, Rollout: 100, Conditions: badRegex})
+	store.AddFlag(&Flag{
+		Name:           "bad_variant",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Conditions:     badRegex,
+		Variants:       []Variant{{Name: "treatment", Weight: 100}, {Name: "control", Weight: 0}},
+	})
+
+	ctx := Context{"user_id": "1", "email": "user@example.com"}
+
+	if store.IsEnabled("bad_flag", ctx) {
+		t.Error("expected erroring flag to fail closed")
+	}
+
+	variant, enabled := store.GetVariant("bad_variant", ctx)
+	if variant != "control" || enabled {
+		t.Errorf("expected safe default (control, false), got (%s, %v)", variant, enabled)
+	}
+
+	// Unknown flags are not evaluation errors
+	store.IsEnabled("missing", ctx)
+
+	if len(reported) != 2 || reported[0] != "bad_flag" || reported[1] != "bad_variant" {
+		t.Errorf("expected errors reported for bad_flag and bad_variant, got %v", reported)
+	}
+}
+
+func TestStore_GetVariant_WeightCaps(t *testing.T) {
+	store := NewStore()
+
+	flag := &Flag{
+		Name:           "capped_experiment",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Variants: []Variant{
+			{Name: "control", Weight: 0},
+			{Name: "variant_b", Weight: 10},
+		},
+	}
+	store.AddFlag(flag)
+
+	counts := make(map[string]int)
+	exposedAt10 := make(map[int]bool)
+	for i := 0; i < 10000; i++ {
+		variant, _ := store.GetVariant("capped_experiment", Context{"user_id": i})
+		counts[variant]++
+		if variant == "variant_b" {
+			exposedAt10[i] = true
+		}
+	}
+
+	if counts["variant_b"] < 800 || counts["variant_b"] > 1200 {
+		t.Errorf("expected roughly 10%% exposure to variant_b, got %d/10000", counts["variant_b"])
+	}
+	if counts["variant_b"]+counts["control"] != 10000 {
+		t.Errorf("expected remainder to go to control, got %v", counts)
+	}
+
+	// Raising the cap only adds users to variant_b
+	flag.Variants[1].Weight = 20
+	raised := 0
+	for i := 0; i < 10000; i++ {
+		variant, _ := store.GetVariant("capped_experiment", Context{"user_id": i})
+		if variant == "variant_b" {
+			raised++
+		} else if exposedAt10[i] {
+			t.Fatalf("user %d left variant_b when the cap was raised", i)
+		}
+	}
+	if raised < 1700 || raised > 2300 {
+		t.Errorf("expected roughly 20%% exposure after raising the cap, got %d/10000", raised)
+	}
+}
+
+func TestStore_EvaluateJSON(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlag(&Flag{
+		Name:           "checkout_experiment",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Variants:       []Variant{{Name: "treatment", Weight: 100}, {Name: "control", Weight: 0}},
+	})
+
+	data, err := store.EvaluateJSON("checkout_experiment", Context{"user_id": "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"flag":"checkout_experiment","enabled":true,"variant":"treatment","reason":"variant_selected"}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+
+	if _, err := store.EvaluateJSON("missing", Context{}); err != ErrFlagNotFound {
+		t.Errorf("expected ErrFlagNotFound, got %v", err)
+	}
+}
+
+func TestStore_Evaluate_Reasons(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlag(&Flag{Name: "off", Enabled: false, Rollout: 100})
+	store.AddFlag(&Flag{Name: "none", Enabled: true, Rollout: 0})
+	store.AddFlag(&Flag{Name: "all", Enabled: true, Rollout: 100})
+	store.AddFlag(&Flag{
+		Name:       "us_only",
+		Enabled:    true,
+		Rollout:    100,
+		Conditions: []Condition{{Attribute: "country", Operator: OperatorEqual, Value: "US"}},
+	})
+
+	tests := []struct {
+		flag     string
+		expected Reason
+	}{
+		{flag: "off", expected: ReasonDisabled},
+		{flag: "none", expected: ReasonRolloutExcluded},
+		{flag: "all", expected: ReasonRolloutIncluded},
+		{flag: "us_only", expected: ReasonConditionsNotMet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			result, err := store.Evaluate(tt.flag, Context{"user_id": "1", "country": "DE"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Reason != tt.expected {
+				t.Errorf("expected reason %s, got %s", tt.expected, result.Reason)
+			}
+		})
+	}
+}
+
+func TestStore_AttributeSchema(t *testing.T) {
+	flag := &Flag{
+		Name:            "adults_only",
+		Enabled:         true,
+		Rollout:         100,
+		AttributeSchema: map[string]string{"age": AttributeTypeNumber},
+		Conditions: []Condition{
+			{Attribute: "age", Operator: OperatorGreaterThan, Value: 18},
+		},
+	}
+
+	store := NewStore()
+	if err := store.AddFlag(flag); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		age      interface{}
+		expected bool
+	}{
+		{name: "number", age: 25, expected: true},
+		{name: "numeric string coerced", age: "25", expected: true},
+		{name: "non-numeric rejected", age: "twenty", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enabled, err := store.IsEnabledWithError("adults_only", Context{"user_id": "1", "age": tt.age})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if enabled != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, enabled)
+			}
+		})
+	}
+
+	strict := NewStore(WithStrictMode())
+	strict.AddFlag(flag)
+	if _, err := strict.IsEnabledWithError("adults_only", Context{"user_id": "1", "age": "twenty"}); err != ErrAttributeType {
+		t.Errorf("expected ErrAttributeType in strict mode, got %v", err)
+	}
+
+	invalid := &Flag{Name: "bad_schema", AttributeSchema: map[string]string{"age": "integer"}}
+	if err := invalid.Validate(); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("expected ErrInvalidSchema, got %v", err)
+	}
+}
+
+func TestStore_RequiredAttributes(t *testing.T) {
+	flag := &Flag{
+		Name:               "premium_only",
+		Enabled:            true,
+		Rollout:            100,
+		RequiredAttributes: []string{"plan"},
+		Conditions: []Condition{
+			{Attribute: "plan", Operator: OperatorEqual, Value: "premium"},
+		},
+	}
+
+	strict := NewStore(WithStrictMode())
+	strict.AddFlag(flag)
+
+	enabled, err := strict.IsEnabledWithError("premium_only", Context{"user_id": "1", "plan": "premium"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !enabled {
+		t.Error("expected flag to be enabled when required attribute is present")
+	}
+
+	_, err = strict.IsEnabledWithError("premium_only", Context{"user_id": "1"})
+	if !errors.Is(err, ErrRequiredAttributeMissing) {
+		t.Errorf("expected ErrRequiredAttributeMissing, got %v", err)
+	}
+
+	// Outside strict mode a missing attribute quietly fails the condition
+	lenient := NewStore()
+	lenient.AddFlag(flag)
+	enabled, err = lenient.IsEnabledWithError("premium_only", Context{"user_id": "1"})
+	if err != nil || enabled {
+		t.Errorf("expected (false, nil) outside strict mode, got (%v, %v)", enabled, err)
+	}
+}
+
+func TestStore_RolloutScope(t *testing.T) {
+	store := NewStore()
+
+	premiumOnly := []Condition{{Attribute: "plan", Operator: OperatorEqual, Value: "premium"}}
+	store.AddFlag(&Flag{Name: "global", Enabled: true, Rollout: 30})
+	store.AddFlag(&Flag{Name: "segment", Enabled: true, Rollout: 30, Conditions: premiumOnly})
+
+	// Same flag with a scope, so only the scope changes the hash key
+	scopedStore := NewStore()
+	scopedStore.AddFlag(&Flag{Name: "segment", Enabled: true, Rollout: 30, RolloutScope: "premium", Conditions: premiumOnly})
+
+	premium, global, segment, scoped, differs := 0, 0, 0, 0, 0
+	for i := 0; i < 10000; i++ {
+		plan := "basic"
+		if i%4 == 0 {
+			plan = "premium"
+		}
+		ctx := Context{"user_id": i, "plan": plan}
+
+		if store.IsEnabled("global", ctx) {
+			global++
+		}
+		if plan != "premium" {
+			continue
+		}
+		premium++
+		inSegment := store.IsEnabled("segment", ctx)
+		inScoped := scopedStore.IsEnabled("segment", ctx)
+		if inSegment {
+			segment++
+		}
+		if inScoped {
+			scoped++
+		}
+		if inSegment != inScoped {
+			differs++
+		}
+	}
+
+	// 30% applies within the conditioned segment, not across everyone
+	for name, count := range map[string]int{"segment": segment, "scoped": scoped} {
+		if ratio := float64(count) / float64(premium); ratio < 0.25 || ratio > 0.35 {
+			t.Errorf("%s: expected ~30%% of premium users, got %.2f", name, ratio)
+		}
+	}
+	if ratio := float64(global) / 10000; ratio < 0.25 || ratio > 0.35 {
+		t.Errorf("global: expected ~30%% of all users, got %.2f", ratio)
+	}
+
+	// The scope reshuffles which premium users are bucketed in
+	if differs == 0 {
+		t.Error("expected scoped bucketing to differ from unscoped bucketing")
+	}
+}
+
+func TestStore_DryRun(t *testing.T) {
+	var records []EvaluationRecord
+	store := NewStore(WithEvaluationHook(func(record EvaluationRecord) {
+		records = append(records, record)
+	}))
+
+	store.AddFlag(&Flag{Name: "candidate", Enabled: true, Rollout: 100, DryRun: true})
+	store.AddFlag(&Flag{
+		Name:           "candidate_experiment",
+		Enabled:        true,
+		DryRun:         true,
+		DefaultVariant: "control",
+		Variants:       []Variant{{Name: "treatment", Weight: 100}, {Name: "control", Weight: 0}},
+	})
+
+	ctx := Context{"user_id": "1"}
+
+	if store.IsEnabled("candidate", ctx) {
+		t.Error("expected dry-run flag to return disabled to the caller")
+	}
+
+	variant, enabled := store.GetVariant("candidate_experiment", ctx)
+	if variant != "control" || enabled {
+		t.Errorf("expected dry-run variant (control, false), got (%s, %v)", variant, enabled)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 hook records, got %d", len(records))
+	}
+	if !records[0].DryRun || !records[0].Result.Enabled {
+		t.Errorf("expected hook to receive would-be enabled decision, got %+v", records[0])
+	}
+	if records[1].Result.Variant != "treatment" || !records[1].Result.Enabled {
+		t.Errorf("expected hook to receive would-be treatment variant, got %+v", records[1])
+	}
+}
+
+func TestStore_FlagInheritance(t *testing.T) {
+	store := NewStore()
+
+	parent := &Flag{
+		Name:       "internal_users",
+		Enabled:    true,
+		Rollout:    100,
+		Conditions: []Condition{{Attribute: "country", Operator: OperatorEqual, Value: "US"}},
+	}
+	child := &Flag{
+		Name:       "internal_beta",
+		Enabled:    true,
+		Extends:    "internal_users",
+		Conditions: []Condition{{Attribute: "plan", Operator: OperatorEqual, Value: "premium"}},
+	}
+
+	// Children may precede their parents within a batch
+	if err := store.AddFlags([]*Flag{child, parent}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resolved, _ := store.GetFlag("internal_beta")
+	if len(resolved.Conditions) != 2 || resolved.Rollout != 100 {
+		t.Errorf("expected inherited condition and rollout, got %+v", resolved)
+	}
+	if len(child.Conditions) != 1 {
+		t.Error("expected the caller's flag to be left untouched")
+	}
+
+	tests := []struct {
+		name     string
+		ctx      Context
+		expected bool
+	}{
+		{name: "parent and child conditions match", ctx: Context{"user_id": "1", "country": "US", "plan": "premium"}, expected: true},
+		{name: "parent condition fails", ctx: Context{"user_id": "1", "country": "DE", "plan": "premium"}, expected: false},
+		{name: "child condition fails", ctx: Context{"user_id": "1", "country": "US", "plan": "basic"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := store.IsEnabled("internal_beta", tt.ctx); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	// Overriding rollout on the child wins over the parent
+	store.AddFlag(&Flag{Name: "internal_dark", Enabled: true, Extends: "internal_users", Rollout: 10})
+	if resolved, _ := store.GetFlag("internal_dark"); resolved.Rollout != 10 {
+		t.Errorf("expected child rollout override 10, got %d", resolved.Rollout)
+	}
+
+	// Missing parent
+	err := store.AddFlag(&Flag{Name: "orphan", Enabled: true, Extends: "missing"})
+	if !errors.Is(err, ErrFlagNotFound) {
+		t.Errorf("expected ErrFlagNotFound for missing parent, got %v", err)
+	}
+
+	// Re-adding the parent as a child of its own descendant is a cycle
+	err = store.AddFlag(&Flag{Name: "internal_users", Enabled: true, Extends: "internal_beta"})
+	if err != ErrInheritanceCycle {
+		t.Errorf("expected ErrInheritanceCycle, got %v", err)
+	}
+
+	// Cycles within a batch
+	err = NewStore().AddFlags([]*Flag{
+		{Name: "a", Enabled: true, Extends: "b"},
+		{Name: "b", Enabled: true, Extends: "a"},
+	})
+	if err != ErrInheritanceCycle {
+		t.Errorf("expected ErrInheritanceCycle for batch cycle, got %v", err)
+	}
+}
+
+func TestStore_WithListAnyMatch(t *testing.T) {
+	flag := &Flag{
+		Name:       "editor_tools",
+		Enabled:    true,
+		Rollout:    100,
+		Conditions: []Condition{{Attribute: "roles", Operator: OperatorEqual, Value: "editor"}},
+	}
+	ctx := Context{"user_id": "u1", "roles": []interface{}{"viewer", "editor"}}
+
+	store := NewStore(WithListAnyMatch())
+	store.AddFlag(flag)
+	if !store.IsEnabled("editor_tools", ctx) {
+		t.Error("expected list attribute to match on one of its elements")
+	}
+
+	plain := NewStore()
+	plain.AddFlag(flag)
+	if plain.IsEnabled("editor_tools", ctx) {
+		t.Error("expected list attribute not to match without WithListAnyMatch")
+	}
+}
+
+func TestStore_SeedAttribute(t *testing.T) {
+	store := NewStore()
+	store.AddFlag(&Flag{Name: "session_banner", Enabled: true, Rollout: 50, SeedAttribute: "session_seed"})
+	store.AddFlag(&Flag{
+		Name:          "session_layout",
+		Enabled:       true,
+		SeedAttribute: "session_seed",
+		Variants:      []Variant{{Name: "grid", Weight: 50}, {Name: "list", Weight: 50}},
+	})
+
+	enabled := map[bool]bool{}
+	variants := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		ctx := Context{"user_id": "user-1", "session_seed": fmt.Sprintf("seed-%d", i)}
+
+		first := store.IsEnabled("session_banner", ctx)
+		if again := store.IsEnabled("session_banner", ctx); again != first {
+			t.Errorf("expected same seed to give %v, got %v", first, again)
+		}
+		enabled[first] = true
+
+		variant, _ := store.GetVariant("session_layout", ctx)
+		if again, _ := store.GetVariant("session_layout", ctx); again != variant {
+			t.Errorf("expected same seed to give %v, got %v", variant, again)
+		}
+		variants[variant] = true
+	}
+	if len(enabled) != 2 {
+		t.Errorf("expected different seeds to reshuffle the rollout, got %v", enabled)
+	}
+	if len(variants) != 2 {
+		t.Errorf("expected different seeds to reshuffle variants, got %v", variants)
+	}
+
+	// A missing seed is treated like a missing rollout key
+	if store.IsEnabled("session_banner", Context{"user_id": "user-1"}) {
+		t.Error("expected flag to be disabled without a seed")
+	}
+}
+
+func TestStore_FlagsUsingAttribute(t *testing.T) {
+	store := NewStore()
+	store.AddFlags([]*Flag{
+		{Name: "by_condition", Enabled: true, Conditions: []Condition{{Attribute: "country", Operator: OperatorEqual, Value: "US"}}},
+		{Name: "by_rollout_key", Enabled: true, Rollout: 50, RolloutKey: "country"},
+		{Name: "by_variant", Enabled: true, Variants: []Variant{
+			{Name: "local", Weight: 100, Conditions: []Condition{{Attribute: "country", Operator: OperatorIn, Value: []interface{}{"DE", "FR"}}}},
+		}},
+		{Name: "by_template", Enabled: true, Conditions: []Condition{{Attribute: "home", Operator: OperatorEqual, Value: "{{country}}"}}},
+		{Name: "unrelated", Enabled: true, Conditions: []Condition{{Attribute: "plan", Operator: OperatorEqual, Value: "pro"}}},
+	})
+
+	expected := []string{"by_condition", "by_rollout_key", "by_template", "by_variant"}
+	if names := store.FlagsUsingAttribute("country"); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	if names := store.FlagsUsingAttribute("plan"); !reflect.DeepEqual(names, []string{"unrelated"}) {
+		t.Errorf("expected [unrelated], got %v", names)
+	}
+	if names := store.FlagsUsingAttribute("missing"); len(names) != 0 {
+		t.Errorf("expected no flags, got %v", names)
+	}
+}
+
+func TestStore_VersionPinning(t *testing.T) {
+	experiment := func(version int, variants ...Variant) *Flag {
+		return &Flag{
+			Name:           "pricing",
+			Enabled:        true,
+			Version:        version,
+			DefaultVariant: "holdout",
+			Variants:       append([]Variant{{Name: "holdout", Weight: 0}}, variants...),
+		}
+	}
+	assign := func(flag *Flag) []string {
+		store := NewStore()
+		if err := store.AddFlag(flag); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assignments := make([]string, 1000)
+		for i := range assignments {
+			assignments[i], _ = store.GetVariant("pricing", Context{"user_id": fmt.Sprintf("user-%d", i)})
+		}
+		return assignments
+	}
+
+	before := assign(experiment(1, Variant{Name: "a", Weight: 45}, Variant{Name: "b", Weight: 45}))
+	after := assign(experiment(1, Variant{Name: "a", Weight: 45}, Variant{Name: "b", Weight: 45}, Variant{Name: "c", Weight: 5}))
+
+	joined := 0
+	for i := range before {
+		switch {
+		case before[i] != "holdout" && after[i] != before[i]:
+			t.Fatalf("user-%d moved from %s to %s", i, before[i], after[i])
+		case before[i] == "holdout" && after[i] == "c":
+			joined++
+		case after[i] != before[i]:
+			t.Fatalf("user-%d moved from holdout to %s", i, after[i])
+		}
+	}
+	if joined == 0 {
+		t.Error("expected the new variant to take users from the holdout")
+	}
+
+	bumped := assign(experiment(2, Variant{Name: "a", Weight: 45}, Variant{Name: "b", Weight: 45}, Variant{Name: "c", Weight: 5}))
+	moved := 0
+	for i := range after {
+		if bumped[i] != after[i] {
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Error("expected a version bump to re-randomize assignments")
+	}
+}
+
+func TestStore_RenameFlag(t *testing.T) {
+	store := NewStore()
+	store.AddFlags([]*Flag{
+		{Name: "checkout_v2", Enabled: true, Rollout: 30},
+		{Name: "checkout_v2_internal", Enabled: true, Extends: "checkout_v2", Conditions: []Condition{
+			{Attribute: "internal", Operator: OperatorEqual, Value: true},
+		}},
+		{Name: "pricing", Enabled: true, Variants: []Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50}}},
+		{Name: "taken", Enabled: true},
+	})
+
+	users := make([]Context, 200)
+	before := make([]bool, len(users))
+	variants := make([]string, len(users))
+	for i := range users {
+		users[i] = Context{"user_id": fmt.Sprintf("user-%d", i)}
+		before[i] = store.IsEnabled("checkout_v2", users[i])
+		variants[i], _ = store.GetVariant("pricing", users[i])
+	}
+
+	if err := store.RenameFlag("checkout_v2", "new_checkout"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.RenameFlag("pricing", "pricing_experiment"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := store.GetFlag("checkout_v2"); err != ErrFlagNotFound {
+		t.Errorf("expected old name to be gone, got %v", err)
+	}
+	flag, err := store.GetFlag("new_checkout")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flag.Salt != "checkout_v2" {
+		t.Errorf("expected salt checkout_v2, got %q", flag.Salt)
+	}
+	for i, ctx := range users {
+		if result := store.IsEnabled("new_checkout", ctx); result != before[i] {
+			t.Errorf("%v: expected %v, got %v", ctx, before[i], result)
+		}
+		if variant, _ := store.GetVariant("pricing_experiment", ctx); variant != variants[i] {
+			t.Errorf("%v: expected %v, got %v", ctx, variants[i], variant)
+		}
+	}
+
+	if child, _ := store.GetFlag("checkout_v2_internal"); child.Extends != "new_checkout" {
+		t.Errorf("expected child to extend new_checkout, got %q", child.Extends)
+	}
+	if err := store.Validate(); err != nil {
+		t.Errorf("expected store to stay consistent, got %v", err)
+	}
+
+	// Renaming again keeps the original salt
+	store.RenameFlag("new_checkout", "checkout")
+	if flag, _ := store.GetFlag("checkout"); flag.Salt != "checkout_v2" {
+		t.Errorf("expected salt checkout_v2, got %q", flag.Salt)
+	}
+
+	if err := store.RenameFlag("missing", "other"); err != ErrFlagNotFound {
+		t.Errorf("expected ErrFlagNotFound, got %v", err)
+	}
+	if err := store.RenameFlag("checkout", "taken"); !errors.Is(err, ErrFlagExists) {
+		t.Errorf("expected ErrFlagExists, got %v", err)
+	}
+}
+
+func TestStore_ScoreThreshold(t *testing.T) {
+	store := NewStore()
+	err := store.AddFlag(&Flag{
+		Name:           "risk_review",
+		Enabled:        true,
+		Rollout:        100,
+		ScoreThreshold: 50,
+		Conditions: []Condition{
+			{Attribute: "new_account", Operator: OperatorEqual, Value: true, Score: 30},
+			{Attribute: "country", Operator: OperatorIn, Value: []interface{}{"XX", "YY"}, Score: 40},
+			{Attribute: "amount", Operator: OperatorGreaterThan, Value: 1000, Score: 15},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		ctx      Context
+		expected bool
+	}{
+		{"two matches crossing threshold", Context{"user_id": "u1", "new_account": true, "country": "XX"}, true},
+		{"two matches below threshold", Context{"user_id": "u1", "new_account": true, "amount": 5000}, false},
+		{"one match", Context{"user_id": "u1", "country": "XX"}, false},
+		{"all matches", Context{"user_id": "u1", "new_account": true, "country": "YY", "amount": 5000}, true},
+		{"no matches", Context{"user_id": "u1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := store.IsEnabled("risk_review", tt.ctx); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	err = store.AddFlag(&Flag{Name: "bad", Enabled: true, ScoreThreshold: -1})
+	if !errors.Is(err, ErrInvalidCondition) {
+		t.Errorf("expected ErrInvalidCondition, got %v", err)
+	}
+}
+
+func TestStore_Prefix(t *testing.T) {
+	store := NewStore()
+	store.AddFlags([]*Flag{
+		{Name: "checkout.express", Enabled: true, Rollout: 100},
+		{Name: "checkout.onepage", Enabled: true, Rollout: 100, Conditions: []Condition{
+			{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+		}},
+		{Name: "checkout.layout", Enabled: true, Variants: []Variant{{Name: "a", Weight: 100}}},
+		{Name: "checkout_legacy", Enabled: true, Rollout: 100},
+		{Name: "search.autocomplete", Enabled: true, Rollout: 100},
+	})
+
+	expected := []string{"checkout.express", "checkout.layout", "checkout.onepage"}
+	if names := store.ListFlagsWithPrefix("checkout."); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	if names := store.ListFlagsWithPrefix("billing."); len(names) != 0 {
+		t.Errorf("expected no flags, got %v", names)
+	}
+
+	results := store.EvaluatePrefix("checkout.", Context{"user_id": "u1", "country": "DE"})
+	expectedResults := map[string]bool{
+		"checkout.express": true,
+		"checkout.onepage": false,
+		"checkout.layout":  false,
+	}
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("expected %v, got %v", expectedResults, results)
+	}
+
+	if results := store.EvaluatePrefix("checkout.", Context{"user_id": "u1", "country": "US"}); !results["checkout.onepage"] {
+		t.Errorf("expected checkout.onepage to be enabled, got %v", results)
+	}
+	if all := store.EvaluatePrefix("", Context{"user_id": "u1"}); len(all) != 5 {
+		t.Errorf("expected every flag for an empty prefix, got %v", all)
+	}
+}
+
+func TestStore_Validate(t *testing.T) {
+	store := NewStore()
+	store.AddFlag(&Flag{Name: "internal_users", Enabled: true, Rollout: 100})
+	store.AddFlag(&Flag{Name: "internal_beta", Enabled: true, Extends: "internal_users"})
+	store.AddFlag(&Flag{
+		Name:           "experiment",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Variants:       []Variant{{Name: "control", Weight: 50}, {Name: "treatment", Weight: 50}},
+	})
+
+	if err := store.Validate(); err != nil {
+		t.Fatalf("expected clean store to validate, got %v", err)
+	}
+
+	// Removing a parent leaves its child dangling
+	store.RemoveFlag("internal_users")
+	if err := store.Validate(); !errors.Is(err, ErrFlagNotFound) {
+		t.Errorf("expected ErrFlagNotFound for dangling parent, got %v", err)
+	}
+
+	// Merging can close an inheritance cycle
+	cyclic := NewStore()
+	cyclic.AddFlag(&Flag{Name: "a", Enabled: true})
+	cyclic.AddFlag(&Flag{Name: "b", Enabled: true, Extends: "a"})
+	other := NewStore()
+	other.AddFlag(&Flag{Name: "b", Enabled: true})
+	other.AddFlag(&Flag{Name: "a", Enabled: true, Extends: "b"})
+	other.RemoveFlag("b")
+	cyclic.Merge(other, MergeOverride)
+	if err := cyclic.Validate(); err != ErrInheritanceCycle {
+		t.Errorf("expected ErrInheritanceCycle, got %v", err)
+	}
+
+	if err := NewStore().Validate(); err != nil {
+		t.Errorf("expected empty store to validate, got %v", err)
+	}
+}
+
+// countingEvaluator is a FlagEvaluator decorator that counts calls
+type countingEvaluator struct {
+	next     FlagEvaluator
+	enabled  int
+	variants int
+}
+
+func (c *countingEvaluator) Enabled(name string, ctx Context) (bool, error) {
+	c.enabled++
+	return c.next.Enabled(name, ctx)
+}
+
+func (c *countingEvaluator) Variant(name string, ctx Context) (string, bool, error) {
+	c.variants++
+	return c.next.Variant(name, ctx)
+}
+
+func TestStore_FlagEvaluatorDecorator(t *testing.T) {
+	store := NewStore()
+	store.AddFlag(&Flag{Name: "simple", Enabled: true, Rollout: 100})
+	store.AddFlag(&Flag{
+		Name:           "experiment",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Variants:       []Variant{{Name: "treatment", Weight: 100}, {Name: "control", Weight: 0}},
+	})
+
+	var evaluator FlagEvaluator = &countingEvaluator{next: store}
+	ctx := Context{"user_id": "1"}
+
+	enabled, err := evaluator.Enabled("simple", ctx)
+	if err != nil || !enabled {
+		t.Errorf("expected (true, nil), got (%v, %v)", enabled, err)
+	}
+
+	variant, enabled, err := evaluator.Variant("experiment", ctx)
+	if err != nil || !enabled || variant != "treatment" {
+		t.Errorf("expected (treatment, true, nil), got (%s, %v, %v)", variant, enabled, err)
+	}
+
+	if _, err := evaluator.Enabled("missing", ctx); err != ErrFlagNotFound {
+		t.Errorf("expected ErrFlagNotFound to pass through, got %v", err)
+	}
+
+	counter := evaluator.(*countingEvaluator)
+	if counter.enabled != 2 || counter.variants != 1 {
+		t.Errorf("expected 2 Enabled and 1 Variant calls, got %d and %d", counter.enabled, counter.variants)
+	}
+}
+
+// countingStrategy is a rollout strategy that counts rollout decisions
+type countingStrategy struct {
+	*DefaultRolloutStrategy
+	calls int
+}
+
+func (c *countingStrategy) ShouldRollout(flag *Flag, ctx Context) (bool, error) {
+	c.calls++
+	return c.DefaultRolloutStrategy.ShouldRollout(flag, ctx)
+}
+
+func TestStore_EvaluationCache(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	strategy := &countingStrategy{DefaultRolloutStrategy: NewDefaultRolloutStrategy(nil)}
+	store := NewStore(
+		WithClock(func() time.Time { return now }),
+		WithEvaluationCache(10, time.Minute),
+		func(s *Store) { s.rolloutStrategy = strategy },
+	)
+
+	store.AddFlag(&Flag{
+		Name:       "cached",
+		Enabled:    true,
+		Rollout:    100,
+		Conditions: []Condition{{Attribute: "country", Operator: OperatorEqual, Value: "US"}},
+	})
+
+	ctx := Context{"user_id": "1", "country": "US", "session": "a"}
+
+	store.IsEnabled("cached", ctx)
+	store.IsEnabled("cached", ctx)
+	if strategy.calls != 1 {
+		t.Errorf("expected cache hit on repeated evaluation, got %d decisions", strategy.calls)
+	}
+
+	// Attributes the flag does not reference share the entry
+	store.IsEnabled("cached", Context{"user_id": "1", "country": "US", "session": "b"})
+	if strategy.calls != 1 {
+		t.Errorf("expected cache hit for unrelated attribute change, got %d decisions", strategy.calls)
+	}
+
+	// Referenced attributes do not
+	store.IsEnabled("cached", Context{"user_id": "2", "country": "US"})
+	if strategy.calls != 2 {
+		t.Errorf("expected cache miss for different user, got %d decisions", strategy.calls)
+	}
+
+	// Updating the flag invalidates its entries
+	store.AddFlag(&Flag{Name: "cached", Enabled: true, Rollout: 0})
+	if store.IsEnabled("cached", ctx) {
+		t.Error("expected updated flag to be evaluated, not a stale cached result")
+	}
+	if strategy.calls != 3 {
+		t.Errorf("expected cache miss after flag update, got %d decisions", strategy.calls)
+	}
+
+	// Entries expire after the TTL
+	now = now.Add(2 * time.Minute)
+	store.IsEnabled("cached", ctx)
+	if strategy.calls != 4 {
+		t.Errorf("expected cache miss after TTL, got %d decisions", strategy.calls)
+	}
+
+	// Flags that read the clock are never cached
+	store.AddFlag(&Flag{
+		Name:       "clocked",
+		Enabled:    true,
+		Rollout:    100,
+		Conditions: []Condition{{Attribute: NowAttribute, Operator: OperatorTimeOfDayBetween, Value: []string{"00:00", "23:59"}}},
+	})
+	store.IsEnabled("clocked", ctx)
+	store.IsEnabled("clocked", ctx)
+	if strategy.calls != 6 {
+		t.Errorf("expected clock-dependent flag to skip the cache, got %d decisions", strategy.calls)
+	}
+}
+
+func TestStore_EvaluationCacheTimeBasedStrategy(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(30 * time.Minute)
+	store := NewStore(
+		WithClock(func() time.Time { return now }),
+		WithEvaluationCache(10, time.Hour),
+		WithSwitchback(WithIntervalMinutes(60), WithStartTime(start)),
+	)
+	store.AddFlag(&Flag{
+		Name:     "pricing",
+		Enabled:  true,
+		Variants: []Variant{{Name: "control", Weight: 50}, {Name: "treatment", Weight: 50}},
+	})
+
+	ctx := Context{"user_id": "1"}
+	if variant, _ := store.GetVariant("pricing", ctx); variant != "control" {
+		t.Fatalf("expected control in the first interval, got %s", variant)
+	}
+	now = now.Add(time.Hour)
+	if variant, _ := store.GetVariant("pricing", ctx); variant != "treatment" {
+		t.Errorf("expected treatment after the switch, not a cached decision, got %s", variant)
+	}
+
+	now = start
+	stepped := NewStore(
+		WithClock(func() time.Time { return now }),
+		WithEvaluationCache(10, time.Hour),
+		WithStepRollout(start, []Step{{Percent: 100, After: time.Minute}}),
+	)
+	stepped.AddFlag(&Flag{Name: "canary", Enabled: true})
+	if stepped.IsEnabled("canary", ctx) {
+		t.Fatal("expected canary disabled before the first step")
+	}
+	now = now.Add(time.Minute)
+	if !stepped.IsEnabled("canary", ctx) {
+		t.Error("expected the step strategy's current decision, not a cached one")
+	}
+}
+
+func TestEvaluationCache_StalePut(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newEvaluationCache(10, time.Minute)
+	result := EvaluationResult{Flag: "f", Enabled: true}
+
+	// A decision started before an invalidation must not be stored
+	generation := cache.generation("f")
+	cache.invalidate("f")
+	cache.put("k", "f", generation, result, now)
+	if _, ok := cache.get("k", now); ok {
+		t.Error("expected put with a stale generation to be dropped")
+	}
+
+	generation = cache.generation("f")
+	cache.clear()
+	cache.put("k", "f", generation, result, now)
+	if _, ok := cache.get("k", now); ok {
+		t.Error("expected put from before clear to be dropped")
+	}
+
+	cache.put("k", "f", cache.generation("f"), result, now)
+	if _, ok := cache.get("k", now); !ok {
+		t.Error("expected put with the current generation to be stored")
+	}
+}
+
+func TestStore_StaticAttributes(t *testing.T) {
+	attrs := Context{"env": "prod", "build": "abc123"}
+	store := NewStore(WithStaticAttributes(attrs))
+
+	// The store keeps its own copy
+	attrs["env"] = "staging"
+
+	store.AddFlag(&Flag{
+		Name:       "prod_only",
+		Enabled:    true,
+		Rollout:    100,
+		Conditions: []Condition{{Attribute: "env", Operator: OperatorEqual, Value: "prod"}},
+	})
+
+	if !store.IsEnabled("prod_only", Context{"user_id": "1"}) {
+		t.Error("expected condition on env to match the static attribute")
+	}
+
+	// Per-call values take precedence
+	if store.IsEnabled("prod_only", Context{"user_id": "1", "env": "staging"}) {
+		t.Error("expected per-call env to override the static attribute")
+	}
+}
+
+func TestStore_CohortRollout(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlag(&Flag{
+		Name:    "cohort_ramp",
+		Enabled: true,
+		Rollout: 10,
+		CohortRollout: &CohortRollout{
+			Attribute: "joined_at",
+			Steps: []CohortStep{
+				{Before: "2024-01-01", Rollout: 100},
+				{Before: "2024-06-01", Rollout: 50},
+			},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		joinedAt interface{}
+		expected int
+	}{
+		{name: "oldest cohort", joinedAt: "2023-05-01", expected: 100},
+		{name: "middle cohort", joinedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), expected: 50},
+		{name: "newest users use flag rollout", joinedAt: "2024-09-01T10:00:00Z", expected: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cohort, _ := store.GetFlag("cohort_ramp")
+			rollout := store.effectiveRollout(cohort, Context{"joined_at": tt.joinedAt})
+			if rollout != tt.expected {
+				t.Errorf("expected effective rollout %d, got %d", tt.expected, rollout)
+			}
+
+			enabled := 0
+			for i := 0; i < 1000; i++ {
+				if store.IsEnabled("cohort_ramp", Context{"user_id": i, "joined_at": tt.joinedAt}) {
+					enabled++
+				}
+			}
+			if diff := enabled - tt.expected*10; diff < -60 || diff > 60 {
+				t.Errorf("expected roughly %d%% enabled, got %d/1000", tt.expected, enabled)
+			}
+		})
+	}
+
+	invalid := &Flag{
+		Name:          "bad_cohort",
+		CohortRollout: &CohortRollout{Attribute: "joined_at", Steps: []CohortStep{{Before: "soon", Rollout: 50}}},
+	}
+	if err := invalid.Validate(); !errors.Is(err, ErrInvalidRollout) {
+		t.Errorf("expected ErrInvalidRollout, got %v", err)
+	}
+}
+
+func TestStore_NowConditions(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	store := NewStore(WithClock(func() time.Time { return now }))
+
+	store.AddFlag(&Flag{
+		Name:    "business_hours_banner",
+		Enabled: true,
+		Rollout: 100,
+		Conditions: []Condition{
+			{Attribute: NowAttribute, Operator: OperatorTimeOfDayBetween, Value: []interface{}{"09:00", "17:00"}},
+		},
+	})
+
+	if !store.IsEnabled("business_hours_banner", Context{"user_id": "1"}) {
+		t.Error("expected flag to be enabled during business hours")
+	}
+
+	now = time.Date(2024, 5, 15, 20, 0, 0, 0, time.UTC)
+	if store.IsEnabled("business_hours_banner", Context{"user_id": "1"}) {
+		t.Error("expected flag to be disabled after business hours")
+	}
+}
+
+func TestStore_CountEnabled(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlags([]*Flag{
+		{Name: "on", Enabled: true, Rollout: 100},
+		{Name: "also_on", Enabled: true, Rollout: 100},
+		{Name: "off", Enabled: false, Rollout: 100},
+		{Name: "excluded", Enabled: true, Rollout: 0},
+		{
+			Name:       "us_only",
+			Enabled:    true,
+			Rollout:    100,
+			Conditions: []Condition{{Attribute: "country", Operator: OperatorEqual, Value: "US"}},
+		},
+		{
+			Name:           "experiment",
+			Enabled:        true,
+			DefaultVariant: "control",
+			Variants:       []Variant{{Name: "control", Weight: 100}},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		ctx      Context
+		expected int
+	}{
+		{name: "US user", ctx: Context{"user_id": "1", "country": "US"}, expected: 3},
+		{name: "DE user", ctx: Context{"user_id": "1", "country": "DE"}, expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if count := store.CountEnabled(tt.ctx); count != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, count)
+			}
+		})
+	}
+}
+
+func TestStore_WithDefaultRolloutKey(t *testing.T) {
+	store := NewStore(WithDefaultRolloutKey("account_id"))
+
+	store.AddFlags([]*Flag{
+		{Name: "account_feature", Enabled: true, Rollout: 50},
+		{Name: "user_feature", Enabled: true, Rollout: 50, RolloutKey: "user_id"},
+	})
+
+	flag, _ := store.GetFlag("account_feature")
+	if flag.GetRolloutKey() != "account_id" {
+		t.Errorf("expected %s, got %s", "account_id", flag.GetRolloutKey())
+	}
+	flag, _ = store.GetFlag("user_feature")
+	if flag.GetRolloutKey() != "user_id" {
+		t.Errorf("expected %s, got %s", "user_id", flag.GetRolloutKey())
+	}
+
+	// Only account_id varies, so the store default must be what buckets users
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		if store.IsEnabled("account_feature", Context{"user_id": "same", "account_id": i}) {
+			enabled++
+		}
+	}
+	if enabled < 440 || enabled > 560 {
+		t.Errorf("expected roughly 50%% enabled by account_id, got %d/1000", enabled)
+	}
+
+	// Without account_id in the context the flag cannot bucket the user
+	if store.IsEnabled("account_feature", Context{"user_id": "1"}) {
+		t.Error("expected flag to be disabled without the default rollout key")
+	}
+}
+
+func TestStore_VariantAllocation(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlags([]*Flag{
+		{
+			Name:           "full",
+			Enabled:        true,
+			DefaultVariant: "control",
+			Variants:       []Variant{{Name: "control", Weight: 50}, {Name: "treatment", Weight: 50}},
+		},
+		{
+			Name:           "holdout",
+			Enabled:        true,
+			DefaultVariant: "holdout",
+			Variants:       []Variant{{Name: "a", Weight: 20}, {Name: "b", Weight: 30}, {Name: "holdout", Weight: 0}},
+		},
+		{
+			Name:           "equal",
+			Enabled:        true,
+			DefaultVariant: "a",
+			Variants:       []Variant{{Name: "a", Weight: 33}, {Name: "b", Weight: 33}, {Name: "c", Weight: 33}},
+		},
+		{Name: "simple", Enabled: true, Rollout: 25},
+	})
+
+	tests := []struct {
+		flag     string
+		expected map[string]float64
+	}{
+		{flag: "full", expected: map[string]float64{"control": 50, "treatment": 50}},
+		{flag: "holdout", expected: map[string]float64{"a": 20, "b": 30, "holdout": 50}},
+		{flag: "equal", expected: map[string]float64{"a": 34, "b": 33, "c": 33}},
+		{flag: "simple", expected: map[string]float64{"on": 25, "off": 75}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			allocation, err := store.VariantAllocation(tt.flag)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(allocation) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, allocation)
+			}
+			sum := 0.0
+			for name, pct := range tt.expected {
+				if allocation[name] != pct {
+					t.Errorf("expected %s to get %v%%, got %v%%", name, pct, allocation[name])
+				}
+				sum += allocation[name]
+			}
+			if sum != 100 {
+				t.Errorf("expected allocation to sum to 100, got %v", sum)
+			}
+		})
+	}
+
+	// Overrides are applied as in evaluation
+	disabled := false
+	rollout := 60
+	store.SetOverride("simple", Override{Rollout: &rollout})
+	store.SetOverride("full", Override{Variant: "treatment"})
+	store.SetOverride("holdout", Override{Enabled: &disabled})
+	overridden := map[string]map[string]float64{
+		"simple":  {"on": 60, "off": 40},
+		"full":    {"treatment": 100},
+		"holdout": {"holdout": 100},
+	}
+	for flag, expected := range overridden {
+		allocation, err := store.VariantAllocation(flag)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(allocation, expected) {
+			t.Errorf("%s: expected %v with the override, got %v", flag, expected, allocation)
+		}
+	}
+
+	if _, err := store.VariantAllocation("missing"); err != ErrFlagNotFound {
+		t.Errorf("expected ErrFlagNotFound, got %v", err)
+	}
+}
+
+func TestStore_WithCaseInsensitiveAttributes(t *testing.T) {
+	store := NewStore(WithCaseInsensitiveAttributes(), WithStaticAttributes(Context{"Env": "prod"}))
+
+	store.AddFlags([]*Flag{
+		{
+			Name:       "us_launch",
+			Enabled:    true,
+			Rollout:    100,
+			Conditions: []Condition{{Attribute: "country", Operator: OperatorEqual, Value: "US"}},
+		},
+		{
+			Name:    "prod_only",
+			Enabled: true,
+			Rollout: 100,
+			Conditions: []Condition{
+				{Attribute: "ENV", Operator: OperatorEqual, Value: "prod"},
+				{Attribute: "home", Operator: OperatorEqual, Value: "{{Country}}"},
+			},
+		},
+		{Name: "half", Enabled: true, Rollout: 50, RolloutKey: "User_ID"},
+	})
+
+	tests := []struct {
+		name     string
+		flag     string
+		ctx      Context
+		expected bool
+	}{
+		{name: "capitalized context key", flag: "us_launch", ctx: Context{"user_id": "1", "Country": "US"}, expected: true},
+		{name: "uppercase context key", flag: "us_launch", ctx: Context{"user_id": "1", "COUNTRY": "US"}, expected: true},
+		{name: "exact lowercase key wins", flag: "us_launch", ctx: Context{"user_id": "1", "country": "US", "Country": "DE"}, expected: true},
+		{name: "value still case sensitive", flag: "us_launch", ctx: Context{"user_id": "1", "Country": "us"}, expected: false},
+		{name: "static attributes and templates", flag: "prod_only", ctx: Context{"user_id": "1", "Country": "US", "HOME": "US"}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if enabled := store.IsEnabled(tt.flag, tt.ctx); enabled != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, enabled)
+			}
+		})
+	}
+
+	// The rollout key lookup is case-insensitive too
+	for i := 0; i < 100; i++ {
+		lower := store.IsEnabled("half", Context{"user_id": i})
+		upper := store.IsEnabled("half", Context{"USER_ID": i})
+		if lower != upper {
+			t.Fatalf("expected same rollout decision for user %d regardless of key case", i)
+		}
+	}
+}
+
+func TestStore_Merge(t *testing.T) {
+	newDefaults := func() *Store {
+		store := NewStore()
+		store.AddFlags([]*Flag{
+			{Name: "shared", Enabled: true, Rollout: 100},
+			{Name: "defaults_only", Enabled: true, Rollout: 100},
+		})
+		return store
+	}
+	overrides := NewStore()
+	overrides.AddFlags([]*Flag{
+		{Name: "shared", Enabled: false, Rollout: 100},
+		{Name: "overrides_only", Enabled: true, Rollout: 100},
+	})
+
+	tests := []struct {
+		name          string
+		policy        MergePolicy
+		expectedErr   error
+		sharedEnabled bool
+		size          int
+	}{
+		{name: "override", policy: MergeOverride, sharedEnabled: false, size: 3},
+		{name: "skip", policy: MergeSkip, sharedEnabled: true, size: 3},
+		{name: "error", policy: MergeError, expectedErr: ErrFlagConflict, sharedEnabled: true, size: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newDefaults()
+			err := store.Merge(overrides, tt.policy)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, got %v", tt.expectedErr, err)
+			}
+
+			ctx := Context{"user_id": "1"}
+			if enabled := store.IsEnabled("shared", ctx); enabled != tt.sharedEnabled {
+				t.Errorf("expected shared enabled %v, got %v", tt.sharedEnabled, enabled)
+			}
+			if store.Size() != tt.size {
+				t.Errorf("expected %d flags, got %d", tt.size, store.Size())
+			}
+			if !store.IsEnabled("defaults_only", ctx) {
+				t.Error("expected defaults_only to survive the merge")
+			}
+		})
+	}
+}
+
+func TestStore_MergeThenPatch(t *testing.T) {
+	other := NewStore()
+	other.AddFlags([]*Flag{
+		{
+			Name:       "internal_users",
+			Enabled:    true,
+			Rollout:    100,
+			Conditions: []Condition{{Attribute: "email", Operator: OperatorEndsWith, Value: "@example.com"}},
+		},
+		{Name: "internal_dark", Enabled: true, Extends: "internal_users", Rollout: 100},
+	})
+
+	store := NewStore()
+	if err := store.Merge(other, MergeOverride); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.PatchFlag("internal_dark", []byte(`[{"op": "replace", "path": "/rollout", "value": 50}]`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	flag, _ := store.GetFlag("internal_dark")
+	if flag.Rollout != 50 {
+		t.Errorf("expected patched rollout 50, got %d", flag.Rollout)
+	}
+	if len(flag.Conditions) != 1 {
+		t.Errorf("expected the parent condition once, got %d conditions", len(flag.Conditions))
+	}
+}
+
+func TestFlag_Clone(t *testing.T) {
+	sticky := true
+	original := &Flag{
+		Name:    "clone_me",
+		Enabled: true,
+		Rollout: 50,
+		Conditions: []Condition{
+			{Attribute: "country", Operator: OperatorIn, Value: []interface{}{"US", "CA"}},
+			{Attribute: "meta", Operator: OperatorEqual, Value: map[string]interface{}{"tags": []string{"a"}}},
+		},
+		Variants: []Variant{
+			{
+				Name:       "blue",
+				Weight:     100,
+				Conditions: []Condition{{Attribute: "plan", Operator: OperatorIn, Value: []string{"pro"}}},
+				Payload:    map[string]interface{}{"color": "blue"},
+			},
+		},
+		AttributeSchema:    map[string]string{"age": AttributeTypeNumber},
+		RequiredAttributes: []string{"country"},
+		CohortRollout:      &CohortRollout{Attribute: "joined_at", Steps: []CohortStep{{Before: "2024-01-01", Rollout: 10}}},
+		Schedule:           &Schedule{Windows: []ScheduleWindow{{Weekdays: []string{"mon"}}}},
+		Sticky:             &sticky,
+	}
+
+	clone := original.Clone()
+	if !reflect.DeepEqual(clone, original) {
+		t.Fatalf("expected clone to equal original, got %+v", clone)
+	}
+
+	clone.Conditions[0].Value.([]interface{})[0] = "DE"
+	clone.Conditions[1].Value.(map[string]interface{})["tags"].([]string)[0] = "b"
+	clone.Conditions = append(clone.Conditions, Condition{Attribute: "x", Operator: OperatorEqual, Value: 1})
+	clone.Variants[0].Conditions[0].Value.([]string)[0] = "free"
+	clone.Variants[0].Payload.(map[string]interface{})["color"] = "red"
+	clone.AttributeSchema["age"] = AttributeTypeString
+	clone.RequiredAttributes[0] = "plan"
+	clone.CohortRollout.Steps[0].Rollout = 90
+	clone.Schedule.Windows[0].Weekdays[0] = "sun"
+	*clone.Sticky = false
+
+	if got := original.Conditions[0].Value.([]interface{})[0]; got != "US" {
+		t.Errorf("expected %v, got %v", "US", got)
+	}
+	if got := original.Conditions[1].Value.(map[string]interface{})["tags"].([]string)[0]; got != "a" {
+		t.Errorf("expected %v, got %v", "a", got)
+	}
+	if len(original.Conditions) != 2 {
+		t.Errorf("expected %d conditions, got %d", 2, len(original.Conditions))
+	}
+	if got := original.Variants[0].Conditions[0].Value.([]string)[0]; got != "pro" {
+		t.Errorf("expected %v, got %v", "pro", got)
+	}
+	if got := original.Variants[0].Payload.(map[string]interface{})["color"]; got != "blue" {
+		t.Errorf("expected %v, got %v", "blue", got)
+	}
+	if got := original.AttributeSchema["age"]; got != AttributeTypeNumber {
+		t.Errorf("expected %v, got %v", AttributeTypeNumber, got)
+	}
+	if got := original.RequiredAttributes[0]; got != "country" {
+		t.Errorf("expected %v, got %v", "country", got)
+	}
+	if got := original.CohortRollout.Steps[0].Rollout; got != 10 {
+		t.Errorf("expected %v, got %v", 10, got)
+	}
+	if got := original.Schedule.Windows[0].Weekdays[0]; got != "mon" {
+		t.Errorf("expected %v, got %v", "mon", got)
+	}
+	if !*original.Sticky {
+		t.Error("expected original to stay sticky")
+	}
+
+	var nilFlag *Flag
+	if nilFlag.Clone() != nil {
+		t.Error("expected nil clone of nil flag")
+	}
+}
+
+func TestStore_IsEnabledAnyAll(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlag(&Flag{
+		Name:       "adult_content",
+		Enabled:    true,
+		Rollout:    100,
+		Conditions: []Condition{{Attribute: "age", Operator: OperatorGreaterThanOrEqual, Value: 18}},
+	})
+
+	adult := Context{"user_id": "parent", "age": 40}
+	otherAdult := Context{"user_id": "partner", "age": 38}
+	child := Context{"user_id": "kid", "age": 9}
+
+	tests := []struct {
+		name        string
+		ctxs        []Context
+		expectedAny bool
+		expectedAll bool
+	}{
+		{name: "one qualifying profile", ctxs: []Context{child, adult}, expectedAny: true, expectedAll: false},
+		{name: "all qualifying profiles", ctxs: []Context{adult, otherAdult}, expectedAny: true, expectedAll: true},
+		{name: "no qualifying profile", ctxs: []Context{child}, expectedAny: false, expectedAll: false},
+		{name: "no contexts", ctxs: nil, expectedAny: false, expectedAll: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := store.IsEnabledAny("adult_content", tt.ctxs...); got != tt.expectedAny {
+				t.Errorf("expected any %v, got %v", tt.expectedAny, got)
+			}
+			if got := store.IsEnabledAll("adult_content", tt.ctxs...); got != tt.expectedAll {
+				t.Errorf("expected all %v, got %v", tt.expectedAll, got)
+			}
+		})
+	}
+}
+
+func TestStore_GetVariant_ConditionGroups(t *testing.T) {
+	store := NewStore()
+
+	err := store.AddFlag(&Flag{
+		Name:           "beta_checkout",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Variants: []Variant{
+			{
+				Name:   "beta",
+				Weight: 100,
+				Groups: []ConditionGroup{{
+					Logic: LogicOr,
+					Conditions: []Condition{
+						{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+						{Attribute: "email", Operator: OperatorEndsWith, Value: "@example.com"},
+					},
+				}},
+			},
+			{Name: "control", Weight: 0},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		ctx      Context
+		expected string
+	}{
+		{name: "eligible via country", ctx: Context{"user_id": "1", "country": "US"}, expected: "beta"},
+		{name: "eligible via internal tester", ctx: Context{"user_id": "2", "country": "DE", "email": "qa@example.com"}, expected: "beta"},
+		{name: "not eligible", ctx: Context{"user_id": "3", "country": "DE", "email": "jane@mail.com"}, expected: "control"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, _, err := store.GetVariantWithError("beta_checkout", tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if variant != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, variant)
+			}
+		})
+	}
+
+	invalid := &Flag{
+		Name:     "bad_group",
+		Enabled:  true,
+		Variants: []Variant{{Name: "a", Weight: 100, Groups: []ConditionGroup{{Logic: LogicOr}}}},
+	}
+	var validationErr *FlagValidationError
+	if err := invalid.Validate(); !errors.As(err, &validationErr) || validationErr.Field != "variants[0].groups[0]" {
+		t.Errorf("expected validation error on variants[0].groups[0], got %v", err)
+	}
+}
+
+func TestStore_RolloutFromAttribute(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlag(&Flag{
+		Name:                 "tenant_feature",
+		Enabled:              true,
+		Rollout:              50,
+		RolloutFromAttribute: "tenant_rollout",
+	})
+
+	tests := []struct {
+		name     string
+		rollout  interface{}
+		expected int
+	}{
+		{name: "low tenant rollout", rollout: 10, expected: 10},
+		{name: "high tenant rollout", rollout: 90.0, expected: 90},
+		{name: "string rollout", rollout: "30", expected: 30},
+		{name: "missing attribute falls back", rollout: nil, expected: 50},
+		{name: "out of range falls back", rollout: 150, expected: 50},
+		{name: "invalid value falls back", rollout: "lots", expected: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enabled := 0
+			for i := 0; i < 1000; i++ {
+				ctx := Context{"user_id": i}
+				if tt.rollout != nil {
+					ctx["tenant_rollout"] = tt.rollout
+				}
+				if store.IsEnabled("tenant_feature", ctx) {
+					enabled++
+				}
+			}
+			if diff := enabled - tt.expected*10; diff < -60 || diff > 60 {
+				t.Errorf("expected roughly %d%% enabled, got %d/1000", tt.expected, enabled)
+			}
+		})
+	}
+}
+
+func TestStore_RolloutFromAttributeOverride(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlag(&Flag{
+		Name:                 "tenant_feature",
+		Enabled:              true,
+		Rollout:              50,
+		RolloutFromAttribute: "tenant_rollout",
+	})
+
+	rollout := 0
+	if err := store.SetOverride("tenant_feature", Override{Rollout: &rollout}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The override's rollout wins over the attribute-supplied one
+	for i := 0; i < 100; i++ {
+		if store.IsEnabled("tenant_feature", Context{"user_id": i, "tenant_rollout": 100}) {
+			t.Fatalf("expected override rollout 0 to disable user %d", i)
+		}
+	}
+}
+
+func TestStore_GetVariantAndPayload(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlag(&Flag{
+		Name:           "button_test",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Variants: []Variant{
+			{Name: "control", Weight: 50, Payload: map[string]interface{}{"color": "gray"}},
+			{Name: "blue", Weight: 50, Payload: map[string]interface{}{"color": "blue"}},
+		},
+	})
+
+	expectedPayloads := map[string]string{
+		"control": `{"color":"gray"}`,
+		"blue":    `{"color":"blue"}`,
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		ctx := Context{"user_id": i}
+		variant, payload, enabled := store.GetVariantAndPayload("button_test", ctx)
+		if !enabled {
+			t.Fatalf("expected flag to be enabled for user %d", i)
+		}
+		if string(payload) != expectedPayloads[variant] {
+			t.Errorf("user %d: payload %s does not belong to variant %s", i, payload, variant)
+		}
+
+		// Consistent with GetVariant for the same key
+		if expected, _ := store.GetVariant("button_test", ctx); expected != variant {
+			t.Errorf("user %d: expected variant %s, got %s", i, expected, variant)
+		}
+		seen[variant] = true
+	}
+
+	if len(seen) != 2 {
+		t.Errorf("expected both variants to be assigned, got %v", seen)
+	}
+}
+
+func TestStore_RegisterSet(t *testing.T) {
+	store := NewStore()
+
+	employees := make([]string, 5000)
+	for i := range employees {
+		employees[i] = fmt.Sprintf("emp_%d", i)
+	}
+	store.RegisterSet("employees", employees)
+
+	err := store.AddFlag(&Flag{
+		Name:    "internal_tools",
+		Enabled: true,
+		Rollout: 100,
+		Conditions: []Condition{
+			{Attribute: "user_id", Operator: OperatorIn, Value: "@set:employees"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = store.AddFlag(&Flag{
+		Name:    "customer_survey",
+		Enabled: true,
+		Rollout: 100,
+		Conditions: []Condition{
+			{Attribute: "user_id", Operator: OperatorNotIn, Value: "@set:employees"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		flag     string
+		userID   interface{}
+		expected bool
+	}{
+		{"first employee", "internal_tools", "emp_0", true},
+		{"last employee", "internal_tools", "emp_4999", true},
+		{"customer", "internal_tools", "cust_1", false},
+		{"employee excluded", "customer_survey", "emp_42", false},
+		{"customer included", "customer_survey", "cust_1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := store.IsEnabled(tt.flag, Context{"user_id": tt.userID})
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestStore_RegisterSet_Unknown(t *testing.T) {
+	store := NewStore()
+
+	err := store.AddFlag(&Flag{
+		Name:    "missing_set",
+		Enabled: true,
+		Conditions: []Condition{
+			{Attribute: "user_id", Operator: OperatorIn, Value: "@set:nope"},
+		},
+	})
+	if !errors.Is(err, ErrUnknownSet) {
+		t.Errorf("expected ErrUnknownSet, got %v", err)
+	}
+}
+
+func TestStore_ListConditionIndexed(t *testing.T) {
+	store := NewStore()
+
+	regions := make([]interface{}, 1000)
+	for i := range regions {
+		regions[i] = fmt.Sprintf("region_%d", i)
+	}
+	regions[10] = 42.0 // JSON number alongside strings
+
+	original := &Flag{
+		Name:    "regional",
+		Enabled: true,
+		Rollout: 100,
+		Conditions: []Condition{
+			{Attribute: "region", Operator: OperatorIn, Value: regions},
+			{Attribute: "tier", Operator: OperatorNotIn, Value: []string{"banned"}},
+		},
+	}
+	if err := store.AddFlag(original); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		ctx      Context
+		expected bool
+	}{
+		{"first element", Context{"region": "region_0", "tier": "gold"}, true},
+		{"last element", Context{"region": "region_999", "tier": "gold"}, true},
+		{"numeric element", Context{"region": 42, "tier": "gold"}, true},
+		{"not in list", Context{"region": "region_1000", "tier": "gold"}, false},
+		{"not_in excluded", Context{"region": "region_0", "tier": "banned"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := store.IsEnabled("regional", tt.ctx); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	// The stored flag serializes exactly like the one that was added
+	stored, _ := store.GetFlag("regional")
+	want, _ := json.Marshal(original)
+	got, _ := json.Marshal(stored)
+	if string(got) != string(want) {
+		t.Errorf("expected stored flag to serialize unchanged")
+	}
+}
+
+func BenchmarkStore_IsEnabledInList1000(b *testing.B) {
+	store := NewStore()
+
+	regions := make([]string, 1000)
+	for i := range regions {
+		regions[i] = fmt.Sprintf("region_%d", i)
+	}
+	store.AddFlag(&Flag{
+		Name:    "regional",
+		Enabled: true,
+		Rollout: 100,
+		Conditions: []Condition{
+			{Attribute: "region", Operator: OperatorIn, Value: regions},
+		},
+	})
+	ctx := Context{"user_id": "u1", "region": "region_999"}
+
+	for i := 0; i < b.N; i++ {
+		store.IsEnabled("regional", ctx)
+	}
+}
+
+func TestStore_KillPercent(t *testing.T) {
+	store := NewStore()
+	flag := &Flag{Name: "legacy_search", Enabled: true, Rollout: 80}
+	store.AddFlag(flag)
+
+	const users = 2000
+	enabledBefore := make(map[int]bool)
+	for i := 0; i < users; i++ {
+		if store.IsEnabled("legacy_search", Context{"user_id": i}) {
+			enabledBefore[i] = true
+		}
+	}
+
+	var previouslyKilled map[int]bool
+	for _, kill := range []int{0, 25, 50, 100} {
+		killed := make(map[int]bool)
+		withKill := *flag
+		withKill.KillPercent = kill
+		store.AddFlag(&withKill)
+
+		for i := 0; i < users; i++ {
+			enabled := store.IsEnabled("legacy_search", Context{"user_id": i})
+			if enabled && !enabledBefore[i] {
+				t.Fatalf("kill %d: user %d enabled outside the rollout", kill, i)
+			}
+			if !enabled && enabledBefore[i] {
+				killed[i] = true
+			}
+		}
+
+		// Killed users are a deterministic subset that only grows
+		for i := range previouslyKilled {
+			if !killed[i] {
+				t.Errorf("kill %d: user %d was killed at a lower percentage but is enabled again", kill, i)
+			}
+		}
+		previouslyKilled = killed
+
+		fraction := float64(len(killed)) / float64(len(enabledBefore))
+		expected := float64(kill) / 100
+		if fraction < expected-0.05 || fraction > expected+0.05 {
+			t.Errorf("kill %d: expected ~%.2f of enabled users killed, got %.2f", kill, expected, fraction)
+		}
+	}
+
+	result, _ := store.Evaluate("legacy_search", Context{"user_id": 1})
+	if result.Reason != ReasonKilled && result.Reason != ReasonRolloutExcluded {
+		t.Errorf("expected killed or rollout_excluded, got %s", result.Reason)
+	}
+}
+
+func TestFlag_ValidateKillPercent(t *testing.T) {
+	flag := &Flag{Name: "bad_kill", Enabled: true, KillPercent: 101}
+	if err := flag.Validate(); !errors.Is(err, ErrInvalidRollout) {
+		t.Errorf("expected ErrInvalidRollout, got %v", err)
+	}
+}
+
+func TestStore_LazyContextValues(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlag(&Flag{Name: "simple", Enabled: true, Rollout: 100})
+	store.AddFlag(&Flag{
+		Name:    "high_value",
+		Enabled: true,
+		Rollout: 100,
+		Conditions: []Condition{
+			{Attribute: "lifetime_value", Operator: OperatorGreaterThan, Value: 1000},
+			{Attribute: "lifetime_value", Operator: OperatorLessThan, Value: 5000},
+		},
+	})
+
+	calls := 0
+	ctx := Context{
+		"user_id": "user_1",
+		"lifetime_value": func() interface{} {
+			calls++
+			return 2500
+		},
+	}
+
+	if !store.IsEnabled("simple", ctx) {
+		t.Error("expected simple flag to be enabled")
+	}
+	if calls != 0 {
+		t.Errorf("expected lazy value not to be computed, got %d calls", calls)
+	}
+
+	if !store.IsEnabled("high_value", ctx) {
+		t.Error("expected high_value flag to be enabled")
+	}
+	if calls != 1 {
+		t.Errorf("expected lazy value to be computed once, got %d calls", calls)
+	}
+
+	// The caller's context is left untouched
+	if _, lazy := ctx["lifetime_value"].(func() interface{}); !lazy {
+		t.Error("expected caller context to keep the lazy value")
+	}
+}
+
+func TestStore_LazyContextValuesShared(t *testing.T) {
+	var records []EvaluationRecord
+	var mu sync.Mutex
+	store := NewStore(WithAsyncEvaluationSink(1000, func(batch []EvaluationRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, batch...)
+	}))
+	for _, name := range []string{"checkout.a", "checkout.b", "checkout.c"} {
+		store.AddFlag(&Flag{
+			Name:       name,
+			Enabled:    true,
+			Rollout:    100,
+			Conditions: []Condition{{Attribute: "lifetime_value", Operator: OperatorGreaterThan, Value: 1000}},
+		})
+	}
+
+	var calls int32
+	ctx := Context{
+		"user_id": "user_1",
+		"lifetime_value": func() interface{} {
+			atomic.AddInt32(&calls, 1)
+			return 2500
+		},
+	}
+
+	// Concurrent calls share the caller's map but never write to it
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name, enabled := range store.EvaluatePrefix("checkout.", ctx) {
+				if !enabled {
+					t.Errorf("expected %s to be enabled", name)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// One computation per call, not per flag
+	if got := atomic.LoadInt32(&calls); got != 8 {
+		t.Errorf("expected 8 computations, got %d", got)
+	}
+	if _, lazy := ctx["lifetime_value"].(func() interface{}); !lazy {
+		t.Error("expected caller context to keep the lazy value")
+	}
+
+	store.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 24 {
+		t.Fatalf("expected 24 records, got %d", len(records))
+	}
+	for _, record := range records {
+		if value := record.Context["lifetime_value"]; value != 2500 {
+			t.Errorf("expected the sink to see the resolved value, got %T %v", value, value)
+		}
+	}
+}
+
+func TestStore_Schedule(t *testing.T) {
+	now := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC) // Wednesday
+	store := NewStore(WithClock(func() time.Time { return now }))
+
+	err := store.AddFlag(&Flag{
+		Name:    "business_hours_support",
+		Enabled: true,
+		Rollout: 100,
+		Schedule: &Schedule{
+			Windows: []ScheduleWindow{
+				{Weekdays: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "17:00"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected bool
+	}{
+		{"wednesday midday", time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC), true},
+		{"wednesday at start", time.Date(2025, 10, 15, 9, 0, 0, 0, time.UTC), true},
+		{"wednesday at end", time.Date(2025, 10, 15, 17, 0, 0, 0, time.UTC), false},
+		{"wednesday early", time.Date(2025, 10, 15, 8, 59, 0, 0, time.UTC), false},
+		{"saturday midday", time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC), false},
+		{"sunday midday", time.Date(2025, 10, 19, 12, 0, 0, 0, time.UTC), false},
+	}
+
+	ctx := Context{"user_id": "user_1"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = tt.at
+			if result := store.IsEnabled("business_hours_support", ctx); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	now = time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	result, _ := store.Evaluate("business_hours_support", ctx)
+	if result.Reason != ReasonOutsideSchedule {
+		t.Errorf("expected reason %s, got %s", ReasonOutsideSchedule, result.Reason)
+	}
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		valid    bool
+	}{
+		{"first week of month", Schedule{Windows: []ScheduleWindow{{DaysOfMonth: []int{1, 2, 3, 4, 5, 6, 7}}}}, true},
+		{"with timezone", Schedule{Timezone: "America/New_York", Windows: []ScheduleWindow{{Start: "09:00", End: "17:00"}}}, true},
+		{"no windows", Schedule{}, false},
+		{"unknown weekday", Schedule{Windows: []ScheduleWindow{{Weekdays: []string{"funday"}}}}, false},
+		{"end before start", Schedule{Windows: []ScheduleWindow{{Start: "17:00", End: "09:00"}}}, false},
+		{"bad time", Schedule{Windows: []ScheduleWindow{{Start: "25:00"}}}, false},
+		{"twelve hour suffix", Schedule{Windows: []ScheduleWindow{{Start: "9:30pm"}}}, false},
+		{"trailing input", Schedule{Windows: []ScheduleWindow{{End: "17:00xyz"}}}, false},
+		{"missing minutes", Schedule{Windows: []ScheduleWindow{{Start: "9"}}}, false},
+		{"single digit minutes", Schedule{Windows: []ScheduleWindow{{Start: "9:5"}}}, false},
+		{"past end of day", Schedule{Windows: []ScheduleWindow{{End: "24:30"}}}, false},
+		{"single digit hour", Schedule{Windows: []ScheduleWindow{{Start: "9:30", End: "24:00"}}}, true},
+		{"bad day of month", Schedule{Windows: []ScheduleWindow{{DaysOfMonth: []int{32}}}}, false},
+		{"bad timezone", Schedule{Timezone: "Mars/Olympus", Windows: []ScheduleWindow{{}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("expected valid, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidSchedule) {
+				t.Errorf("expected ErrInvalidSchedule, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStore_ScheduleConcurrentValidate(t *testing.T) {
+	store := NewStore()
+	flag := &Flag{
+		Name:    "after_hours",
+		Enabled: true,
+		Rollout: 100,
+		Schedule: &Schedule{
+			Timezone: "America/New_York",
+			Windows:  []ScheduleWindow{{Start: "18:00", End: "24:00"}},
+		},
+	}
+	if err := store.AddFlag(flag); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Validate is side-effect free, so it may race with evaluation
+	stored, _ := store.GetFlag("after_hours")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			stored.Validate()
+		}()
+		go func() {
+			defer wg.Done()
+			store.IsEnabled("after_hours", Context{"user_id": "1"})
+		}()
+	}
+	wg.Wait()
+
+	if flag.Schedule.loc != nil {
+		t.Error("expected the caller's schedule to be left unchanged")
+	}
+	if stored.Schedule.loc == nil || stored.Schedule.loc.String() != "America/New_York" {
+		t.Errorf("expected the stored schedule to resolve its timezone, got %v", stored.Schedule.loc)
+	}
+}
+
+func TestStore_TTL(t *testing.T) {
+	created := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	now := created
+	store := NewStore(WithClock(func() time.Time { return now }))
+
+	ttl := Duration(7 * 24 * time.Hour)
+	if err := store.AddFlag(&Flag{Name: "temporary_banner", Enabled: true, Rollout: 100, TTL: ttl, CreatedAt: &created}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.AddFlag(&Flag{Name: "permanent", Enabled: true, Rollout: 100}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := Context{"user_id": "user_1"}
+	now = created.Add(6 * 24 * time.Hour)
+	if !store.IsEnabled("temporary_banner", ctx) {
+		t.Errorf("expected flag within TTL to be enabled")
+	}
+	if expired := store.ExpiredFlags(); len(expired) != 0 {
+		t.Errorf("expected no expired flags, got %v", expired)
+	}
+
+	// Reloading the same configuration never extends the lifetime
+	if err := store.AddFlag(&Flag{Name: "temporary_banner", Enabled: true, Rollout: 100, TTL: ttl, CreatedAt: &created}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now = created.Add(8 * 24 * time.Hour)
+	if store.IsEnabled("temporary_banner", ctx) {
+		t.Errorf("expected flag past TTL to be disabled")
+	}
+	result, _ := store.Evaluate("temporary_banner", ctx)
+	if result.Reason != ReasonExpired {
+		t.Errorf("expected reason %s, got %s", ReasonExpired, result.Reason)
+	}
+	if !store.IsEnabled("permanent", ctx) {
+		t.Errorf("expected flag without TTL to stay enabled")
+	}
+	if expired := store.ExpiredFlags(); !reflect.DeepEqual(expired, []string{"temporary_banner"}) {
+		t.Errorf("expected [temporary_banner], got %v", expired)
+	}
+
+	tests := []struct {
+		name  string
+		flag  *Flag
+		field string
+	}{
+		{name: "negative ttl", flag: &Flag{Name: "negative", TTL: Duration(-time.Hour), CreatedAt: &created}, field: "ttl"},
+		{name: "ttl without created_at", flag: &Flag{Name: "unstamped", TTL: ttl}, field: "created_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.flag.Validate()
+			var validationErr *FlagValidationError
+			if !errors.Is(err, ErrInvalidTTL) || !errors.As(err, &validationErr) || validationErr.Field != tt.field {
+				t.Errorf("expected ErrInvalidTTL on %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestDuration_JSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Duration
+		wantErr  bool
+	}{
+		{name: "duration string", input: `"168h"`, expected: Duration(168 * time.Hour)},
+		{name: "nanoseconds", input: `3600000000000`, expected: Duration(time.Hour)},
+		{name: "invalid string", input: `"a week"`, wantErr: true},
+		{name: "wrong type", input: `true`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Duration
+			err := json.Unmarshal([]byte(tt.input), &d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && d != tt.expected {
+				t.Errorf("expected %v, got %v", time.Duration(tt.expected), time.Duration(d))
+			}
+		})
+	}
+
+	data, _ := json.Marshal(Duration(168 * time.Hour))
+	if string(data) != `"168h0m0s"` {
+		t.Errorf("expected %v, got %v", `"168h0m0s"`, string(data))
+	}
+}
+
+func TestFlag_ValidationError(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     *Flag
+		field    string
+		sentinel error
+	}{
+		{
+			name:     "rollout out of range",
+			flag:     &Flag{Name: "too_much", Rollout: 150},
+			field:    "rollout",
+			sentinel: ErrInvalidRollout,
+		},
+		{
+			name: "bad flag condition",
+			flag: &Flag{Name: "bad_cond", Conditions: []Condition{
+				{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+				{Attribute: "", Operator: OperatorEqual, Value: "x"},
+			}},
+			field:    "conditions[1]",
+			sentinel: ErrInvalidCondition,
+		},
+		{
+			name: "bad variant condition operator",
+			flag: &Flag{Name: "bad_variant", Variants: []Variant{
+				{Name: "a", Weight: 50},
+				{Name: "b", Weight: 50, Conditions: []Condition{{Attribute: "plan", Operator: "~="}}},
+			}},
+			field:    "variants[1].conditions[0]",
+			sentinel: ErrInvalidOperator,
+		},
+		{
+			name: "variant weights over 100",
+			flag: &Flag{Name: "overweight", Variants: []Variant{
+				{Name: "a", Weight: 60},
+				{Name: "b", Weight: 60},
+			}},
+			field:    "variants",
+			sentinel: ErrInvalidRollout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewStore().AddFlag(tt.flag)
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("expected %v, got %v", tt.sentinel, err)
+			}
+
+			var validationErr *FlagValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected *FlagValidationError, got %T", err)
+			}
+			if validationErr.FlagName != tt.flag.Name {
+				t.Errorf("expected flag %s, got %s", tt.flag.Name, validationErr.FlagName)
+			}
+			if validationErr.Field != tt.field {
+				t.Errorf("expected field %s, got %s", tt.field, validationErr.Field)
+			}
+			if validationErr.Reason == "" {
+				t.Error("expected a reason")
+			}
+		})
+	}
+}
+
+func TestStore_GetMultivariate(t *testing.T) {
+	store := NewStore()
+
+	err := store.AddFlag(&Flag{
+		Name:    "landing_factorial",
+		Enabled: true,
+		Rollout: 100,
+		Dimensions: []Dimension{
+			{Name: "color", Variants: []Variant{{Name: "blue", Weight: 50}, {Name: "green", Weight: 50}}},
+			{Name: "copy", Variants: []Variant{{Name: "short", Weight: 50}, {Name: "long", Weight: 50}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	combos := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		ctx := Context{"user_id": i}
+		assignment, enabled := store.GetMultivariate("landing_factorial", ctx)
+		if !enabled {
+			t.Fatalf("expected flag to be enabled for user %d", i)
+		}
+
+		// Deterministic
+		again, _ := store.GetMultivariate("landing_factorial", ctx)
+		if again["color"] != assignment["color"] || again["copy"] != assignment["copy"] {
+			t.Fatalf("user %d: assignment changed between calls", i)
+		}
+		combos[assignment["color"]+"/"+assignment["copy"]]++
+	}
+
+	// Independent hashing fills every cell of the 2x2 design roughly evenly
+	for _, combo := range []string{"blue/short", "blue/long", "green/short", "green/long"} {
+		if combos[combo] < 400 || combos[combo] > 600 {
+			t.Errorf("expected ~500 users in %s, got %d", combo, combos[combo])
+		}
+	}
+
+	if _, enabled := store.GetMultivariate("landing_factorial", Context{}); enabled {
+		t.Error("expected flag to be disabled without a rollout key")
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	ctx := NewContext().
+		String("country", "US").
+		Int("age", 25).
+		Float("score", 0.75).
+		Bool("beta", true).
+		Strings("roles", "admin", "editor").
+		Build()
+
+	if v, ok := ctx["country"].(string); !ok || v != "US" {
+		t.Errorf("expected country US, got %v", ctx["country"])
+	}
+	if v, ok := ctx["age"].(int); !ok || v != 25 {
+		t.Errorf("expected age 25, got %v", ctx["age"])
+	}
+	if v, ok := ctx["score"].(float64); !ok || v != 0.75 {
+		t.Errorf("expected score 0.75, got %v", ctx["score"])
+	}
+	if v, ok := ctx["beta"].(bool); !ok || !v {
+		t.Errorf("expected beta true, got %v", ctx["beta"])
+	}
+	if v, ok := ctx["roles"].([]string); !ok || len(v) != 2 || v[0] != "admin" {
+		t.Errorf("expected roles [admin editor], got %v", ctx["roles"])
+	}
+	if len(ctx) != 5 {
+		t.Errorf("expected 5 attributes, got %d", len(ctx))
+	}
+
+	store := NewStore()
+	store.AddFlag(&Flag{
+		Name:       "us_adults",
+		Enabled:    true,
+		Rollout:    100,
+		Conditions: []Condition{{Attribute: "age", Operator: OperatorGreaterThanOrEqual, Value: 18}},
+	})
+	if !store.IsEnabled("us_adults", ctx) {
+		t.Error("expected built context to evaluate like a map literal")
+	}
+}
+
+func TestStore_EligibleVariants(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlag(&Flag{
+		Name:           "regional_pricing",
+		Enabled:        true,
+		DefaultVariant: "standard",
+		Variants: []Variant{
+			{Name: "eu_discount", Weight: 30, Conditions: []Condition{
+				{Attribute: "country", Operator: OperatorIn, Value: []string{"DE", "FR"}},
+			}},
+			{Name: "us_bundle", Weight: 30, Conditions: []Condition{
+				{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+			}},
+			{Name: "euro_zone", Weight: 20, Conditions: []Condition{
+				{Attribute: "currency", Operator: OperatorEqual, Value: "EUR"},
+			}},
+			{Name: "standard", Weight: 20},
+		},
+	})
+
+	eligible, err := store.EligibleVariants("regional_pricing", Context{"country": "DE", "currency": "EUR"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"eu_discount", "euro_zone", "standard"}
+	if len(eligible) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, eligible)
+	}
+	for i := range expected {
+		if eligible[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, eligible)
+		}
+	}
+
+	if _, err := store.EligibleVariants("missing", Context{}); !errors.Is(err, ErrFlagNotFound) {
+		t.Errorf("expected ErrFlagNotFound, got %v", err)
+	}
+}
+
+func TestStore_Overrides(t *testing.T) {
+	store := NewStore()
+
+	store.AddFlag(&Flag{Name: "new_search", Enabled: true, Rollout: 0})
+	store.AddFlag(&Flag{
+		Name:           "checkout_test",
+		Enabled:        true,
+		DefaultVariant: "control",
+		Variants: []Variant{
+			{Name: "control", Weight: 50},
+			{Name: "one_click", Weight: 50, Conditions: []Condition{
+				{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+			}},
+		},
+	})
+
+	ctx := Context{"user_id": "user_1", "country": "DE"}
+
+	if store.IsEnabled("new_search", ctx) {
+		t.Fatal("expected base flag with 0% rollout to be disabled")
+	}
+
+	rollout := 100
+	if err := store.SetOverride("new_search", Override{Rollout: &rollout}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !store.IsEnabled("new_search", ctx) {
+		t.Error("expected rollout override to win over the base flag")
+	}
+
+	disabled := false
+	store.SetOverride("new_search", Override{Enabled: &disabled, Rollout: &rollout})
+	if store.IsEnabled("new_search", ctx) {
+		t.Error("expected enabled override to disable the flag")
+	}
+
+	// The base flag is untouched
+	base, _ := store.GetFlag("new_search")
+	if !base.Enabled || base.Rollout != 0 {
+		t.Errorf("expected base flag to be unchanged, got enabled=%v rollout=%d", base.Enabled, base.Rollout)
+	}
+
+	store.ClearOverride("new_search")
+	if store.IsEnabled("new_search", ctx) {
+		t.Error("expected base behavior after clearing the override")
+	}
+
+	store.SetOverride("checkout_test", Override{Variant: "one_click"})
+	for i := 0; i < 20; i++ {
+		variant, enabled := store.GetVariant("checkout_test", Context{"user_id": i, "country": "DE"})
+		if variant != "one_click" || !enabled {
+			t.Fatalf("expected forced variant one_click, got %s (enabled=%v)", variant, enabled)
+		}
+	}
+
+	store.ClearOverride("checkout_test")
+	if variant, _ := store.GetVariant("checkout_test", ctx); variant != "control" {
+		t.Errorf("expected control after clearing the override, got %s", variant)
+	}
+
+	invalid := 150
+	if err := store.SetOverride("new_search", Override{Rollout: &invalid}); !errors.Is(err, ErrInvalidRollout) {
+		t.Errorf("expected ErrInvalidRollout, got %v", err)
+	}
+}
+
+func TestStore_ReadOnly(t *testing.T) {
+	store := NewStore(WithReadOnly())
+
+	// The initial load is allowed, in as many calls as needed, until Seal
+	err := store.AddFlags([]*Flag{
+		{Name: "dark_mode", Enabled: true, Rollout: 100},
+		{Name: "beta", Enabled: false},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error on initial load: %v", err)
+	}
+	if err := store.AddFlag(&Flag{Name: "legacy", Enabled: true}); err != nil {
+		t.Fatalf("unexpected error on initial load: %v", err)
+	}
+	off := false
+	if err := store.SetOverride("legacy", Override{Enabled: &off}); err != nil {
+		t.Fatalf("unexpected error on initial load: %v", err)
+	}
+	store.Seal()
+
+	rollout := 100
+	mutations := []struct {
+		name   string
+		mutate func() error
+	}{
+		{"AddFlag", func() error { return store.AddFlag(&Flag{Name: "sneaky", Enabled: true}) }},
+		{"AddFlags", func() error { return store.AddFlags([]*Flag{{Name: "sneaky", Enabled: true}}) }},
+		{"ReplaceAll", func() error { return store.ReplaceAll([]*Flag{{Name: "sneaky", Enabled: true}}) }},
+		{"RenameFlag", func() error { return store.RenameFlag("beta", "gamma") }},
+		{"PatchFlag", func() error { return store.PatchFlag("beta", []byte(`[]`)) }},
+		{"Merge", func() error { return store.Merge(NewStore(), MergeOverride) }},
+		{"SetOverride", func() error { return store.SetOverride("beta", Override{Rollout: &rollout}) }},
+		{"TryRemoveFlag", func() error { return store.TryRemoveFlag("dark_mode") }},
+		{"TryClear", func() error { return store.TryClear() }},
+		{"TryClearOverride", func() error { return store.TryClearOverride("legacy") }},
+		{"TryRegisterSet", func() error { return store.TryRegisterSet("employees", []string{"u1"}) }},
+	}
+
+	for _, tt := range mutations {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.mutate(); !errors.Is(err, ErrReadOnly) {
+				t.Errorf("expected ErrReadOnly, got %v", err)
+			}
+		})
+	}
+
+	// Mutators without an error result leave the store unchanged
+	store.RemoveFlag("dark_mode")
+	store.Clear()
+	store.ClearOverride("legacy")
+	store.RegisterSet("employees", []string{"u1"})
+
+	if store.Size() != 3 {
+		t.Errorf("expected 3 flags, got %d", store.Size())
+	}
+	if _, ok := store.GetOverride("legacy"); !ok {
+		t.Error("expected the override to be kept")
+	}
+	if !store.IsEnabled("dark_mode", Context{"user_id": "u1"}) {
+		t.Error("expected evaluation to keep working in read-only mode")
+	}
+}
+
+func TestStore_EvaluationTimeout(t *testing.T) {
+	var reported error
+	store := NewStore(
+		WithEvaluationTimeout(20*time.Millisecond),
+		WithSafeMode(func(flag string, err error) { reported = err }),
+	)
+
+	store.AddFlag(&Flag{
+		Name:    "slow_flag",
+		Enabled: true,
+		Rollout: 100,
+		Conditions: []Condition{
+			{Attribute: "risk_score", Operator: OperatorLessThan, Value: 50},
+			{Attribute: "fraud_score", Operator: OperatorLessThan, Value: 50},
+		},
+	})
+	store.AddFlag(&Flag{Name: "fast_flag", Enabled: true, Rollout: 100})
+
+	// Deliberately slow attributes stand in for misbehaving conditions
+	var fraudChecks int32
+	ctx := Context{
+		"user_id": "user_1",
+		"risk_score": func() interface{} {
+			time.Sleep(50 * time.Millisecond)
+			return 10
+		},
+		"fraud_score": func() interface{} {
+			atomic.AddInt32(&fraudChecks, 1)
+			time.Sleep(50 * time.Millisecond)
+			return 10
+		},
+	}
+
+	start := time.Now()
+	if store.IsEnabled("slow_flag", ctx) {
+		t.Error("expected the safe default when evaluation times out")
+	}
+	if elapsed := time.Since(start); elapsed > 90*time.Millisecond {
+		t.Errorf("expected evaluation to stop after the first slow condition, took %s", elapsed)
+	}
+	if !errors.Is(reported, ErrEvaluationTimeout) {
+		t.Errorf("expected ErrEvaluationTimeout to be reported, got %v", reported)
+	}
+	if n := atomic.LoadInt32(&fraudChecks); n != 0 {
+		t.Errorf("expected no condition to run past the deadline, got %d", n)
+	}
+
+	if _, err := store.IsEnabledWithError("slow_flag", ctx); !errors.Is(err, ErrEvaluationTimeout) {
+		t.Errorf("expected ErrEvaluationTimeout, got %v", err)
+	}
+
+	if !store.IsEnabled("fast_flag", ctx) {
+		t.Error("expected fast flag to evaluate normally under the timeout")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	store := NewStore()
+	store.AddFlags([]*Flag{
+		{Name: "unchanged", Enabled: true, Rollout: 50},
+		{Name: "toggled", Enabled: true, Rollout: 100},
+		{Name: "ramped", Enabled: true, Rollout: 10},
+		{Name: "retargeted", Enabled: true, Rollout: 100, Conditions: []Condition{
+			{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+		}},
+		{Name: "retired", Enabled: true},
+		{Name: "experiment", Enabled: true, Variants: []Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50}}},
+	})
+	old := store.Snapshot()
+
+	store.AddFlag(&Flag{Name: "toggled", Enabled: false, Rollout: 100})
+	store.AddFlag(&Flag{Name: "ramped", Enabled: true, Rollout: 25})
+	store.AddFlag(&Flag{Name: "retargeted", Enabled: true, Rollout: 100, Conditions: []Condition{
+		{Attribute: "country", Operator: OperatorEqual, Value: "CA"},
+	}})
+	store.RemoveFlag("retired")
+	store.AddFlag(&Flag{Name: "launched", Enabled: true, Rollout: 5})
+	store.AddFlag(&Flag{Name: "experiment", Enabled: true, Variants: []Variant{{Name: "a", Weight: 20}, {Name: "b", Weight: 80}}})
+	current := store.Snapshot()
+
+	if old.Len() != 6 || current.Len() != 6 {
+		t.Fatalf("expected 6 flags in each snapshot, got %d and %d", old.Len(), current.Len())
+	}
+	if flag, _ := old.Flag("toggled"); !flag.Enabled {
+		t.Error("expected the old snapshot to be unaffected by later changes")
+	}
+
+	expected := []FlagChange{
+		{Flag: "experiment", Kind: ChangeUpdated},
+		{Flag: "launched", Kind: ChangeAdded},
+		{Flag: "ramped", Kind: ChangeRolloutChanged, Old: 10, New: 25},
+		{Flag: "retargeted", Kind: ChangeConditionsChanged},
+		{Flag: "retired", Kind: ChangeRemoved},
+		{Flag: "toggled", Kind: ChangeEnabledToggled, Old: true, New: false},
+	}
+
+	changes := Diff(old, current)
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %+v", len(expected), len(changes), changes)
+	}
+	for i, want := range expected {
+		got := changes[i]
+		if got.Flag != want.Flag || got.Kind != want.Kind {
+			t.Errorf("change %d: expected %s %s, got %s %s", i, want.Flag, want.Kind, got.Flag, got.Kind)
+		}
+		if want.Old != nil && (got.Old != want.Old || got.New != want.New) {
+			t.Errorf("change %d: expected %v -> %v, got %v -> %v", i, want.Old, want.New, got.Old, got.New)
+		}
+	}
+
+	if changes := Diff(current, current); len(changes) != 0 {
+		t.Errorf("expected no changes between identical snapshots, got %+v", changes)
+	}
+
+	// Fields without their own kind are still compared
+	base := Flag{Name: "checkout", Enabled: true, Rollout: 100}
+	edits := map[string]func(*Flag){
+		"kill_percent":    func(f *Flag) { f.KillPercent = 5 },
+		"required":        func(f *Flag) { f.RequiredAttributes = []string{"plan"} },
+		"rollout_key":     func(f *Flag) { f.RolloutKey = "account_id" },
+		"salt":            func(f *Flag) { f.Salt = "v2" },
+		"dry_run":         func(f *Flag) { f.DryRun = true },
+		"default_variant": func(f *Flag) { f.DefaultVariant = "off" },
+	}
+	for name, edit := range edits {
+		t.Run(name, func(t *testing.T) {
+			before, after := NewStore(), NewStore()
+			edited := base
+			edit(&edited)
+			before.AddFlag(&base)
+			after.AddFlag(&edited)
+
+			changes := Diff(before.Snapshot(), after.Snapshot())
+			if len(changes) != 1 || changes[0].Kind != ChangeUpdated {
+				t.Errorf("expected one %s change, got %+v", ChangeUpdated, changes)
+			}
+		})
+	}
+}
+
+func TestStore_GetFlagJSON(t *testing.T) {
+	store := NewStore()
+	store.AddFlag(&Flag{
+		Name:    "premium_feature",
+		Enabled: true,
+		Rollout: 50,
+		Conditions: []Condition{
+			{Attribute: "plan", Operator: OperatorIn, Value: []string{"pro", "enterprise"}},
+			{Attribute: "age", Operator: OperatorGreaterThanOrEqual, Value: 18, Negate: true},
+		},
+	})
+
+	data, err := store.GetFlagJSON("premium_feature")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"name":"premium_feature","enabled":true,"rollout":50,"conditions":[` +
+		`{"attribute":"plan","operator":"in","value":["pro","enterprise"]},` +
+		`{"attribute":"age","operator":">=","value":18,"negate":true}]}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+
+	if _, err := store.GetFlagJSON("missing"); !errors.Is(err, ErrFlagNotFound) {
+		t.Errorf("expected ErrFlagNotFound, got %v", err)
+	}
+}
+
+func TestStore_GetFlagJSONRoundTrip(t *testing.T) {
+	store := NewStore()
+	store.RegisterSet("employees", []string{"emp_1", "emp_2"})
+	store.AddFlags([]*Flag{
+		{Name: "internal", Enabled: true, Rollout: 100, Conditions: []Condition{
+			{Attribute: "user_id", Operator: OperatorIn, Value: SetRefPrefix + "employees"},
+		}},
+		{Name: "internal_beta", Enabled: true, Rollout: 100, Extends: "internal", Conditions: []Condition{
+			{Attribute: "beta_tester", Operator: OperatorEqual, Value: true},
+		}},
+	})
+
+	data, err := store.GetFlagJSON("internal_beta")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"name":"internal_beta","extends":"internal","enabled":true,"rollout":100,"conditions":[` +
+		`{"attribute":"beta_tester","operator":"==","value":true}]}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+
+	var reloaded Flag
+	if err := json.Unmarshal(data, &reloaded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.AddFlag(&reloaded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	flag, _ := store.GetFlag("internal_beta")
+	if len(flag.Conditions) != 2 {
+		t.Errorf("expected the parent and own condition once each, got %d conditions", len(flag.Conditions))
+	}
+	if again, _ := store.GetFlagJSON("internal_beta"); string(again) != string(data) {
+		t.Errorf("expected %s after reloading, got %s", data, again)
+	}
+}
+
+func TestStore_NonStickyVariants(t *testing.T) {
+	store := NewStore(WithRandom(rand.New(rand.NewSource(42))))
+
+	nonSticky := false
+	store.AddFlag(&Flag{
+		Name:    "promo_banner",
+		Enabled: true,
+		Sticky:  &nonSticky,
+		Variants: []Variant{
+			{Name: "red", Weight: 50},
+			{Name: "blue", Weight: 50},
+		},
+	})
+
+	// The same draws from an identically seeded source predict every assignment
+	expected := rand.New(rand.NewSource(42))
+	ctx := Context{"user_id": "user_1"}
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		want := "red"
+		if expected.Intn(100) >= 50 {
+			want = "blue"
+		}
+		variant, enabled := store.GetVariant("promo_banner", ctx)
+		if !enabled || variant != want {
+			t.Fatalf("draw %d: expected %s, got %s (enabled=%v)", i, want, variant, enabled)
+		}
+		seen[variant] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("expected the same user to see both variants, got %v", seen)
+	}
+}
+
+func TestStore_StickyByDefault(t *testing.T) {
+	store := NewStore(WithRandom(rand.New(rand.NewSource(1))))
+
+	sticky := true
+	for _, flag := range []*Flag{
+		{Name: "implicit", Enabled: true, Variants: []Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50}}},
+		{Name: "explicit", Enabled: true, Sticky: &sticky, Variants: []Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50}}},
+	} {
+		store.AddFlag(flag)
+		strategy := NewDefaultRolloutStrategy(nil)
+		for i := 0; i < 50; i++ {
+			ctx := Context{"user_id": i}
+			want, _ := strategy.GetVariant(flag, ctx)
+			for j := 0; j < 3; j++ {
+				if got, _ := store.GetVariant(flag.Name, ctx); got != want {
+					t.Fatalf("%s: user %d expected %s, got %s", flag.Name, i, want, got)
+				}
+			}
+		}
+	}
+}
+
+// recordingResolver serves attributes from a map and records requested keys
+type recordingResolver struct {
+	attrs     map[string]interface{}
+	requested map[string]int
+}
+
+func (r *recordingResolver) Resolve(key string) (interface{}, bool) {
+	r.requested[key]++
+	value, ok := r.attrs[key]
+	return value, ok
+}
+
+func TestStore_IsEnabledResolver(t *testing.T) {
+	store := NewStore()
+	store.AddFlag(&Flag{
+		Name:    "regional_launch",
+		Enabled: true,
+		Rollout: 100,
+		Conditions: []Condition{
+			{Attribute: "country", Operator: OperatorEqual, Value: "US"},
+		},
+	})
+
+	resolver := &recordingResolver{
+		attrs: map[string]interface{}{
+			"user_id":        "user_1",
+			"country":        "US",
+			"lifetime_value": 5000,
+			"segments":       []string{"vip"},
+		},
+		requested: make(map[string]int),
+	}
+
+	if !store.IsEnabledResolver("regional_launch", resolver) {
+		t.Error("expected flag to be enabled")
+	}
+
+	expected := map[string]int{"user_id": 1, "country": 1}
+	if len(resolver.requested) != len(expected) {
+		t.Errorf("expected requests %v, got %v", expected, resolver.requested)
+	}
+	for key, count := range expected {
+		if resolver.requested[key] != count {
+			t.Errorf("expected %s to be requested %d time(s), got %d", key, count, resolver.requested[key])
+		}
+	}
+
+	lookup := AttributeResolverFunc(func(key string) (interface{}, bool) {
+		if key == "country" {
+			return "CA", true
+		}
+		return nil, false
+	})
+	if store.IsEnabledResolver("regional_launch", lookup) {
+		t.Error("expected flag to be disabled for a non-matching country")
+	}
+}
+
+func TestStore_RampJitter(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	store := NewStore(WithClock(func() time.Time { return now }))
+
+	// A near-instant jump from 0% to 50%, spread over ten minutes of jitter
+	store.AddFlag(&Flag{
+		Name:       "new_feed",
+		Enabled:    true,
+		Ramp:       &Ramp{From: 0, To: 50, Start: start, End: start.Add(time.Second)},
+		RampJitter: Duration(10 * time.Minute),
+	})
+
+	enabledFraction := func() float64 {
+		enabled := 0
+		for i := 0; i < 2000; i++ {
+			if store.IsEnabled("new_feed", Context{"user_id": i}) {
+				enabled++
+			}
+		}
+		return float64(enabled) / 2000
+	}
+
+	var fractions []float64
+	for minute := 0; minute <= 12; minute += 2 {
+		now = start.Add(time.Duration(minute)*time.Minute + time.Second)
+		fractions = append(fractions, enabledFraction())
+	}
+
+	if fractions[0] > 0.1 {
+		t.Errorf("expected few users right after the jump, got %.2f", fractions[0])
+	}
+	for i := 1; i < len(fractions); i++ {
+		if fractions[i] < fractions[i-1] {
+			t.Errorf("expected enabled fraction to grow, got %v", fractions)
+		}
+	}
+	// Midway through the window roughly half of the eventual 50% is enabled
+	if fractions[3] < 0.15 || fractions[3] > 0.35 {
+		t.Errorf("expected ~25%% enabled after 6 minutes, got %.2f", fractions[3])
+	}
+	if last := fractions[len(fractions)-1]; last < 0.45 || last > 0.55 {
+		t.Errorf("expected ~50%% enabled after the window, got %.2f", last)
+	}
+}
+
+func TestStore_ReplaceAll(t *testing.T) {
+	store := NewStore()
+	err := store.AddFlags([]*Flag{
+		{Name: "kept", Enabled: true},
+		{Name: "updated", Enabled: true, Rollout: 10},
+		{Name: "removed", Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var events []string
+	unsubscribe := store.Subscribe(func(change FlagChange) {
+		events = append(events, fmt.Sprintf("%s:%s", change.Kind, change.Flag))
+	})
+
+	err = store.ReplaceAll([]*Flag{
+		{Name: "kept", Enabled: true},
+		{Name: "updated", Enabled: true, Rollout: 50},
+		{Name: "added", Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"added:added", "removed:removed", "updated:updated"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected %v, got %v", expected, events)
+	}
+	if got := store.ListFlags(); len(got) != 3 {
+		t.Errorf("expected 3 flags, got %v", got)
+	}
+	if rollout, _ := store.EffectiveRollout("updated"); rollout != 50 {
+		t.Errorf("expected 50, got %d", rollout)
+	}
+
+	// An invalid batch leaves the store and subscribers untouched
+	events = nil
+	if err := store.ReplaceAll([]*Flag{{Name: "bad", Rollout: 150}}); !errors.Is(err, ErrInvalidRollout) {
+		t.Errorf("expected %v, got %v", ErrInvalidRollout, err)
+	}
+	if err := store.ReplaceAll([]*Flag{{Name: "orphan", Extends: "kept"}}); !errors.Is(err, ErrFlagNotFound) {
+		t.Errorf("expected %v, got %v", ErrFlagNotFound, err)
+	}
+	if len(events) != 0 || store.Size() != 3 {
+		t.Errorf("expected no events and 3 flags, got %v and %d", events, store.Size())
+	}
+
+	unsubscribe()
+	if err := store.ReplaceAll(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("expected no events after unsubscribe, got %v", events)
+	}
+}
+
+func TestStore_SubscribeMutations(t *testing.T) {
+	store := NewStore()
+
+	var events []FlagChange
+	store.Subscribe(func(change FlagChange) {
+		// Subscribers run outside the store lock
+		store.Size()
+		events = append(events, change)
+	})
+
+	store.AddFlag(&Flag{Name: "checkout", Enabled: true})
+	store.AddFlag(&Flag{Name: "checkout", Enabled: true})
+	store.AddFlag(&Flag{Name: "checkout"})
+	store.RenameFlag("checkout", "payments")
+	store.RemoveFlag("payments")
+	store.RemoveFlag("payments")
+
+	expected := []ChangeKind{ChangeAdded, ChangeUpdated, ChangeRemoved, ChangeAdded, ChangeRemoved}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+	for i, kind := range expected {
+		if events[i].Kind != kind {
+			t.Errorf("event %d: expected %v, got %v", i, kind, events[i].Kind)
+		}
+	}
+	if old, updated := events[1].Old.(*Flag), events[1].New.(*Flag); !old.Enabled || updated.Enabled {
+		t.Errorf("expected enabled flag to be disabled, got %v to %v", old.Enabled, updated.Enabled)
+	}
+}
+
+func TestStore_QuantifiedCondition(t *testing.T) {
+	store := NewStore()
+	err := store.AddFlag(&Flag{
+		Name:    "domestic_only",
+		Enabled: true,
+		Rollout: 100,
+		Conditions: []Condition{{
+			Attribute:    "past_orders",
+			Operator:     OperatorIn,
+			Value:        []interface{}{"US", "CA"},
+			Quantifier:   QuantifierAll,
+			ElementField: "country",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	domestic := Context{"user_id": "u1", "past_orders": []interface{}{
+		map[string]interface{}{"country": "US"},
+		map[string]interface{}{"country": "CA"},
+	}}
+	if !store.IsEnabled("domestic_only", domestic) {
+		t.Error("expected enabled when every order is in the set")
+	}
+	abroad := Context{"user_id": "u1", "past_orders": []interface{}{
+		map[string]interface{}{"country": "US"},
+		map[string]interface{}{"country": "FR"},
+	}}
+	if store.IsEnabled("domestic_only", abroad) {
+		t.Error("expected disabled when an order is outside the set")
+	}
+}
+
+func TestStore_SegmentOverrides(t *testing.T) {
+	store := NewStore()
+	err := store.AddFlag(&Flag{
+		Name:    "checkout_copy",
+		Enabled: true,
+		Variants: []Variant{
+			{Name: "control", Weight: 50},
+			{Name: "treatment", Weight: 50},
+			{Name: "localized", Weight: 0},
+		},
+		SegmentOverrides: []SegmentOverride{{
+			Conditions: []Condition{{Attribute: "country", Operator: OperatorEqual, Value: "DE"}},
+			Variant:    "localized",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+
+		result, err := store.Evaluate("checkout_copy", Context{"user_id": userID, "country": "DE"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Variant != "localized" || !result.Enabled || result.Reason != ReasonSegmentOverride {
+			t.Fatalf("expected localized segment override for %s, got %+v", userID, result)
+		}
+
+		variant, _ := store.GetVariant("checkout_copy", Context{"user_id": userID, "country": "US"})
+		seen[variant] = true
+	}
+
+	expected := map[string]bool{"control": true, "treatment": true}
+	if !reflect.DeepEqual(seen, expected) {
+		t.Errorf("expected %v, got %v", expected, seen)
+	}
+}
+
+func TestFlag_ValidateSegmentOverrides(t *testing.T) {
+	variants := []Variant{{Name: "a", Weight: 100}}
+	country := []Condition{{Attribute: "country", Operator: OperatorEqual, Value: "DE"}}
+
+	tests := []struct {
+		name     string
+		segment  SegmentOverride
+		expected error
+	}{
+		{"unknown variant", SegmentOverride{Conditions: country, Variant: "b"}, ErrInvalidVariant},
+		{"no conditions", SegmentOverride{Variant: "a"}, ErrInvalidCondition},
+		{"invalid condition", SegmentOverride{Conditions: []Condition{{Attribute: "country", Operator: "~"}}, Variant: "a"}, ErrInvalidOperator},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag := &Flag{Name: "f", Variants: variants, SegmentOverrides: []SegmentOverride{tt.segment}}
+			if err := flag.Validate(); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestStore_ContextHashFallback(t *testing.T) {
+	flags := []*Flag{
+		{Name: "half", Enabled: true, Rollout: 50},
+		{Name: "experiment", Enabled: true, Variants: []Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50}}},
+	}
+	anonymous := func(i int) Context {
+		return Context{"device": "ios", "session": fmt.Sprintf("s-%d", i)}
+	}
+
+	plain := NewStore()
+	if err := plain.AddFlags(flags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if plain.IsEnabled("half", anonymous(i)) {
+			t.Fatal("expected contexts without a rollout key to be excluded by default")
+		}
+	}
+
+	store := NewStore(WithContextHashFallback(), WithEvaluationCache(100, time.Minute))
+	if err := store.AddFlags(flags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	enabled := 0
+	variants := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		first := store.IsEnabled("half", anonymous(i))
+		variant, _ := store.GetVariant("experiment", anonymous(i))
+		for j := 0; j < 3; j++ {
+			if store.IsEnabled("half", anonymous(i)) != first {
+				t.Fatalf("expected a stable rollout decision for context %d", i)
+			}
+			if again, _ := store.GetVariant("experiment", anonymous(i)); again != variant {
+				t.Fatalf("expected stable variant %q for context %d, got %q", variant, i, again)
+			}
+		}
+		if first {
+			enabled++
+		}
+		variants[variant]++
+	}
+
+	if enabled < 400 || enabled > 600 {
+		t.Errorf("expected about 500 enabled contexts, got %d", enabled)
+	}
+	if variants["a"] < 400 || variants["b"] < 400 {
+		t.Errorf("expected both variants to be assigned evenly, got %v", variants)
+	}
+
+	// Lazy attributes are left out of the hash and never computed for it
+	computed := false
+	lazy := anonymous(7)
+	lazy["risk"] = func() interface{} {
+		computed = true
+		return "high"
+	}
+	if store.IsEnabled("half", lazy) != store.IsEnabled("half", anonymous(7)) {
+		t.Error("expected a lazy attribute not to change the context hash")
+	}
+	if computed {
+		t.Error("expected the context hash not to compute lazy attributes")
+	}
+}
+
+func TestStore_RolloutKeyRules(t *testing.T) {
+	store := NewStore()
+	variants := []Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50}}
+	err := store.AddFlags([]*Flag{
+		{
+			Name:     "mixed",
+			Enabled:  true,
+			Variants: variants,
+			RolloutKeyRules: []RolloutKeyRule{
+				{Conditions: []Condition{{Attribute: "user_type", Operator: OperatorEqual, Value: "b2b"}}, Key: "account_id"},
+			},
+		},
+		// Reference flags hash under the same salt with a fixed key
+		{Name: "by_account", Salt: "mixed", Enabled: true, Variants: variants, RolloutKey: "account_id"},
+		{Name: "by_user", Salt: "mixed", Enabled: true, Variants: variants},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 50; i++ {
+		b2b := Context{"user_type": "b2b", "user_id": fmt.Sprintf("user_%d", i), "account_id": fmt.Sprintf("acct_%d", i%7)}
+		got, _ := store.GetVariant("mixed", b2b)
+		expected, _ := store.GetVariant("by_account", b2b)
+		if got != expected {
+			t.Errorf("expected b2b context %d to bucket on account_id (%s), got %s", i, expected, got)
+		}
+
+		b2c := Context{"user_type": "b2c", "user_id": fmt.Sprintf("user_%d", i), "account_id": fmt.Sprintf("acct_%d", i%7)}
+		got, _ = store.GetVariant("mixed", b2c)
+		expected, _ = store.GetVariant("by_user", b2c)
+		if got != expected {
+			t.Errorf("expected b2c context %d to bucket on user_id (%s), got %s", i, expected, got)
+		}
+	}
+
+	// Users of one account share its variant
+	first, _ := store.GetVariant("mixed", Context{"user_type": "b2b", "user_id": "u1", "account_id": "acme"})
+	for i := 0; i < 20; i++ {
+		ctx := Context{"user_type": "b2b", "user_id": fmt.Sprintf("u%d", i), "account_id": "acme"}
+		if variant, _ := store.GetVariant("mixed", ctx); variant != first {
+			t.Errorf("expected every acme user to get %s, got %s", first, variant)
+		}
+	}
+
+	invalid := &Flag{Name: "bad", RolloutKeyRules: []RolloutKeyRule{{Conditions: []Condition{{Attribute: "user_type", Operator: OperatorEqual, Value: "b2b"}}}}}
+	var validationErr *FlagValidationError
+	if err := invalid.Validate(); !errors.As(err, &validationErr) || validationErr.Field != "rollout_key_rules[0].key" {
+		t.Errorf("expected rollout_key_rules[0].key validation error, got %v", err)
+	}
+}
+
+func TestFlag_EachConditionList(t *testing.T) {
+	cond := func(attr string) []Condition {
+		return []Condition{{Attribute: attr, Operator: OperatorEqual, Value: "x"}}
+	}
+	flag := &Flag{
+		Name:       "walk",
+		Conditions: cond("flag"),
+		Variants: []Variant{{
+			Name:       "a",
+			Conditions: cond("variant"),
+			Groups:     []ConditionGroup{{Conditions: cond("group"), Groups: []ConditionGroup{{Conditions: cond("nested")}}}},
+		}},
+		SegmentOverrides: []SegmentOverride{{Conditions: cond("segment"), Variant: "a"}},
+		RolloutKeyRules:  []RolloutKeyRule{{Conditions: cond("rule"), Key: "account_id"}},
+		Dimensions:       []Dimension{{Name: "color", Variants: []Variant{{Name: "red", Conditions: cond("dimension")}}}},
+	}
+
+	var attrs []string
+	flag.EachConditionList(func(conditions []Condition) {
+		for _, c := range conditions {
+			attrs = append(attrs, c.Attribute)
+		}
+	})
+	expected := []string{"flag", "variant", "group", "nested", "segment", "rule", "dimension"}
+	if !reflect.DeepEqual(attrs, expected) {
+		t.Errorf("expected %v, got %v", expected, attrs)
+	}
+
+	mapped, err := flag.mapConditionLists(func(conditions []Condition) ([]Condition, error) {
+		return cond("mapped"), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	flag.EachConditionList(func(conditions []Condition) {
+		for _, c := range conditions {
+			if c.Attribute == "mapped" {
+				t.Errorf("expected the original flag to be unchanged")
+			}
+		}
+	})
+	mapped.EachConditionList(func(conditions []Condition) {
+		if conditions[0].Attribute != "mapped" {
+			t.Errorf("expected every list to be mapped, got %v", conditions[0].Attribute)
+		}
+	})
+}
+
+func TestStore_PatchFlag(t *testing.T) {
+	newStore := func(t *testing.T) *Store {
+		store := NewStore()
+		err := store.AddFlag(&Flag{
+			Name:       "checkout",
+			Enabled:    true,
+			Rollout:    10,
+			Conditions: []Condition{{Attribute: "country", Operator: OperatorEqual, Value: "US"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return store
+	}
+
+	t.Run("replace rollout", func(t *testing.T) {
+		store := newStore(t)
+		err := store.PatchFlag("checkout", []byte(`[
+			{"op": "test", "path": "/rollout", "value": 10},
+			{"op": "replace", "path": "/rollout", "value": 50},
+			{"op": "add", "path": "/conditions/-", "value": {"attribute": "plan", "operator": "==", "value": "pro"}}
+		]`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		flag, _ := store.GetFlag("checkout")
+		if flag.Rollout != 50 || len(flag.Conditions) != 2 || !flag.Enabled {
+			t.Errorf("expected rollout 50 with 2 conditions, got %+v", flag)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		patch    string
+		expected error
+	}{
+		{"invalid rollout", `[{"op": "replace", "path": "/rollout", "value": 150}]`, ErrInvalidRollout},
+		{"invalid operator", `[{"op": "replace", "path": "/conditions/0/operator", "value": "~"}]`, ErrInvalidOperator},
+		{"failed test", `[{"op": "test", "path": "/rollout", "value": 20}, {"op": "replace", "path": "/rollout", "value": 50}]`, ErrInvalidPatch},
+		{"missing path", `[{"op": "replace", "path": "/conditions/3/value", "value": "CA"}]`, ErrInvalidPatch},
+		{"unknown field", `[{"op": "add", "path": "/rolout", "value": 50}]`, ErrInvalidPatch},
+		{"rename", `[{"op": "replace", "path": "/name", "value": "payments"}]`, ErrInvalidPatch},
+		{"not a patch", `{"rollout": 50}`, ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStore(t)
+			if err := store.PatchFlag("checkout", []byte(tt.patch)); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+			flag, _ := store.GetFlag("checkout")
+			if flag.Rollout != 10 || flag.Conditions[0].Operator != OperatorEqual {
+				t.Errorf("expected flag unchanged, got %+v", flag)
+			}
+		})
+	}
+
+	if err := newStore(t).PatchFlag("missing", []byte(`[]`)); err != ErrFlagNotFound {
+		t.Errorf("expected %v, got %v", ErrFlagNotFound, err)
+	}
+
+	t.Run("config form", func(t *testing.T) {
+		store := NewStore()
+		store.RegisterSet("employees", []string{"u1"})
+		store.AddFlag(&Flag{
+			Name:       "internal",
+			Enabled:    true,
+			Rollout:    100,
+			Conditions: []Condition{{Attribute: "user_id", Operator: OperatorIn, Value: "@set:employees"}},
+		})
+		store.AddFlag(&Flag{Name: "internal_beta", Enabled: true, Rollout: 100, Extends: "internal"})
+
+		if err := store.PatchFlag("internal_beta", []byte(`[{"op": "replace", "path": "/rollout", "value": 50}]`)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := store.PatchFlag("internal", []byte(`[{"op": "replace", "path": "/rollout", "value": 90}]`)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Set references survive the patch and follow the registered set
+		store.RegisterSet("employees", []string{"u2"})
+		store.PatchFlag("internal", []byte(`[]`))
+		if store.IsEnabled("internal", Context{"user_id": "u1"}) {
+			t.Error("expected patched flag to keep its @set: reference")
+		}
+
+		// The child still extends its parent rather than holding a merged copy
+		flag, _ := store.GetFlag("internal_beta")
+		if flag.Extends != "internal" || len(flag.Conditions) != 1 || flag.Rollout != 50 {
+			t.Errorf("expected child to keep extending its parent, got %+v", flag)
+		}
+	})
+
+	t.Run("concurrent patches", func(t *testing.T) {
+		store := NewStore()
+		store.AddFlag(&Flag{Name: "checkout", Enabled: true, Rollout: 0, RequiredAttributes: []string{"user_id"}})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				patch := fmt.Sprintf(`[{"op": "add", "path": "/required_attributes/-", "value": "attr_%d"}]`, i)
+				if err := store.PatchFlag("checkout", []byte(patch)); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		flag, _ := store.GetFlag("checkout")
+		if len(flag.RequiredAttributes) != 21 {
+			t.Errorf("expected every patch applied, got %d attributes", len(flag.RequiredAttributes))
+		}
+	})
+}
+
+func TestApplyPatchOperation(t *testing.T) {
+	doc := func() interface{} {
+		return map[string]interface{}{
+			"a": []interface{}{1.0, 2.0},
+			"b": map[string]interface{}{"c/d": "x"},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		op       patchOperation
+		expected interface{}
+	}{
+		{"add array index", patchOperation{Op: "add", Path: "/a/1", Value: json.RawMessage(`9`)}, []interface{}{1.0, 9.0, 2.0}},
+		{"remove array index", patchOperation{Op: "remove", Path: "/a/0"}, []interface{}{2.0}},
+		{"move", patchOperation{Op: "move", From: "/b/c~1d", Path: "/a/-"}, []interface{}{1.0, 2.0, "x"}},
+		{"copy", patchOperation{Op: "copy", From: "/a/1", Path: "/a/0"}, []interface{}{2.0, 1.0, 2.0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := applyPatchOperation(doc(), tt.op)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := result.(map[string]interface{})["a"]; !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	for _, op := range []patchOperation{
+		{Op: "remove", Path: "/a/2"},
+		{Op: "add", Path: "/a/01", Value: json.RawMessage(`1`)},
+		{Op: "move", From: "/b", Path: "/b/e"},
+		{Op: "remove", Path: ""},
+		{Op: "frobnicate", Path: "/a"},
+		{Op: "replace", Path: "a"},
+	} {
+		if _, err := applyPatchOperation(doc(), op); err == nil {
+			t.Errorf("expected error for %+v", op)
+		}
+	}
+}
+
+func TestEvaluate_Standalone(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	flags := []*Flag{
+		{Name: "simple", Enabled: true, Rollout: 50},
+		{Name: "targeted", Enabled: true, Rollout: 100, Conditions: []Condition{{Attribute: "country", Operator: OperatorIn, Value: []interface{}{"US", "CA"}}}},
+		{Name: "variants", Enabled: true, DefaultVariant: "control", Variants: []Variant{{Name: "control", Weight: 50}, {Name: "treatment", Weight: 50}}},
+		{Name: "ramp", Enabled: true, Ramp: &Ramp{From: 0, To: 100, Start: now.Add(-time.Hour), End: now.Add(time.Hour)}},
+		{Name: "disabled", Enabled: false},
+	}
+
+	hasher := NewMurmur3Hasher()
+	store := NewStore(WithClock(func() time.Time { return now }), WithRolloutStrategy(NewDefaultRolloutStrategy(hasher)))
+	if err := store.AddFlags(flags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, flag := range flags {
+		for i := 0; i < 50; i++ {
+			ctx := Context{"user_id": fmt.Sprintf("user-%d", i), "country": []string{"US", "DE"}[i%2]}
+
+			expected, err := store.Evaluate(flag.Name, ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := Evaluate(flag, ctx, WithEvalClock(func() time.Time { return now }), WithEvalHasher(hasher))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != expected {
+				t.Errorf("%s for %v: expected %+v, got %+v", flag.Name, ctx, expected, got)
+			}
+		}
+	}
+
+	if _, err := Evaluate(&Flag{Name: "bad", Rollout: 150}, Context{}); !errors.Is(err, ErrInvalidRollout) {
+		t.Errorf("expected %v, got %v", ErrInvalidRollout, err)
+	}
+	if _, err := Evaluate(nil, Context{}); err != ErrFlagNotFound {
+		t.Errorf("expected %v, got %v", ErrFlagNotFound, err)
+	}
+}
@@ -15,6 +15,7 @@ type SwitchbackRolloutStrategy struct {
 	intervalMinutes int
 	startTime       time.Time
 	swapDaily       bool
+	clock           func() time.Time
 	timeProvider    func() time.Time
 }
 
@@ -43,6 +44,14 @@ func WithDailySwap(enabled bool) SwitchbackOption {
 	}
 }
 
+// WithSwitchbackClock sets the time source used to pick the current interval.
+// By default a Store's clock (see WithClock) is used, or time.Now outside a Store
+func WithSwitchbackClock(clock func() time.Time) SwitchbackOption {
+	return func(s *SwitchbackRolloutStrategy) {
+		s.clock = clock
+	}
+}
+
 // NewSwitchbackRolloutStrategy creates a new switchback rollout strategy
 func NewSwitchbackRolloutStrategy(opts ...SwitchbackOption) *SwitchbackRolloutStrategy {
 	s := &SwitchbackRolloutStrategy{
@@ -56,35 +65,68 @@ func NewSwitchbackRolloutStrategy(opts ...SwitchbackOption) *SwitchbackRolloutSt
 	for _, opt := range opts {
 		opt(s)
 	}
+	if s.clock != nil {
+		s.timeProvider = s.clock
+	}
 
 	return s
 }
 
+// useStoreClock picks intervals with the store's clock unless
+// WithSwitchbackClock was given
+func (s *SwitchbackRolloutStrategy) useStoreClock(clock func() time.Time) {
+	if s.clock == nil {
+		s.timeProvider = clock
+	}
+}
+
 // GetCurrentInterval returns which time interval we're currently in
 func (s *SwitchbackRolloutStrategy) GetCurrentInterval() int {
-	now := s.timeProvider()
-	elapsed := now.Sub(s.startTime)
-	intervalDuration := time.Duration(s.intervalMinutes) * time.Minute
-	return int(elapsed / intervalDuration)
+	return s.intervalAt(s.timeProvider())
 }
 
 // GetCurrentDay returns which day number we're in since start time
 func (s *SwitchbackRolloutStrategy) GetCurrentDay() int {
-	now := s.timeProvider()
-	elapsed := now.Sub(s.startTime)
-	return int(elapsed / (24 * time.Hour))
+	return s.dayAt(s.timeProvider())
 }
 
 // GetTimeUntilNextSwitch returns how much time until the next interval switch
 func (s *SwitchbackRolloutStrategy) GetTimeUntilNextSwitch() time.Duration {
 	now := s.timeProvider()
-	elapsed := now.Sub(s.startTime)
-	intervalDuration := time.Duration(s.intervalMinutes) * time.Minute
-	currentInterval := int(elapsed / intervalDuration)
-	nextSwitchTime := s.startTime.Add(time.Duration(currentInterval+1) * intervalDuration)
+	nextSwitchTime := s.intervalStart(s.intervalAt(now) + 1)
 	return nextSwitchTime.Sub(now)
 }
 
+// intervalDuration returns the length of a single switchback interval
+func (s *SwitchbackRolloutStrategy) intervalDuration() time.Duration {
+	return time.Duration(s.intervalMinutes) * time.Minute
+}
+
+// intervalAt returns the interval number containing t
+// Times before the start time fall into negative intervals
+func (s *SwitchbackRolloutStrategy) intervalAt(t time.Time) int {
+	return floorDiv(t.Sub(s.startTime), s.intervalDuration())
+}
+
+// intervalStart returns the start time of an interval number
+func (s *SwitchbackRolloutStrategy) intervalStart(interval int) time.Time {
+	return s.startTime.Add(time.Duration(interval) * s.intervalDuration())
+}
+
+// dayAt returns the day number containing t
+func (s *SwitchbackRolloutStrategy) dayAt(t time.Time) int {
+	return floorDiv(t.Sub(s.startTime), 24*time.Hour)
+}
+
+// floorDiv divides d by unit, rounding toward negative infinity
+func floorDiv(d, unit time.Duration) int {
+	n := d / unit
+	if d%unit < 0 {
+		n--
+	}
+	return int(n)
+}
+
 // ShouldRollout always returns true for switchback tests since all users participate
 func (s *SwitchbackRolloutStrategy) ShouldRollout(flag *Flag, ctx Context) (bool, error) {
 	return true, nil
@@ -93,28 +135,66 @@ func (s *SwitchbackRolloutStrategy) ShouldRollout(flag *Flag, ctx Context) (bool
 // GetVariant returns the current variant based on time interval
 // All users get the same variant at the same time
 func (s *SwitchbackRolloutStrategy) GetVariant(flag *Flag, ctx Context) (string, error) {
-	if !flag.HasVariants() {
-		return flag.DefaultVariant, nil
-	}
-
-	intervalNum := s.GetCurrentInterval()
-	dayNum := s.GetCurrentDay()
+	return s.variantAt(flag, s.timeProvider()), nil
+}
 
+// variantAt returns the variant active at time t
+func (s *SwitchbackRolloutStrategy) variantAt(flag *Flag, t time.Time) string {
 	// Calculate which variant index to use
 	numVariants := len(flag.Variants)
 	if numVariants == 0 {
-		return flag.DefaultVariant, nil
+		return flag.DefaultVariant
 	}
 
-	// Determine base index from interval
-	variantIndex := intervalNum % numVariants
+	intervalNum := s.intervalAt(t)
+	dayNum := s.dayAt(t)
+
+	// Determine base index from interval, keeping it non-negative for
+	// times before the start
+	variantIndex := ((intervalNum % numVariants) + numVariants) % numVariants
 
 	// If daily swap is enabled and we're on an odd day, reverse the order
-	if s.swapDaily && dayNum%2 == 1 {
+	if s.swapDaily && dayNum%2 != 0 {
 		variantIndex = (numVariants - 1) - variantIndex
 	}
 
-	return flag.Variants[variantIndex].Name, nil
+	return flag.Variants[variantIndex].Name
+}
+
+// ScheduleEntry describes one switchback interval
+type ScheduleEntry struct {
+	// Interval is the interval number since the start time
+	Interval int
+
+	// Start is when the interval begins
+	Start time.Time
+
+	// Day is the day number since the start time
+	Day int
+
+	// Variant is the variant active during the interval
+	Variant string
+}
+
+// Schedule returns count consecutive intervals beginning with the one that
+// contains from, along with the variant active in each (honoring daily swap).
+// A count of zero or less returns nil
+func (s *SwitchbackRolloutStrategy) Schedule(flag *Flag, from time.Time, count int) []ScheduleEntry {
+	if count <= 0 {
+		return nil
+	}
+	entries := make([]ScheduleEntry, 0, count)
+	first := s.intervalAt(from)
+	for i := 0; i < count; i++ {
+		start := s.intervalStart(first + i)
+		entries = append(entries, ScheduleEntry{
+			Interval: first + i,
+			Start:    start,
+			Day:      s.dayAt(start),
+			Variant:  s.variantAt(flag, start),
+		})
+	}
+	return entries
 }
 
 // GetSwitchbackInfo returns detailed information about current switchback state
@@ -131,7 +211,7 @@ func (s *SwitchbackRolloutStrategy) GetInfo() SwitchbackInfo {
 		CurrentInterval:  s.GetCurrentInterval(),
 		CurrentDay:       s.GetCurrentDay(),
 		TimeUntilSwitch:  s.GetTimeUntilNextSwitch(),
-		IntervalDuration: time.Duration(s.intervalMinutes) * time.Minute,
+		IntervalDuration: s.intervalDuration(),
 	}
 }
 
@@ -152,6 +232,54 @@ func GetSwitchbackInfo(store *Store) *SwitchbackInfo {
 	return nil
 }
 
+// GetCurrentVariant returns the variant a switchback flag serves right now
+// and whether the flag is enabled, without an evaluation context. Errors are
+// reported to the safe mode callback as with GetVariant
+func (s *Store) GetCurrentVariant(name string) (string, bool) {
+	variant, enabled, err := s.GetCurrentVariantWithError(name)
+	if err != nil && s.reportError(name, err) {
+		if flag, err := s.GetFlag(name); err == nil {
+			return flag.DefaultVariant, false
+		}
+	}
+	return variant, enabled
+}
+
+// GetCurrentVariantWithError is GetCurrentVariant with error information.
+// It returns ErrContextRequired unless the store uses switchback and the
+// flag is sticky and reads no context attributes (conditions, kill switch,
+// dimensions...)
+func (s *Store) GetCurrentVariantWithError(name string) (string, bool, error) {
+	flag, err := s.GetFlag(name)
+	if err != nil {
+		return "", false, err
+	}
+	if _, ok := s.rolloutStrategy.(*SwitchbackRolloutStrategy); !ok || !contextFree(flag) {
+		return "", false, fmt.Errorf("%w: %q", ErrContextRequired, name)
+	}
+
+	result, err := s.evaluateFlag(flag, Context{})
+	if err != nil {
+		return "", false, err
+	}
+	return result.Variant, result.Enabled, nil
+}
+
+// contextFree reports whether a flag's decision under switchback is the same
+// for every context. The rollout key is ignored since switchback doesn't hash
+// it. Non-sticky flags draw a random variant per call and never qualify
+func contextFree(flag *Flag) bool {
+	if !flag.IsSticky() || flag.KillPercent > 0 || len(flag.Dimensions) > 0 {
+		return false
+	}
+	for _, attr := range referencedAttributes(flag) {
+		if attr != flag.GetRolloutKey() {
+			return false
+		}
+	}
+	return true
+}
+
 // String returns a human-readable description of the switchback state
 func (s *SwitchbackRolloutStrategy) String() string {
 	info := s.GetInfo()
@@ -1,9 +1,12 @@
 package toggo
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/pedrampdd/toggo/testutil"
 )
 
 func TestSwitchbackRolloutStrategy_GetCurrentInterval(t *testing.T) {
@@ -441,3 +444,185 @@ func TestSwitchbackRolloutStrategy_String(t *testing.T) {
 		t.Error("String() should provide meaningful description")
 	}
 }
+
+func TestSwitchbackRolloutStrategy_Schedule(t *testing.T) {
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	strategy := NewSwitchbackRolloutStrategy(
+		WithIntervalMinutes(720),
+		WithStartTime(startTime),
+		WithDailySwap(true),
+	)
+
+	flag := &Flag{
+		Name:    "pricing",
+		Enabled: true,
+		Variants: []Variant{
+			{Name: "control", Weight: 50},
+			{Name: "treatment", Weight: 50},
+		},
+	}
+
+	schedule := strategy.Schedule(flag, startTime.Add(time.Hour), 5)
+
+	expected := []ScheduleEntry{
+		{Interval: 0, Start: startTime, Day: 0, Variant: "control"},
+		{Interval: 1, Start: startTime.Add(12 * time.Hour), Day: 0, Variant: "treatment"},
+		{Interval: 2, Start: startTime.Add(24 * time.Hour), Day: 1, Variant: "treatment"},
+		{Interval: 3, Start: startTime.Add(36 * time.Hour), Day: 1, Variant: "control"},
+		{Interval: 4, Start: startTime.Add(48 * time.Hour), Day: 2, Variant: "control"},
+	}
+
+	if len(schedule) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(schedule))
+	}
+
+	for i, entry := range schedule {
+		if entry.Interval != expected[i].Interval || !entry.Start.Equal(expected[i].Start) ||
+			entry.Day != expected[i].Day || entry.Variant != expected[i].Variant {
+			t.Errorf("entry %d: expected %+v, got %+v", i, expected[i], entry)
+		}
+
+		// The schedule agrees with GetVariant at that time
+		strategy.timeProvider = func() time.Time { return entry.Start }
+		if variant, _ := strategy.GetVariant(flag, Context{}); variant != entry.Variant {
+			t.Errorf("entry %d: GetVariant returned %s, schedule says %s", i, variant, entry.Variant)
+		}
+	}
+
+	for _, count := range []int{0, -1} {
+		if schedule := strategy.Schedule(flag, startTime, count); schedule != nil {
+			t.Errorf("expected nil schedule for count %d, got %v", count, schedule)
+		}
+	}
+}
+
+func TestSwitchbackRolloutStrategy_ScheduleBeforeStart(t *testing.T) {
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	strategy := NewSwitchbackRolloutStrategy(
+		WithIntervalMinutes(720),
+		WithStartTime(startTime),
+		WithDailySwap(true),
+	)
+
+	flag := &Flag{
+		Name:    "pricing",
+		Enabled: true,
+		Variants: []Variant{
+			{Name: "control", Weight: 50},
+			{Name: "treatment", Weight: 50},
+		},
+	}
+
+	schedule := strategy.Schedule(flag, startTime.Add(-25*time.Hour), 4)
+
+	expected := []ScheduleEntry{
+		{Interval: -3, Start: startTime.Add(-36 * time.Hour), Day: -2, Variant: "treatment"},
+		{Interval: -2, Start: startTime.Add(-24 * time.Hour), Day: -1, Variant: "treatment"},
+		{Interval: -1, Start: startTime.Add(-12 * time.Hour), Day: -1, Variant: "control"},
+		{Interval: 0, Start: startTime, Day: 0, Variant: "control"},
+	}
+
+	if len(schedule) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(schedule))
+	}
+
+	for i, entry := range schedule {
+		if entry.Interval != expected[i].Interval || !entry.Start.Equal(expected[i].Start) ||
+			entry.Day != expected[i].Day || entry.Variant != expected[i].Variant {
+			t.Errorf("entry %d: expected %+v, got %+v", i, expected[i], entry)
+		}
+	}
+}
+
+func TestStore_GetCurrentVariant(t *testing.T) {
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	flag := &Flag{
+		Name:     "pricing",
+		Enabled:  true,
+		Variants: []Variant{{Name: "control", Weight: 50}, {Name: "treatment", Weight: 50}},
+	}
+
+	store := NewStore(WithSwitchback(WithIntervalMinutes(60), WithStartTime(startTime)))
+	strategy := store.rolloutStrategy.(*SwitchbackRolloutStrategy)
+	strategy.timeProvider = func() time.Time { return startTime.Add(90 * time.Minute) }
+	if err := store.AddFlag(flag); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	variant, enabled, err := store.GetCurrentVariantWithError("pricing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !enabled || variant != "treatment" {
+		t.Errorf("expected treatment, got %q (enabled %v)", variant, enabled)
+	}
+	if variant, _ := store.GetCurrentVariant("pricing"); variant != "treatment" {
+		t.Errorf("expected treatment, got %q", variant)
+	}
+
+	t.Run("store clock", func(t *testing.T) {
+		clock := testutil.NewMockClock(startTime.Add(30 * time.Minute))
+		store := NewStore(
+			WithClock(clock.Now),
+			WithSwitchback(WithIntervalMinutes(60), WithStartTime(startTime)),
+		)
+		if err := store.AddFlag(flag.Clone()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if variant, _ := store.GetCurrentVariant("pricing"); variant != "control" {
+			t.Errorf("expected control in the first interval, got %q", variant)
+		}
+		clock.Advance(time.Hour)
+		if variant, _ := store.GetCurrentVariant("pricing"); variant != "treatment" {
+			t.Errorf("expected treatment after the interval boundary, got %q", variant)
+		}
+
+		// An explicit switchback clock wins over the store clock
+		pinned := NewStore(
+			WithClock(clock.Now),
+			WithSwitchback(WithIntervalMinutes(60), WithStartTime(startTime),
+				WithSwitchbackClock(func() time.Time { return startTime })),
+		)
+		pinned.AddFlag(flag.Clone())
+		if variant, _ := pinned.GetCurrentVariant("pricing"); variant != "control" {
+			t.Errorf("expected WithSwitchbackClock to override the store clock, got %q", variant)
+		}
+	})
+
+	t.Run("user hashed flag", func(t *testing.T) {
+		store := NewStore()
+		if err := store.AddFlag(flag.Clone()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, _, err := store.GetCurrentVariantWithError("pricing"); !errors.Is(err, ErrContextRequired) {
+			t.Errorf("expected %v, got %v", ErrContextRequired, err)
+		}
+	})
+
+	t.Run("context conditions", func(t *testing.T) {
+		store := NewStore(WithSwitchback())
+		conditional := flag.Clone()
+		conditional.Conditions = []Condition{{Attribute: "country", Operator: OperatorEqual, Value: "US"}}
+		if err := store.AddFlag(conditional); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, _, err := store.GetCurrentVariantWithError("pricing"); !errors.Is(err, ErrContextRequired) {
+			t.Errorf("expected %v, got %v", ErrContextRequired, err)
+		}
+	})
+
+	t.Run("non-sticky flag", func(t *testing.T) {
+		store := NewStore(WithSwitchback())
+		random := flag.Clone()
+		nonSticky := false
+		random.Sticky = &nonSticky
+		if err := store.AddFlag(random); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, _, err := store.GetCurrentVariantWithError("pricing"); !errors.Is(err, ErrContextRequired) {
+			t.Errorf("expected %v, got %v", ErrContextRequired, err)
+		}
+	})
+}
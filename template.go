@@ -0,0 +1,70 @@
+package toggo
+
+import "strings"
+
+// Delimiters of context attribute templates in condition values
+const (
+	templateOpen  = "{{"
+	templateClose = "}}"
+)
+
+// isTemplate reports whether a condition value contains a template
+func isTemplate(value interface{}) bool {
+	str, ok := value.(string)
+	return ok && strings.Contains(str, templateOpen)
+}
+
+// templateAttributes returns the attributes referenced by a templated value
+func templateAttributes(value interface{}) []string {
+	str, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	var attrs []string
+	for {
+		start := strings.Index(str, templateOpen)
+		if start < 0 {
+			return attrs
+		}
+		end := strings.Index(str[start:], templateClose)
+		if end < 0 {
+			return attrs
+		}
+		attrs = append(attrs, strings.TrimSpace(str[start+len(templateOpen):start+end]))
+		str = str[start+end+len(templateClose):]
+	}
+}
+
+// resolveTemplate substitutes context attributes into a templated value.
+// Returns false if the template is malformed or an attribute is missing
+func resolveTemplate(value string, ctx Context) (interface{}, bool) {
+	var b strings.Builder
+	rest := value
+	for {
+		start := strings.Index(rest, templateOpen)
+		if start < 0 {
+			b.WriteString(rest)
+			return b.String(), true
+		}
+		end := strings.Index(rest[start:], templateClose)
+		if end < 0 {
+			return nil, false
+		}
+
+		attr := strings.TrimSpace(rest[start+len(templateOpen) : start+end])
+		resolved, ok := ctx.Get(attr)
+		if !ok || attr == "" {
+			return nil, false
+		}
+
+		// A value that is a single template keeps the attribute's type
+		if start == 0 && start+end+len(templateClose) == len(value) {
+			return resolved, true
+		}
+
+		b.WriteString(rest[:start])
+		b.WriteString(canonicalString(resolved))
+		rest = rest[start+end+len(templateClose):]
+	}
+}
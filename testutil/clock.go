@@ -0,0 +1,45 @@
+// Package testutil provides helpers for testing code that uses toggo
+package testutil
+
+import (
+	"sync"
+	"time"
+)
+
+// MockClock is a manually controlled time source, safe for concurrent use.
+// Pass its Now method to toggo.WithClock to test schedules, ramps and other
+// time-dependent flags deterministically:
+//
+//	clock := testutil.NewMockClock(start)
+//	store := toggo.NewStore(toggo.WithClock(clock.Now))
+//	clock.Advance(time.Hour)
+type MockClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewMockClock creates a clock stopped at now
+func NewMockClock(now time.Time) *MockClock {
+	return &MockClock{now: now}
+}
+
+// Now returns the clock's current time
+func (c *MockClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d, or backward if d is negative
+func (c *MockClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t
+func (c *MockClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
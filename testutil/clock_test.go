@@ -0,0 +1,54 @@
+package testutil
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMockClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := NewMockClock(start)
+
+	if now := clock.Now(); !now.Equal(start) {
+		t.Errorf("expected %v, got %v", start, now)
+	}
+
+	clock.Advance(90 * time.Minute)
+	if expected := start.Add(90 * time.Minute); !clock.Now().Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, clock.Now())
+	}
+
+	clock.Advance(-time.Hour)
+	if expected := start.Add(30 * time.Minute); !clock.Now().Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, clock.Now())
+	}
+
+	later := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	clock.Set(later)
+	if !clock.Now().Equal(later) {
+		t.Errorf("expected %v, got %v", later, clock.Now())
+	}
+}
+
+func TestMockClock_Concurrent(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewMockClock(start)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				clock.Advance(time.Second)
+				clock.Now()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if expected := start.Add(1000 * time.Second); !clock.Now().Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, clock.Now())
+	}
+}
@@ -0,0 +1,34 @@
+package testutil_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pedrampdd/toggo"
+	"github.com/pedrampdd/toggo/testutil"
+)
+
+func ExampleMockClock() {
+	// Monday 8:00 UTC
+	clock := testutil.NewMockClock(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC))
+	store := toggo.NewStore(toggo.WithClock(clock.Now))
+	store.AddFlag(&toggo.Flag{
+		Name:    "business_hours_banner",
+		Enabled: true,
+		Rollout: 100,
+		Schedule: &toggo.Schedule{
+			Windows: []toggo.ScheduleWindow{{Weekdays: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "17:00"}},
+		},
+	})
+	ctx := toggo.Context{"user_id": "user-1"}
+
+	fmt.Println(store.IsEnabled("business_hours_banner", ctx))
+	clock.Advance(2 * time.Hour)
+	fmt.Println(store.IsEnabled("business_hours_banner", ctx))
+	clock.Set(time.Date(2024, 1, 6, 10, 0, 0, 0, time.UTC)) // Saturday
+	fmt.Println(store.IsEnabled("business_hours_banner", ctx))
+	// Output:
+	// false
+	// true
+	// false
+}
@@ -0,0 +1,214 @@
+package toggo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the string formats accepted for timestamps and dates
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseTimeValue reads a time.Time, a date/timestamp string or unix seconds
+func parseTimeValue(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	case int, int32, int64, float32, float64:
+		secs, err := toFloat64(v)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(int64(secs), 0).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// compareTimes orders a and b as times when both read as one, returning
+// -1, 0 or 1. Comparisons use it so a date written as time.Time, as a date
+// or timestamp string, or as unix seconds compares by instant
+func compareTimes(a, b interface{}) (int, bool) {
+	ta, ok := parseTimeValue(a)
+	if !ok {
+		return 0, false
+	}
+	tb, ok := parseTimeValue(b)
+	if !ok {
+		return 0, false
+	}
+	return ta.Compare(tb), true
+}
+
+// NowAttribute is the reserved condition attribute that evaluates against
+// the store clock instead of the context. The time is in UTC, whatever the
+// location of the clock's times
+const NowAttribute = "@now"
+
+// usesClock reports whether the condition reads the current time rather
+// than a context attribute
+func (c *Condition) usesClock() bool {
+	return c.Attribute == NowAttribute || (c.Attribute == "" && c.Operator.IsTimeOperator())
+}
+
+// parseTimeOfDayRange reads a ["HH:MM", "HH:MM"] value as minutes since midnight
+func parseTimeOfDayRange(value interface{}) (int, int, bool) {
+	if !isList(value) {
+		return 0, 0, false
+	}
+	items := listItems(value)
+	if len(items) != 2 {
+		return 0, 0, false
+	}
+	start, startErr := parseClock(fmt.Sprint(items[0]), 0)
+	end, endErr := parseClock(fmt.Sprint(items[1]), 24*60)
+	if startErr != nil || endErr != nil || start == end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// parseWeekdays reads a non-empty list of day names such as "mon" or "sat"
+func parseWeekdays(value interface{}) ([]time.Weekday, bool) {
+	if !isList(value) {
+		return nil, false
+	}
+	items := listItems(value)
+	if len(items) == 0 {
+		return nil, false
+	}
+	days := make([]time.Weekday, len(items))
+	for i, item := range items {
+		day, ok := weekdays[strings.ToLower(fmt.Sprint(item))]
+		if !ok {
+			return nil, false
+		}
+		days[i] = day
+	}
+	return days, true
+}
+
+// evaluateTimeOfDay checks if a timestamp's time of day, in its own location,
+// is within the [start, end) range
+func evaluateTimeOfDay(ctxValue, condValue interface{}) bool {
+	t, ok := parseTimeValue(ctxValue)
+	if !ok {
+		return false
+	}
+	start, end, ok := parseTimeOfDayRange(condValue)
+	if !ok {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// evaluateDayOfWeek checks if a timestamp, in its own location, falls on a listed day
+func evaluateDayOfWeek(ctxValue, condValue interface{}) bool {
+	t, ok := parseTimeValue(ctxValue)
+	if !ok {
+		return false
+	}
+	days, ok := parseWeekdays(condValue)
+	if !ok {
+		return false
+	}
+	for _, day := range days {
+		if t.Weekday() == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration is a time.Duration that reads and writes configuration as a Go
+// duration string such as "168h". Plain numbers are read as nanoseconds
+type Duration time.Duration
+
+// MarshalJSON writes the duration as a string such as "168h0m0s"
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reads a duration string or a number of nanoseconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var nanos int64
+	if err := json.Unmarshal(data, &nanos); err == nil {
+		*d = Duration(nanos)
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return d.set(value)
+}
+
+// MarshalYAML writes the duration as a string such as "168h0m0s"
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// UnmarshalYAML reads a duration string or a number of nanoseconds
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value interface{}
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return d.set(value)
+}
+
+func (d *Duration) set(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	case int:
+		*d = Duration(v)
+	default:
+		return fmt.Errorf("invalid duration %v", value)
+	}
+	return nil
+}
+
+// parseAge reads a duration value: a time.Duration, a Go duration string such
+// as "168h", or a whole number of days such as "7d"
+func parseAge(value interface{}) (time.Duration, bool) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, v >= 0
+	case string:
+		if days, ok := strings.CutSuffix(v, "d"); ok {
+			n, err := strconv.Atoi(days)
+			if err != nil || n < 0 {
+				return 0, false
+			}
+			return time.Duration(n) * 24 * time.Hour, true
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return 0, false
+		}
+		return d, true
+	}
+	return 0, false
+}
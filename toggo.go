@@ -98,6 +98,24 @@
 //   - starts_with (string starts with)
 //   - ends_with (string ends with)
 //   - regex (regular expression match)
+//   - divisible_by (multiple of an integer)
+//   - within_radius (location within a radius in km of a point)
+//   - bucket_in (hashed 0-99 bucket in list or range)
+//   - semver_satisfies (version satisfies an npm-style range)
+//   - count_gt (list attribute has more than n elements)
+//   - count_lt (list attribute has fewer than n elements)
+//   - not_regex (attribute present and does not match regex)
+//   - time_of_day_between (timestamp's time of day within a range)
+//   - day_of_week_in (timestamp falls on a listed weekday)
+//   - older_than (timestamp is more than a duration before now)
+//   - newer_than (timestamp is less than a duration before now)
+//   - sample_percent (attribute value in a stable sample of n percent of values)
+//   - is_type (attribute is of a type and non-empty)
+//   - percentile_above (percentile rank attribute (0-100) is above value)
+//   - top_percent (percentile rank attribute is in the top value percent)
+//   - is_empty (string, list or map attribute is empty or missing)
+//   - is_not_empty (string, list or map attribute has at least one element)
+//   - length_between (attribute length is within an inclusive range)
 package toggo
 
 const (
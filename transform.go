@@ -0,0 +1,83 @@
+package toggo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Transform normalizes a context value before a condition's operator runs
+type Transform string
+
+const (
+	// TransformLower lowercases a string value
+	TransformLower Transform = "lower"
+
+	// TransformUpper uppercases a string value
+	TransformUpper Transform = "upper"
+
+	// TransformTrim removes leading and trailing whitespace from a string value
+	TransformTrim Transform = "trim"
+
+	// TransformEmailDomain replaces an email address with its domain,
+	// e.g. "jane@example.com" becomes "example.com"
+	TransformEmailDomain Transform = "email_domain"
+)
+
+// IsValid checks if the transform is supported
+func (t Transform) IsValid() bool {
+	switch t {
+	case TransformLower, TransformUpper, TransformTrim, TransformEmailDomain:
+		return true
+	}
+	return false
+}
+
+// apply transforms a string value. It returns false if the value cannot be
+// transformed, such as an email_domain without an "@"
+func (t Transform) apply(s string) (string, bool) {
+	switch t {
+	case TransformLower:
+		return strings.ToLower(s), true
+	case TransformUpper:
+		return strings.ToUpper(s), true
+	case TransformTrim:
+		return strings.TrimSpace(s), true
+	case TransformEmailDomain:
+		at := strings.LastIndex(s, "@")
+		if at < 0 || at == len(s)-1 {
+			return "", false
+		}
+		return s[at+1:], true
+	}
+	return s, true
+}
+
+// applyTransforms runs a condition's transforms over value in order. Only
+// string values are transformed; other values pass through unchanged
+func applyTransforms(value interface{}, transforms []Transform) (interface{}, bool) {
+	s, ok := value.(string)
+	if !ok || len(transforms) == 0 {
+		return value, true
+	}
+	for _, t := range transforms {
+		if s, ok = t.apply(s); !ok {
+			return nil, false
+		}
+	}
+	return s, true
+}
+
+// extractRegex returns the text of pattern's first capture group in value.
+// Returns false if the pattern does not match or the group did not participate
+func extractRegex(value interface{}, pattern string) (string, bool) {
+	re, err := compileRegex(pattern)
+	if err != nil {
+		return "", false
+	}
+	s := fmt.Sprint(value)
+	match := re.FindStringSubmatchIndex(s)
+	if match == nil || match[2] < 0 {
+		return "", false
+	}
+	return s[match[2]:match[3]], true
+}
@@ -0,0 +1,83 @@
+package toggo
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// UsageStats reports how often a flag has been evaluated
+type UsageStats struct {
+	// Evaluations is the number of evaluations since the store was created
+	Evaluations uint64
+
+	// LastEvaluated is the store clock time of the latest evaluation, or
+	// the zero time if the flag has not been evaluated
+	LastEvaluated time.Time
+}
+
+// usageTracker counts evaluations per flag without taking the store lock
+type usageTracker struct {
+	flags sync.Map // flag name -> *flagUsage
+}
+
+// flagUsage holds one flag's counters
+type flagUsage struct {
+	evaluations atomic.Uint64
+	last        atomic.Int64 // unix nanoseconds
+}
+
+// WithUsageTracking records, per flag, how many times it was evaluated and
+// when it was last evaluated, see FlagUsage. Useful to find stale flags.
+// Without it nothing is tracked
+func WithUsageTracking() StoreOption {
+	return func(s *Store) {
+		s.usage = &usageTracker{}
+	}
+}
+
+// record counts an evaluation of flag at now
+func (u *usageTracker) record(flag string, now time.Time) {
+	entry, ok := u.flags.Load(flag)
+	if !ok {
+		entry, _ = u.flags.LoadOrStore(flag, &flagUsage{})
+	}
+	usage := entry.(*flagUsage)
+	usage.evaluations.Add(1)
+
+	// Keep the latest time even if evaluations finish out of order
+	nanos := now.UnixNano()
+	for {
+		last := usage.last.Load()
+		if nanos <= last || usage.last.CompareAndSwap(last, nanos) {
+			return
+		}
+	}
+}
+
+// stats returns a flag's counters, zero if it was never evaluated
+func (u *usageTracker) stats(flag string) UsageStats {
+	entry, ok := u.flags.Load(flag)
+	if !ok {
+		return UsageStats{}
+	}
+	usage := entry.(*flagUsage)
+	stats := UsageStats{Evaluations: usage.evaluations.Load()}
+	if last := usage.last.Load(); last != 0 {
+		stats.LastEvaluated = time.Unix(0, last)
+	}
+	return stats
+}
+
+// FlagUsage returns how often the named flag has been evaluated, as tracked
+// since the store was created with WithUsageTracking. It returns
+// ErrFlagNotFound for unknown flags and zero stats without usage tracking
+func (s *Store) FlagUsage(name string) (UsageStats, error) {
+	if _, err := s.GetFlag(name); err != nil {
+		return UsageStats{}, err
+	}
+	if s.usage == nil {
+		return UsageStats{}, nil
+	}
+	return s.usage.stats(name), nil
+}
@@ -0,0 +1,86 @@
+package toggo
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStore_FlagUsage(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	store := NewStore(WithUsageTracking(), WithClock(clock))
+	store.AddFlag(&Flag{Name: "feature", Enabled: true, Rollout: 100})
+	store.AddFlag(&Flag{Name: "experiment", Enabled: true, Variants: []Variant{{Name: "a", Weight: 100}}})
+	store.AddFlag(&Flag{Name: "stale", Enabled: true, Rollout: 100})
+
+	ctx := Context{"user_id": "u1"}
+	store.IsEnabled("feature", ctx)
+	store.IsEnabled("feature", ctx)
+	store.GetVariant("experiment", ctx)
+
+	stats, err := store.FlagUsage("feature")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Evaluations != 2 {
+		t.Errorf("expected %v, got %v", 2, stats.Evaluations)
+	}
+	if !stats.LastEvaluated.Equal(now) {
+		t.Errorf("expected %v, got %v", now, stats.LastEvaluated)
+	}
+
+	mu.Lock()
+	now = now.Add(time.Hour)
+	mu.Unlock()
+	store.IsEnabled("feature", ctx)
+	if stats, _ := store.FlagUsage("feature"); stats.Evaluations != 3 || !stats.LastEvaluated.Equal(now) {
+		t.Errorf("expected 3 evaluations at %v, got %+v", now, stats)
+	}
+
+	if stats, _ := store.FlagUsage("experiment"); stats.Evaluations != 1 {
+		t.Errorf("expected %v, got %v", 1, stats.Evaluations)
+	}
+	if stats, _ := store.FlagUsage("stale"); stats.Evaluations != 0 || !stats.LastEvaluated.IsZero() {
+		t.Errorf("expected no usage, got %+v", stats)
+	}
+	if _, err := store.FlagUsage("missing"); err != ErrFlagNotFound {
+		t.Errorf("expected ErrFlagNotFound, got %v", err)
+	}
+}
+
+func TestStore_FlagUsage_Concurrent(t *testing.T) {
+	store := NewStore(WithUsageTracking())
+	store.AddFlag(&Flag{Name: "feature", Enabled: true, Rollout: 50})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 250; j++ {
+				store.IsEnabled("feature", Context{"user_id": i*1000 + j})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if stats, _ := store.FlagUsage("feature"); stats.Evaluations != 2000 {
+		t.Errorf("expected %v, got %v", 2000, stats.Evaluations)
+	}
+}
+
+func TestStore_FlagUsage_Disabled(t *testing.T) {
+	store := NewStore()
+	store.AddFlag(&Flag{Name: "feature", Enabled: true, Rollout: 100})
+	store.IsEnabled("feature", Context{"user_id": "u1"})
+
+	if stats, err := store.FlagUsage("feature"); err != nil || stats.Evaluations != 0 {
+		t.Errorf("expected no usage without tracking, got %+v, %v", stats, err)
+	}
+}
@@ -0,0 +1,56 @@
+package toggo
+
+import "time"
+
+// ActiveAt reports whether t falls inside the variant's StartsAt/EndsAt
+// window. StartsAt is inclusive and EndsAt exclusive; an unset bound is open
+func (v *Variant) ActiveAt(t time.Time) bool {
+	if v.StartsAt != nil && t.Before(*v.StartsAt) {
+		return false
+	}
+	if v.EndsAt != nil && !t.Before(*v.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// withActiveVariants returns the flag with variants outside their window at
+// now removed and their weight shared among the active variants in
+// proportion to the active weights, so the total weight is unchanged.
+// Flags whose variants are all active are returned as-is. Sticky users move
+// between variants when one starts or ends, since bucket ranges shift
+func withActiveVariants(flag *Flag, now time.Time) *Flag {
+	total, activeTotal := 0, 0
+	for i := range flag.Variants {
+		total += flag.Variants[i].Weight
+		if flag.Variants[i].ActiveAt(now) {
+			activeTotal += flag.Variants[i].Weight
+		}
+	}
+	if activeTotal == total {
+		return flag
+	}
+
+	effective := *flag
+	effective.Variants = make([]Variant, 0, len(flag.Variants))
+	if activeTotal == 0 {
+		return &effective
+	}
+
+	assigned, last := 0, -1
+	for i := range flag.Variants {
+		variant := flag.Variants[i]
+		if !variant.ActiveAt(now) {
+			continue
+		}
+		variant.Weight = variant.Weight * total / activeTotal
+		assigned += variant.Weight
+		if variant.Weight > 0 {
+			last = len(effective.Variants)
+		}
+		effective.Variants = append(effective.Variants, variant)
+	}
+	// Rounding leftovers go to the last weighted active variant
+	effective.Variants[last].Weight += total - assigned
+	return &effective
+}